@@ -0,0 +1,49 @@
+package consistent
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestMinReplicaArc(t *testing.T) {
+	x := New(Config{DefaultNumberOfReplicas: 5, MinReplicaArc: 0.03})
+	plain := New(Config{DefaultNumberOfReplicas: 5})
+	for _, m := range []string{"a", "b", "c", "d"} {
+		x.Add(m)
+		plain.Add(m)
+	}
+	differ := 0
+	for i := 0; i < 2000; i++ {
+		k := "user" + strconv.Itoa(i)
+		res, _ := x.GetN(k, 2)
+		all, _ := plain.GetN(k, 4)
+		if len(res) != 2 || res[0] != all[0] {
+			t.Fatalf("%s: got %v, expected primary %s", k, res, all[0])
+		}
+		// expected: the first member after the primary reached through a vnode that is
+		// not a sliver
+		start := x.search(x.hashKey(k))
+		expected := ""
+		for j := 1; j < len(x.sortedHashes) && expected == ""; j++ {
+			i := (start + j) % len(x.sortedHashes)
+			if m := x.circle[x.sortedHashes[i]]; m != res[0] && !x.isSliver(i) {
+				expected = m
+			}
+		}
+		if expected == "" {
+			expected = all[1]
+		}
+		if res[1] != expected {
+			t.Errorf("%s: got replica %s, expected %s", k, res[1], expected)
+		}
+		if res[1] != all[1] {
+			differ++
+		}
+	}
+	if differ == 0 {
+		t.Errorf("expected some replicas to avoid slivers")
+	}
+	if res, _ := x.GetN("ggg", 4); len(res) != 4 {
+		t.Errorf("expected slivers to be used when nothing else is left, got %v", res)
+	}
+}
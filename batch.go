@@ -0,0 +1,74 @@
+package consistent
+
+// GetBatch returns the owner of each of names, as Get would, resolving them all with a
+// single acquisition of the ring lock, e.g. to route many keys during a cache warm-up.
+// Hooks registered with AddHook are not run.
+func (c *Consistent) GetBatch(names []string) ([]string, error) {
+	res := make([]string, len(names))
+	if v, _ := c.view.Load().(*readView); v != nil && v.plain {
+		if len(v.hashes) == 0 {
+			c.stats.lookupBatch(len(names), ErrEmptyCircle)
+			return nil, ErrEmptyCircle
+		}
+		for i, name := range names {
+			res[i] = v.owner(c.hashKey(name))
+		}
+	} else {
+		c.RLock()
+		if len(c.circle) == 0 {
+			c.RUnlock()
+			c.stats.lookupBatch(len(names), ErrEmptyCircle)
+			return nil, ErrEmptyCircle
+		}
+		for i, name := range names {
+			m, err := c.owner(name)
+			if err != nil {
+				c.RUnlock()
+				c.stats.lookupBatch(len(names), err)
+				return nil, err
+			}
+			res[i] = m
+		}
+		c.RUnlock()
+	}
+	c.stats.lookupBatch(len(names), nil)
+	if c.rates != nil {
+		for _, m := range res {
+			c.rates.record(m)
+		}
+	}
+	return res, nil
+}
+
+// CommonOwner returns the member owning every one of keys, for applications keeping
+// transactions on a single shard. It reports false if keys have different owners, if there
+// are no keys or if the ring is empty.
+func (c *Consistent) CommonOwner(keys ...string) (string, bool) {
+	if len(keys) == 0 {
+		return "", false
+	}
+	owners, err := c.GetBatch(keys)
+	if err != nil {
+		return "", false
+	}
+	for _, m := range owners[1:] {
+		if m != owners[0] {
+			return "", false
+		}
+	}
+	return owners[0], true
+}
+
+// SplitByOwner groups keys by owner, each group in the order of keys, so a multi-key
+// operation spanning several members can be split into one per member.
+func (c *Consistent) SplitByOwner(keys []string) (map[string][]string, error) {
+	owners, err := c.GetBatch(keys)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string][]string)
+	for i, m := range owners {
+		res[m] = append(res[m], keys[i])
+	}
+	return res, nil
+}
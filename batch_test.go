@@ -0,0 +1,84 @@
+package consistent
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestGetBatch(t *testing.T) {
+	x := New(newConfig())
+	if _, err := x.GetBatch([]string{"ggg"}); err != ErrEmptyCircle {
+		t.Errorf("got %v, expected ErrEmptyCircle", err)
+	}
+	for i := 0; i < 10; i++ {
+		x.Add("member" + strconv.Itoa(i))
+	}
+	x.Group("g", "key3")
+	names := make([]string, 100)
+	for i := range names {
+		names[i] = "key" + strconv.Itoa(i)
+	}
+	for _, grouped := range []bool{true, false} {
+		res, err := x.GetBatch(names)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, name := range names {
+			if m := mustGet(t, x, name); res[i] != m {
+				t.Errorf("grouped %v: %s: got %s, expected %s", grouped, name, res[i], m)
+			}
+		}
+		x.Ungroup("g")
+	}
+}
+
+func BenchmarkGetBatch(b *testing.B) {
+	x := New(newConfig())
+	for i := 0; i < 100; i++ {
+		x.Add("member" + strconv.Itoa(i))
+	}
+	names := make([]string, 1000)
+	for i := range names {
+		names[i] = "key" + strconv.Itoa(i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x.GetBatch(names)
+	}
+}
+
+func TestCommonOwner(t *testing.T) {
+	x := New(newConfig())
+	if _, ok := x.CommonOwner("a"); ok {
+		t.Error("common owner on an empty ring")
+	}
+	x.Set([]string{"a", "b", "c"})
+	if _, ok := x.CommonOwner(); ok {
+		t.Error("common owner of no keys")
+	}
+	var keys []string
+	for i := 0; i < 50; i++ {
+		keys = append(keys, "key"+strconv.Itoa(i))
+	}
+	split, err := x.SplitByOwner(keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for m, group := range split {
+		n += len(group)
+		if owner, ok := x.CommonOwner(group...); !ok || owner != m {
+			t.Errorf("%q: got %s, %v, expected %s", group, owner, ok, m)
+		}
+	}
+	checkNum(n, len(keys), t)
+	if len(split) > 1 {
+		if _, ok := x.CommonOwner(keys...); ok {
+			t.Error("common owner of keys on several members")
+		}
+	}
+	x.Group("g", "key0", "key1")
+	if _, ok := x.CommonOwner("key0", "key1"); !ok {
+		t.Error("grouped keys have different owners")
+	}
+}
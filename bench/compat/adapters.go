@@ -0,0 +1,59 @@
+//go:build compat
+// +build compat
+
+package compat
+
+import (
+	"github.com/golang/groupcache/consistenthash"
+	"github.com/serialx/hashring"
+)
+
+func init() {
+	Register("hashring", func() Ring { return &hashringRing{r: hashring.New(nil)} })
+	Register("groupcache", func() Ring { return &groupcacheRing{} })
+}
+
+type hashringRing struct{ r *hashring.HashRing }
+
+func (h *hashringRing) Add(member string)    { h.r = h.r.AddNode(member) }
+func (h *hashringRing) Remove(member string) { h.r = h.r.RemoveNode(member) }
+func (h *hashringRing) Get(key string) string {
+	m, _ := h.r.GetNode(key)
+	return m
+}
+
+// groupcacheRing rebuilds the map on Remove, which consistenthash does not have.
+type groupcacheRing struct {
+	m       *consistenthash.Map
+	members []string
+}
+
+// groupcacheReplicas is the number of replicas groupcache uses for its peers.
+const groupcacheReplicas = 50
+
+func (g *groupcacheRing) Add(member string) {
+	if g.m == nil {
+		g.m = consistenthash.New(groupcacheReplicas, nil)
+	}
+	g.members = append(g.members, member)
+	g.m.Add(member)
+}
+
+func (g *groupcacheRing) Remove(member string) {
+	g.m = consistenthash.New(groupcacheReplicas, nil)
+	kept := g.members[:0]
+	for _, m := range g.members {
+		if m != member {
+			kept = append(kept, m)
+		}
+	}
+	g.members = kept
+	g.m.Add(kept...)
+}
+
+func (g *groupcacheRing) Get(key string) string {
+	if g.m == nil {
+		return ""
+	}
+	return g.m.Get(key)
+}
@@ -0,0 +1,159 @@
+// Package compat runs identical workloads against this package and other consistent
+// hashing libraries, through adapters, and reports how they compare: lookup speed, balance
+// of the keys over the members and share of the keys moved when a member joins.
+//
+// Only the adapter for this package is built by default. The adapters for
+// github.com/serialx/hashring and github.com/golang/groupcache/consistenthash are behind
+// the compat build tag, so this module does not depend on them. To compare against them:
+//
+//	go get github.com/serialx/hashring github.com/golang/groupcache
+//	go test -tags compat -run TestReport -v ./bench/compat
+package compat
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"testing"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jiangz222/consistent"
+)
+
+// Ring is the surface the workloads use, implemented by an adapter per library.
+type Ring interface {
+	Add(member string)
+	Remove(member string)
+	Get(key string) string
+}
+
+// Factory creates an empty Ring.
+type Factory func() Ring
+
+var factories = map[string]Factory{
+	"consistent": func() Ring { return consistentRing{consistent.New(consistent.Config{})} },
+}
+
+// Register makes the library called name available to Compare.
+func Register(name string, f Factory) {
+	factories[name] = f
+}
+
+// Libraries returns the names of the registered libraries, sorted.
+func Libraries() []string {
+	res := make([]string, 0, len(factories))
+	for name := range factories {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
+
+type consistentRing struct{ c *consistent.Consistent }
+
+func (r consistentRing) Add(member string)    { r.c.Add(member) }
+func (r consistentRing) Remove(member string) { r.c.Remove(member) }
+func (r consistentRing) Get(key string) string {
+	m, _ := r.c.Get(key)
+	return m
+}
+
+// Workload describes the ring and keys every library is run with.
+type Workload struct {
+	Members int
+	Keys    int
+}
+
+// DefaultWorkload is the workload of TestReport.
+var DefaultWorkload = Workload{Members: 50, Keys: 100000}
+
+// Result is how one library did on a workload.
+type Result struct {
+	Library string
+	// Build is the time taken to add every member.
+	Build time.Duration
+	// GetNs is the time of a Get in nanoseconds.
+	GetNs float64
+	// MaxLoad is the largest number of keys of a member over the average, StdDev the
+	// standard deviation of the keys per member over the average.
+	MaxLoad float64
+	StdDev  float64
+	// Moved is the share of the keys changing member when one more member joins, 1/(n+1)
+	// at best.
+	Moved float64
+}
+
+// Compare runs w against every registered library.
+func Compare(w Workload) []Result {
+	var res []Result
+	for _, name := range Libraries() {
+		res = append(res, Run(name, factories[name], w))
+	}
+	return res
+}
+
+// Run runs w against the rings made by f.
+func Run(name string, f Factory, w Workload) Result {
+	r := f()
+	members := make([]string, w.Members)
+	for i := range members {
+		members[i] = "10.0.0." + strconv.Itoa(i) + ":11211"
+	}
+	keys := make([]string, w.Keys)
+	for i := range keys {
+		keys[i] = "user:" + strconv.Itoa(i)
+	}
+
+	start := time.Now()
+	for _, m := range members {
+		r.Add(m)
+	}
+	res := Result{Library: name, Build: time.Since(start)}
+
+	bench := testing.Benchmark(func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			r.Get(keys[i%len(keys)])
+		}
+	})
+	res.GetNs = float64(bench.NsPerOp())
+
+	owners := make([]string, len(keys))
+	loads := make(map[string]int)
+	for i, k := range keys {
+		owners[i] = r.Get(k)
+		loads[owners[i]]++
+	}
+	avg := float64(len(keys)) / float64(len(members))
+	var sq float64
+	for _, m := range members {
+		l := float64(loads[m])
+		sq += (l - avg) * (l - avg)
+		if l/avg > res.MaxLoad {
+			res.MaxLoad = l / avg
+		}
+	}
+	res.StdDev = math.Sqrt(sq/float64(len(members))) / avg
+
+	r.Add("10.0.1.0:11211")
+	moved := 0
+	for i, k := range keys {
+		if r.Get(k) != owners[i] {
+			moved++
+		}
+	}
+	res.Moved = float64(moved) / float64(len(keys))
+	return res
+}
+
+// WriteReport writes results to w as a table.
+func WriteReport(w io.Writer, results []Result) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "library\tbuild\tget ns/op\tmax load\tstddev\tmoved on join")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%v\t%.1f\t%.3f\t%.3f\t%.3f\n", r.Library, r.Build, r.GetNs, r.MaxLoad, r.StdDev, r.Moved)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,25 @@
+package compat
+
+import (
+	"os"
+	"testing"
+)
+
+func TestReport(t *testing.T) {
+	w := DefaultWorkload
+	if testing.Short() {
+		w = Workload{Members: 10, Keys: 10000}
+	}
+	results := Compare(w)
+	if len(results) != len(Libraries()) {
+		t.Fatalf("got %d results for %d libraries", len(results), len(Libraries()))
+	}
+	for _, r := range results {
+		if r.GetNs <= 0 || r.MaxLoad < 1 || r.Moved <= 0 || r.Moved > 0.5 {
+			t.Errorf("%s: implausible result %+v", r.Library, r)
+		}
+	}
+	if testing.Verbose() {
+		WriteReport(os.Stdout, results)
+	}
+}
@@ -0,0 +1,33 @@
+package consistent
+
+import "errors"
+
+// ErrProbeBudgetExceeded is returned by GetNBounded when the probe budget runs out before
+// n distinct elements were found. The elements found so far are returned along with it.
+var ErrProbeBudgetExceeded = errors.New("probe budget exceeded")
+
+// GetNBounded is like GetN but inspects at most maxProbes points of the circle. If the
+// budget runs out first, the partial result is returned together with ErrProbeBudgetExceeded.
+// A maxProbes of 0 or less means no limit. In WeightedRendezvous mode, which ranks every
+// member, the budget does not apply.
+func (c *Consistent) GetNBounded(name string, n, maxProbes int) ([]string, error) {
+	c.RLock()
+	defer c.RUnlock()
+
+	if len(c.circle) == 0 {
+		c.stats.lookup(ErrEmptyCircle)
+		return nil, ErrEmptyCircle
+	}
+	if maxProbes < 0 {
+		maxProbes = 0
+	}
+	res, exceeded, err := c.lookup(name, n, maxProbes, nil)
+	if err == nil && exceeded {
+		err = ErrProbeBudgetExceeded
+	}
+	c.stats.lookup(err)
+	if c.rates != nil && len(res) > 0 {
+		c.rates.record(res[0])
+	}
+	return res, err
+}
@@ -0,0 +1,63 @@
+package consistent
+
+import "testing"
+
+func TestGetNBounded(t *testing.T) {
+	x := New(newConfig())
+	x.Add("abcdefg")
+	x.Add("hijklmn")
+	x.Add("opqrstu")
+	members, err := x.GetNBounded("9999999", 3, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := x.GetN("9999999", 3)
+	checkNum(len(members), len(expected), t)
+	for i := range expected {
+		if members[i] != expected[i] {
+			t.Errorf("wrong members[%d]: %q, expected %q", i, members[i], expected[i])
+		}
+	}
+}
+
+func TestGetNBoundedExceeded(t *testing.T) {
+	x := New(newConfig())
+	x.Add("abcdefg", 1000)
+	x.Add("hijklmn", 1)
+	members, err := x.GetNBounded("9999999", 2, 1)
+	if err != ErrProbeBudgetExceeded {
+		t.Fatalf("expected probe budget error, got %v", err)
+	}
+	checkNum(len(members), 1, t)
+}
+
+func TestGetNBoundedEmpty(t *testing.T) {
+	x := New(newConfig())
+	_, err := x.GetNBounded("9999999", 2, 10)
+	if err != ErrEmptyCircle {
+		t.Errorf("expected empty circle error")
+	}
+}
+
+func TestGetNBoundedLikeGetN(t *testing.T) {
+	x, o := newOverridesRing()
+	o.Pin("pinned", "hijklmn", 0, "")
+	o.Exclude("excluded", "abcdefg", 0, "")
+	x.Group("g", "grouped")
+	x.MarkDown("opqrstu")
+	for _, k := range []string{"pinned", "excluded", "grouped", "9999999"} {
+		want, _ := x.GetN(k, 3)
+		got, err := x.GetNBounded(k, 3, 1000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("%s: got %q, GetN gives %q", k, got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("%s: got %q, GetN gives %q", k, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package consistent
+
+// HashKey returns the hash of key with the hasher of the ring, as GetByHash expects it.
+func (c *Consistent) HashKey(key string) uint32 {
+	return c.hashKey(key)
+}
+
+// GetByHash returns the element owning the keys whose hash is h, for callers that already
+// hashed the key with HashKey or received the hash over the wire, e.g. to hash once at the
+// edge and resolve on several rings sharing a hasher. Groups and overrides, which match
+// keys rather than hashes, are ignored.
+func (c *Consistent) GetByHash(h uint32) (string, error) {
+	if v, _ := c.view.Load().(*readView); v != nil && v.plain {
+		if len(v.hashes) == 0 {
+			c.stats.lookup(ErrEmptyCircle)
+			return "", ErrEmptyCircle
+		}
+		c.stats.lookup(nil)
+		elt := v.owner(h)
+		if c.rates != nil {
+			c.rates.record(elt)
+		}
+		return elt, nil
+	}
+	c.RLock()
+	defer c.RUnlock()
+	if len(c.circle) == 0 {
+		c.stats.lookup(ErrEmptyCircle)
+		return "", ErrEmptyCircle
+	}
+	res, _, err := c.route(h, 1, 0, "", nil, nil)
+	c.stats.lookup(err)
+	if err != nil {
+		return "", err
+	}
+	elt := res[0]
+	if c.rates != nil {
+		c.rates.record(elt)
+	}
+	return elt, nil
+}
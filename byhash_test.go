@@ -0,0 +1,28 @@
+package consistent
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestGetByHash(t *testing.T) {
+	for _, conf := range []Config{newConfig(), {DefaultNumberOfReplicas: 20, WeightedRendezvous: true}} {
+		x := New(conf)
+		if _, err := x.GetByHash(0); err != ErrEmptyCircle {
+			t.Errorf("got %v, expected ErrEmptyCircle", err)
+		}
+		x.Add("abcdefg", 10)
+		x.Add("hijklmn", 20)
+		x.Add("opqrstu", 30)
+		for i := 0; i < 1000; i++ {
+			key := "key" + strconv.Itoa(i)
+			got, err := x.GetByHash(x.HashKey(key))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := mustGet(t, x, key); got != want {
+				t.Errorf("%s: got %s, expected %s", key, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package consistent
+
+// BytesHasher is implemented by custom hashers that can hash a []byte key without
+// converting it to a string, for GetBytes. HashBytes must return what HashFunc returns for
+// the same key as a string.
+type BytesHasher interface {
+	HashBytes(key []byte) uint32
+}
+
+// GetBytes returns the element Get returns for string(key), without converting key to a
+// string while the ring is plain, that is without groups, overrides or weighted mode, and
+// its hasher builtin or a BytesHasher. It suits callers routing binary IDs or encoded keys
+// on a hot path. Hooks see the key as a string.
+func (c *Consistent) GetBytes(key []byte) (string, error) {
+	if hs := c.hooks.load(); hs != nil {
+		return c.getBytesHooked(hs, key)
+	}
+	return c.getBytes(key)
+}
+
+// getBytesHooked keeps the deferred hook call out of GetBytes, whose results would
+// otherwise escape to the heap.
+func (c *Consistent) getBytesHooked(hs []hookEntry, key []byte) (elt string, err error) {
+	defer around(hs, "Get", string(key))(&elt, &err, nil)
+	return c.getBytes(key)
+}
+
+func (c *Consistent) getBytes(key []byte) (string, error) {
+	if v, _ := c.view.Load().(*readView); v != nil && v.plain {
+		if len(v.hashes) == 0 {
+			c.stats.lookup(ErrEmptyCircle)
+			return "", ErrEmptyCircle
+		}
+		c.stats.lookup(nil)
+		elt := v.owner(c.hashBytes(key))
+		if c.rates != nil {
+			c.rates.record(elt)
+		}
+		return elt, nil
+	}
+	c.RLock()
+	defer c.RUnlock()
+	if len(c.circle) == 0 {
+		c.stats.lookup(ErrEmptyCircle)
+		return "", ErrEmptyCircle
+	}
+	elt, err := c.owner(string(key))
+	c.stats.lookup(err)
+	if err != nil {
+		return "", err
+	}
+	if c.rates != nil {
+		c.rates.record(elt)
+	}
+	return elt, nil
+}
+
+// hashBytes is hashKey of key, without converting it to a string unless the custom
+// hasher is not a BytesHasher.
+func (c *Consistent) hashBytes(key []byte) uint32 {
+	if c.ketama {
+		return ketamaKeyHashBytes(key)
+	}
+	if c.customHasher != nil {
+		if h, ok := c.customHasher.(BytesHasher); ok {
+			return h.HashBytes(key)
+		}
+		return c.customHasher.HashFunc(string(key))
+	}
+	if c.useFnv {
+		return HashFnv.hashBytes(key)
+	}
+	return c.algorithm.hashBytes(key)
+}
@@ -0,0 +1,60 @@
+package consistent
+
+import (
+	"strconv"
+	"testing"
+)
+
+type bytesFnvHasher struct{ fnvHasher }
+
+func (bytesFnvHasher) HashBytes(key []byte) uint32 { return HashFnv.hashBytes(key) }
+
+func TestGetBytes(t *testing.T) {
+	for _, conf := range []Config{
+		newConfig(),
+		{DefaultNumberOfReplicas: 20, Hash: HashXXH64},
+		{DefaultNumberOfReplicas: 20, Hash: HashMurmur3},
+		{DefaultNumberOfReplicas: 20, UseFnv: true},
+		{DefaultNumberOfReplicas: 20, CustomHasher: bytesFnvHasher{}},
+		{KetamaCompatible: true},
+	} {
+		x := New(conf)
+		if _, err := x.GetBytes([]byte("key")); err != ErrEmptyCircle {
+			t.Errorf("got %v, expected ErrEmptyCircle", err)
+		}
+		x.Add("abcdefg")
+		x.Add("hijklmn")
+		x.Add("opqrstu")
+		for i := 0; i < 1000; i++ {
+			key := "key" + strconv.Itoa(i)
+			got, err := x.GetBytes([]byte(key))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := mustGet(t, x, key); got != want {
+				t.Errorf("%s: got %s, expected %s", key, got, want)
+			}
+		}
+	}
+}
+
+func TestGetBytesAllocs(t *testing.T) {
+	x := New(newConfig())
+	x.Add("abcdefg")
+	x.Add("hijklmn")
+	key := []byte("a key longer than the 32 bytes the compiler may keep on the stack")
+	if n := testing.AllocsPerRun(100, func() { x.GetBytes(key) }); n != 0 {
+		t.Errorf("GetBytes allocates %v times", n)
+	}
+}
+
+func BenchmarkGetBytes(b *testing.B) {
+	x := New(newConfig())
+	x.Add("abcdefg")
+	x.Add("hijklmn")
+	key := []byte("a key longer than the 32 bytes the compiler may keep on the stack")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x.GetBytes(key)
+	}
+}
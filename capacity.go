@@ -0,0 +1,91 @@
+package consistent
+
+import (
+	"sort"
+	"time"
+)
+
+// MemberInfo describes a member, or a member being added, to an EvictionPolicy.
+type MemberInfo struct {
+	Name          string
+	Replicas      int
+	LastHeartbeat time.Time // when it was added or last passed to Heartbeat
+}
+
+// EvictionPolicy picks the member to evict when a member is added to a ring holding
+// Config.MaxMembers members.
+type EvictionPolicy interface {
+	// Evict returns the name of the member of members to evict to make room for newcomer,
+	// or "" to refuse newcomer. members is sorted by name and may be modified.
+	Evict(members []MemberInfo, newcomer MemberInfo) string
+}
+
+// EvictLRU evicts the member whose last heartbeat is the oldest.
+type EvictLRU struct{}
+
+// Evict returns the member with the oldest heartbeat.
+func (EvictLRU) Evict(members []MemberInfo, newcomer MemberInfo) string {
+	sort.SliceStable(members, func(i, j int) bool {
+		return members[i].LastHeartbeat.Before(members[j].LastHeartbeat)
+	})
+	return members[0].Name
+}
+
+// EvictLowestWeight evicts the member with the fewest replicas, unless the newcomer has
+// fewer still, in which case the newcomer is refused.
+type EvictLowestWeight struct{}
+
+// Evict returns the member with the fewest replicas, or "".
+func (EvictLowestWeight) Evict(members []MemberInfo, newcomer MemberInfo) string {
+	sort.SliceStable(members, func(i, j int) bool {
+		return members[i].Replicas < members[j].Replicas
+	})
+	if newcomer.Replicas < members[0].Replicas {
+		return ""
+	}
+	return members[0].Name
+}
+
+// memberCap enforces Config.MaxMembers.
+type memberCap struct {
+	max        int
+	policy     EvictionPolicy
+	heartbeats map[string]time.Time
+	now        func() time.Time
+}
+
+// Heartbeat records that elt is alive, for EvictLRU. It reports false if elt is not a
+// member or the ring has no Config.MaxMembers.
+func (c *Consistent) Heartbeat(elt string) bool {
+	c.Lock()
+	defer c.Unlock()
+	if c.capacity == nil || !c.members[elt] {
+		return false
+	}
+	c.capacity.heartbeats[elt] = c.capacity.now()
+	return true
+}
+
+// need c.Lock() before calling
+// makeRoom evicts a member if needed to add elt, and reports whether elt can be added.
+func (c *Consistent) makeRoom(elt string, replicas int) bool {
+	cp := c.capacity
+	if cp == nil || len(c.members) < cp.max {
+		return true
+	}
+	if cp.policy == nil {
+		return false
+	}
+	members := make([]MemberInfo, 0, len(c.members))
+	for m := range c.members {
+		members = append(members, MemberInfo{Name: m, Replicas: c.membersReplicas[m], LastHeartbeat: cp.heartbeats[m]})
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].Name < members[j].Name })
+	victim := cp.policy.Evict(members, MemberInfo{Name: elt, Replicas: replicas, LastHeartbeat: cp.now()})
+	if victim == "" || !c.members[victim] {
+		return false
+	}
+	c.remove(victim, c.membersReplicas[victim])
+	c.changes.Evicted = append(c.changes.Evicted, victim)
+	return len(c.members) < cp.max
+}
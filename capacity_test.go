@@ -0,0 +1,68 @@
+package consistent
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestMaxMembersRefuse(t *testing.T) {
+	x := New(Config{DefaultNumberOfReplicas: 20, MaxMembers: 2})
+	x.Add("a")
+	x.Add("b")
+	x.AddWithSalt("c", "v1")
+	checkNum(len(x.Members()), 2, t)
+	if x.Salt("c") != "" {
+		t.Errorf("expected the salt of a refused member to be dropped")
+	}
+	x.Remove("a")
+	x.Add("c")
+	checkNum(len(x.Members()), 2, t)
+}
+
+func TestMaxMembersLRU(t *testing.T) {
+	x := New(Config{DefaultNumberOfReplicas: 20, MaxMembers: 3, Eviction: EvictLRU{}})
+	now := time.Unix(1000000, 0)
+	x.capacity.now = func() time.Time { return now }
+	for _, m := range []string{"a", "b", "c"} {
+		x.Add(m)
+		now = now.Add(time.Second)
+	}
+	x.Heartbeat("a")
+	var events []ChangeEvent
+	x.OnChange(func(ev ChangeEvent) { events = append(events, ev) })
+	x.Add("d")
+	if got := x.Members(); !reflect.DeepEqual(sortedStrings(got), []string{"a", "c", "d"}) {
+		t.Errorf("got %v, expected b to be evicted", got)
+	}
+	if len(events) != 1 || !reflect.DeepEqual(events[0].Evicted, []string{"b"}) || !reflect.DeepEqual(events[0].Removed, []string{"b"}) {
+		t.Errorf("unexpected events %+v", events)
+	}
+	if x.Heartbeat("b") {
+		t.Errorf("expected no heartbeat for an evicted member")
+	}
+	if err := x.Verify(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMaxMembersLowestWeight(t *testing.T) {
+	x := New(Config{DefaultNumberOfReplicas: 20, MaxMembers: 2, Eviction: EvictLowestWeight{}})
+	x.Add("a", 10)
+	x.Add("b", 30)
+	x.Add("c", 5)
+	if got := sortedStrings(x.Members()); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("got %v, expected the lighter newcomer to be refused", got)
+	}
+	x.Add("d", 20)
+	if got := sortedStrings(x.Members()); !reflect.DeepEqual(got, []string{"b", "d"}) {
+		t.Errorf("got %v, expected a to be evicted", got)
+	}
+}
+
+func sortedStrings(s []string) []string {
+	s = append([]string(nil), s...)
+	sort.Strings(s)
+	return s
+}
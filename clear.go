@@ -0,0 +1,41 @@
+package consistent
+
+import (
+	"sort"
+	"time"
+)
+
+// Clear removes all the members in one acquisition of the ring lock, reporting them as
+// removed to OnChange listeners. By default the circle and the sorted hashes go back to the
+// size Config.ExpectedMembers preallocated, so a ring torn down in a long-lived process
+// does not hold on to the memory of its largest membership; passing true keeps their
+// allocated capacity instead, for a ring about to be repopulated at a similar size.
+func (c *Consistent) Clear(keepCapacity ...bool) {
+	c.Lock()
+	defer c.unlockAndNotify()
+	if len(c.members) == 0 {
+		return
+	}
+	members := make([]string, 0, len(c.members))
+	for m := range c.members {
+		members = append(members, m)
+	}
+	sort.Strings(members)
+	for _, m := range members {
+		c.removePoints(m, c.membersReplicas[m])
+	}
+	start := time.Now()
+	if len(keepCapacity) > 0 && keepCapacity[0] {
+		c.setSortedHashes(c.sortedHashes[:0], start)
+		return
+	}
+	c.circle = make(map[uint32]string, c.expectedVnodes)
+	c.members = make(map[string]bool)
+	c.membersReplicas = make(map[string]int)
+	c.joined = make(map[string]uint64)
+	var hashes uints
+	if c.expectedVnodes > 0 {
+		hashes = make(uints, 0, c.expectedVnodes)
+	}
+	c.setSortedHashes(hashes, start)
+}
@@ -0,0 +1,39 @@
+package consistent
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestClear(t *testing.T) {
+	for _, keep := range []bool{false, true} {
+		x := New(newConfig())
+		x.Set([]string{"abcdefg", "hijklmn", "opqrstu"})
+		var ev ChangeEvent
+		x.OnChange(func(e ChangeEvent) { ev = e })
+		capBefore := cap(x.sortedHashes)
+
+		x.Clear(keep)
+		checkNum(x.MemberCount(), 0, t)
+		checkNum(x.VnodeCount(), 0, t)
+		checkNum(len(x.circle), 0, t)
+		if _, err := x.Get("key"); err != ErrEmptyCircle {
+			t.Errorf("expected ErrEmptyCircle, got %v", err)
+		}
+		sort.Strings(ev.Removed)
+		if len(ev.Removed) != 3 || ev.Removed[0] != "abcdefg" {
+			t.Errorf("removed %v", ev.Removed)
+		}
+		if keep && cap(x.sortedHashes) != capBefore {
+			t.Errorf("expected the capacity of %d to be kept, got %d", capBefore, cap(x.sortedHashes))
+		}
+		if !keep && cap(x.sortedHashes) != 0 {
+			t.Errorf("expected the sorted hashes to be released, got capacity %d", cap(x.sortedHashes))
+		}
+
+		x.Add("abcdefg")
+		if mustGet(t, x, "key") != "abcdefg" {
+			t.Errorf("expected the ring to be usable after Clear")
+		}
+	}
+}
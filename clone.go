@@ -0,0 +1,182 @@
+package consistent
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Clone returns an independent copy of the ring: its members, replicas, salts, circle,
+// routing state and settings, so callers can change the copy for what-if analysis, or
+// build a new topology off the request path and swap it in. Listeners, hooks, pending
+// SetDebounced calls and load and rate counters are not copied. The Overrides table, the
+// Guard and the hashers are shared. Members quarantined for flapping stay quarantined in
+// the copy for a full Config.FlapCooldown, and members added with AddWithTTL expire from
+// the copy independently.
+func (c *Consistent) Clone() *Consistent {
+	c.RLock()
+	defer c.RUnlock()
+	d := c.cloneRing()
+	d.onRebuild = c.onRebuild
+	d.onWarmup = c.onWarmup
+	if f := c.flaps; f != nil {
+		d.flaps = newFlapDetector(f.threshold, f.window, f.cooldown)
+		d.flaps.now = f.now
+		for m, list := range f.changes {
+			d.flaps.changes[m] = append([]time.Time(nil), list...)
+		}
+		for m := range f.quarantined {
+			elt := m
+			d.flaps.quarantined[elt] = time.AfterFunc(f.cooldown, func() { d.release(elt) })
+		}
+	}
+	if len(c.ttl.ttls) > 0 {
+		d.ttl.now = c.ttl.now
+		d.ttl.ttls = make(map[string]time.Duration, len(c.ttl.ttls))
+		d.ttl.deadlines = make(map[string]time.Time, len(c.ttl.deadlines))
+		for m, ttl := range c.ttl.ttls {
+			d.ttl.ttls[m] = ttl
+			d.ttl.deadlines[m] = c.ttl.deadlines[m]
+		}
+		d.scheduleExpiry()
+	}
+	d.publish()
+	return d
+}
+
+// need c.RLock() before calling
+// cloneRing is Clone without the hooks, flap detection and TTLs of the ring, whose timers
+// and callbacks would outlive or observe the copy, nor its read view.
+func (c *Consistent) cloneRing() *Consistent {
+	d := new(Consistent)
+	d.circle = make(map[uint32]string, len(c.circle))
+	for h, m := range c.circle {
+		d.circle[h] = m
+	}
+	d.members = copyStringBool(c.members)
+	d.membersReplicas = make(map[string]int, len(c.membersReplicas))
+	for m, n := range c.membersReplicas {
+		d.membersReplicas[m] = n
+	}
+	d.salts = copyStringString(c.salts)
+	if c.memberHashers != nil {
+		d.memberHashers = make(map[string]Hasher, len(c.memberHashers))
+		for m, h := range c.memberHashers {
+			d.memberHashers[m] = h
+		}
+	}
+	if c.metas != nil {
+		d.metas = make(map[string]interface{}, len(c.metas))
+		for m, v := range c.metas {
+			d.metas[m] = v
+		}
+	}
+	d.zones = copyStringString(c.zones)
+	d.down = copyStringBool(c.down)
+	d.degradedShare = c.degradedShare
+	d.degradedPolicy = c.degradedPolicy
+	if c.capacity != nil {
+		cp := *c.capacity
+		cp.heartbeats = make(map[string]time.Time, len(c.capacity.heartbeats))
+		for m, t := range c.capacity.heartbeats {
+			cp.heartbeats[m] = t
+		}
+		d.capacity = &cp
+	}
+	d.keepDeltas = c.keepDeltas
+	d.deltas = append([]versionDelta(nil), c.deltas...)
+	d.incarnations = make(map[string]uint64, len(c.incarnations))
+	for m, n := range c.incarnations {
+		d.incarnations[m] = n
+	}
+	d.tieBreak = c.tieBreak
+	if c.contested != nil {
+		d.contested = make(map[uint32][]string, len(c.contested))
+		for h, claimants := range c.contested {
+			d.contested[h] = append([]string(nil), claimants...)
+		}
+	}
+	d.joined = make(map[string]uint64, len(c.joined))
+	for m, n := range c.joined {
+		d.joined[m] = n
+	}
+	d.joinSeq = c.joinSeq
+	d.sortedHashes = append(make(uints, 0, cap(c.sortedHashes)), c.sortedHashes...)
+	d.index.build(d.sortedHashes)
+	d.defaultNumberOfReplicas = c.defaultNumberOfReplicas
+	d.count = c.count
+	d.customHasher = c.customHasher
+	d.keyDeriver = c.keyDeriver
+	d.useFnv = c.useFnv
+	d.algorithm = c.algorithm
+	d.ketama = c.ketama
+	d.parallelThreshold = c.parallelThreshold
+	d.expectedVnodes = c.expectedVnodes
+	d.overrides = c.overrides
+	if c.rates != nil {
+		d.rates = newRateTracker()
+	}
+	d.readPolicy = c.readPolicy
+	d.hedgeOwners = c.hedgeOwners
+	d.load.factor = c.load.factor
+	d.load.halfLife = c.load.halfLife
+	d.profile = c.profile
+	d.history = c.history
+	d.history.full = append([]ringVersion(nil), c.history.full...)
+	d.history.summaries = append([]VersionSummary(nil), c.history.summaries...)
+	d.weighted = append([]weightedMember(nil), c.weighted...)
+	d.weightedMode = c.weightedMode
+	d.guard = c.guard
+	d.stats = &ringStats{
+		lookups:           atomic.LoadUint64(&c.stats.lookups),
+		errors:            atomic.LoadUint64(&c.stats.errors),
+		writes:            c.stats.writes,
+		version:           c.stats.version,
+		lastChange:        c.stats.lastChange,
+		rebuilds:          c.stats.rebuilds,
+		rebuildTime:       c.stats.rebuildTime,
+		lastRebuildTime:   c.stats.lastRebuildTime,
+		lastRebuildVnodes: c.stats.lastRebuildVnodes,
+	}
+	d.draining = copyStringBool(c.draining)
+	d.trackMoves = c.trackMoves
+	d.minReplicaArc = c.minReplicaArc
+	d.maxShare = c.maxShare
+	d.strict = c.strict
+	d.groups = copyStringString(c.groups)
+	if c.groupKeys != nil {
+		d.groupKeys = make(map[string][]string, len(c.groupKeys))
+		for g, keys := range c.groupKeys {
+			d.groupKeys[g] = append([]string(nil), keys...)
+		}
+	}
+	d.groupPins = copyStringString(c.groupPins)
+	if c.tracked != nil {
+		d.tracked = make(map[string]struct{}, len(c.tracked))
+		for k := range c.tracked {
+			d.tracked[k] = struct{}{}
+		}
+	}
+	return d
+}
+
+func copyStringBool(m map[string]bool) map[string]bool {
+	if m == nil {
+		return nil
+	}
+	res := make(map[string]bool, len(m))
+	for k, v := range m {
+		res[k] = v
+	}
+	return res
+}
+
+func copyStringString(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	res := make(map[string]string, len(m))
+	for k, v := range m {
+		res[k] = v
+	}
+	return res
+}
@@ -0,0 +1,48 @@
+package consistent
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	x := New(Config{DefaultNumberOfReplicas: 20, KeepDeltas: 5})
+	x.Set([]string{"a", "b", "c"})
+	x.AddWithSalt("d", "v2", 40)
+	x.MarkDown("c")
+	x.Group("user1", "g")
+	y := x.Clone()
+
+	for i := 0; i < 1000; i++ {
+		k := "key" + strconv.Itoa(i)
+		if a, b := mustGet(t, x, k), mustGet(t, y, k); a != b {
+			t.Fatalf("%s: %s on the ring, %s on the clone", k, a, b)
+		}
+	}
+	if y.Version() != x.Version() || y.Salt("d") != "v2" || y.MemberReplicas()["d"] != 40 || len(y.Down()) != 1 {
+		t.Error("clone differs from the ring")
+	}
+
+	// changing one leaves the other as it was
+	before := make(map[string]string)
+	for i := 0; i < 1000; i++ {
+		k := "key" + strconv.Itoa(i)
+		before[k] = mustGet(t, x, k)
+	}
+	y.Remove("a")
+	y.Add("e")
+	y.MarkUp("c")
+	checkNum(len(x.Members()), 4, t)
+	for k, m := range before {
+		if got := mustGet(t, x, k); got != m {
+			t.Fatalf("%s moved from %s to %s on the ring after changing the clone", k, m, got)
+		}
+	}
+	x.Remove("b")
+	if len(y.Members()) != 4 || !sliceContainsMember(y.Members(), "b") {
+		t.Errorf("unexpected clone members %v", y.Members())
+	}
+	if _, err := y.EncodeDelta(y.Version() - 2); err != nil {
+		t.Errorf("clone lost its deltas: %v", err)
+	}
+}
@@ -0,0 +1,258 @@
+package consistent
+
+import (
+	"encoding/binary"
+	"encoding/json"
+)
+
+// Codec encodes snapshots and deltas for persistence and for shipping them between
+// replicas, so stores and sync backends share one code path whatever the format. JSONCodec,
+// BinaryCodec and ProtobufCodec are provided; any other format can be plugged in by
+// implementing Codec.
+type Codec interface {
+	EncodeSnapshot(s Snapshot) ([]byte, error)
+	DecodeSnapshot(data []byte) (Snapshot, error)
+	EncodeDelta(d Delta) ([]byte, error)
+	DecodeDelta(data []byte) (Delta, error)
+}
+
+var (
+	_ Codec = JSONCodec{}
+	_ Codec = BinaryCodec{}
+	_ Codec = ProtobufCodec{}
+)
+
+// JSONCodec encodes snapshots and deltas as JSON, the format of the stores of package
+// store by default.
+type JSONCodec struct{}
+
+// EncodeSnapshot encodes s as JSON.
+func (JSONCodec) EncodeSnapshot(s Snapshot) ([]byte, error) { return json.Marshal(s) }
+
+// DecodeSnapshot decodes a snapshot encoded by EncodeSnapshot.
+func (JSONCodec) DecodeSnapshot(data []byte) (Snapshot, error) {
+	var s Snapshot
+	err := json.Unmarshal(data, &s)
+	return s, err
+}
+
+// EncodeDelta encodes d as JSON.
+func (JSONCodec) EncodeDelta(d Delta) ([]byte, error) { return json.Marshal(d) }
+
+// DecodeDelta decodes a delta encoded by EncodeDelta.
+func (JSONCodec) DecodeDelta(data []byte) (Delta, error) {
+	var d Delta
+	err := json.Unmarshal(data, &d)
+	return d, err
+}
+
+// BinaryCodec encodes snapshots with Snapshot.MarshalBinary and deltas with
+// Delta.MarshalBinary, the format of EncodeDelta.
+type BinaryCodec struct{}
+
+// EncodeSnapshot encodes s with Snapshot.MarshalBinary.
+func (BinaryCodec) EncodeSnapshot(s Snapshot) ([]byte, error) { return s.MarshalBinary() }
+
+// DecodeSnapshot decodes a snapshot encoded by EncodeSnapshot.
+func (BinaryCodec) DecodeSnapshot(data []byte) (Snapshot, error) {
+	var s Snapshot
+	err := s.UnmarshalBinary(data)
+	return s, err
+}
+
+// EncodeDelta encodes d with Delta.MarshalBinary.
+func (BinaryCodec) EncodeDelta(d Delta) ([]byte, error) { return d.MarshalBinary() }
+
+// DecodeDelta decodes a delta encoded by EncodeDelta.
+func (BinaryCodec) DecodeDelta(data []byte) (Delta, error) {
+	var d Delta
+	err := d.UnmarshalBinary(data)
+	return d, err
+}
+
+// ProtobufCodec encodes snapshots and deltas in the protobuf wire format, without
+// depending on a protobuf library, as these messages:
+//
+//	message Member {
+//	  string name = 1;
+//	  int32 replicas = 2;
+//	  string salt = 3;
+//	}
+//	message Snapshot {
+//	  repeated Member members = 1;
+//	}
+//	message Delta {
+//	  uint64 from = 1;
+//	  uint64 to = 2;
+//	  repeated string removed = 3;
+//	  repeated Member added = 4;
+//	}
+//
+// Unknown fields are skipped when decoding, so the messages can be extended.
+type ProtobufCodec struct{}
+
+const (
+	pbVarint  = 0
+	pbFixed64 = 1
+	pbBytes   = 2
+	pbFixed32 = 5
+)
+
+func pbAppendVarint(buf []byte, v uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	return append(buf, tmp[:binary.PutUvarint(tmp[:], v)]...)
+}
+
+func pbAppendBytes(buf []byte, field int, b []byte) []byte {
+	buf = pbAppendVarint(buf, uint64(field)<<3|pbBytes)
+	buf = pbAppendVarint(buf, uint64(len(b)))
+	return append(buf, b...)
+}
+
+func pbAppendUint(buf []byte, field int, v uint64) []byte {
+	if v == 0 {
+		return buf
+	}
+	buf = pbAppendVarint(buf, uint64(field)<<3|pbVarint)
+	return pbAppendVarint(buf, v)
+}
+
+func pbMember(m SnapshotMember) []byte {
+	var buf []byte
+	if m.Name != "" {
+		buf = pbAppendBytes(buf, 1, []byte(m.Name))
+	}
+	buf = pbAppendUint(buf, 2, uint64(int64(m.Replicas)))
+	if m.Salt != "" {
+		buf = pbAppendBytes(buf, 3, []byte(m.Salt))
+	}
+	return buf
+}
+
+// pbFields calls fn with every field of the message in data, with the value of varint
+// fields in v and the contents of length-delimited fields in b. Fixed-size fields are
+// skipped.
+func pbFields(data []byte, fn func(field int, v uint64, b []byte) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return ErrBadEncoding
+		}
+		data = data[n:]
+		field := int(key >> 3)
+		var (
+			v uint64
+			b []byte
+		)
+		switch key & 7 {
+		case pbVarint:
+			v, n = binary.Uvarint(data)
+			if n <= 0 {
+				return ErrBadEncoding
+			}
+			data = data[n:]
+		case pbBytes:
+			l, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < l {
+				return ErrBadEncoding
+			}
+			b = data[n : n+int(l)]
+			data = data[n+int(l):]
+		case pbFixed64, pbFixed32:
+			size := 8
+			if key&7 == pbFixed32 {
+				size = 4
+			}
+			if len(data) < size {
+				return ErrBadEncoding
+			}
+			data = data[size:]
+			continue
+		default:
+			return ErrBadEncoding
+		}
+		if err := fn(field, v, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func pbDecodeMember(data []byte) (SnapshotMember, error) {
+	var m SnapshotMember
+	err := pbFields(data, func(field int, v uint64, b []byte) error {
+		switch field {
+		case 1:
+			m.Name = string(b)
+		case 2:
+			m.Replicas = int(int32(v))
+		case 3:
+			m.Salt = string(b)
+		}
+		return nil
+	})
+	return m, err
+}
+
+// EncodeSnapshot encodes s as a Snapshot message.
+func (ProtobufCodec) EncodeSnapshot(s Snapshot) ([]byte, error) {
+	var buf []byte
+	for _, m := range s.Members {
+		buf = pbAppendBytes(buf, 1, pbMember(m))
+	}
+	return buf, nil
+}
+
+// DecodeSnapshot decodes a Snapshot message.
+func (ProtobufCodec) DecodeSnapshot(data []byte) (Snapshot, error) {
+	s := Snapshot{Members: []SnapshotMember{}}
+	err := pbFields(data, func(field int, v uint64, b []byte) error {
+		if field != 1 {
+			return nil
+		}
+		m, err := pbDecodeMember(b)
+		s.Members = append(s.Members, m)
+		return err
+	})
+	if err != nil {
+		return Snapshot{}, err
+	}
+	return s, nil
+}
+
+// EncodeDelta encodes d as a Delta message.
+func (ProtobufCodec) EncodeDelta(d Delta) ([]byte, error) {
+	buf := pbAppendUint(nil, 1, d.From)
+	buf = pbAppendUint(buf, 2, d.To)
+	for _, name := range d.Removed {
+		buf = pbAppendBytes(buf, 3, []byte(name))
+	}
+	for _, m := range d.Added {
+		buf = pbAppendBytes(buf, 4, pbMember(m))
+	}
+	return buf, nil
+}
+
+// DecodeDelta decodes a Delta message.
+func (ProtobufCodec) DecodeDelta(data []byte) (Delta, error) {
+	var d Delta
+	err := pbFields(data, func(field int, v uint64, b []byte) error {
+		switch field {
+		case 1:
+			d.From = v
+		case 2:
+			d.To = v
+		case 3:
+			d.Removed = append(d.Removed, string(b))
+		case 4:
+			m, err := pbDecodeMember(b)
+			d.Added = append(d.Added, m)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return Delta{}, err
+	}
+	return d, nil
+}
@@ -0,0 +1,65 @@
+package consistent
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestCodecs(t *testing.T) {
+	snap := Snapshot{Members: []SnapshotMember{{Name: "abcdefg", Replicas: 20}, {Name: "hijklmn", Replicas: 40, Salt: "gen2"}}}
+	delta := Delta{From: 3, To: 5, Removed: []string{"opqrstu"}, Added: []SnapshotMember{{Name: "hijklmn", Replicas: 40, Salt: "gen2"}}}
+	for _, c := range []Codec{JSONCodec{}, BinaryCodec{}, ProtobufCodec{}} {
+		data, err := c.EncodeSnapshot(snap)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := c.DecodeSnapshot(data)
+		if err != nil {
+			t.Fatalf("%T: %v", c, err)
+		}
+		if !reflect.DeepEqual(s, snap) {
+			t.Errorf("%T: got %+v, expected %+v", c, s, snap)
+		}
+		data, err = c.EncodeDelta(delta)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d, err := c.DecodeDelta(data)
+		if err != nil {
+			t.Fatalf("%T: %v", c, err)
+		}
+		if !reflect.DeepEqual(d, delta) {
+			t.Errorf("%T: got %+v, expected %+v", c, d, delta)
+		}
+		if _, err := c.DecodeSnapshot(data[:len(data)-1]); err == nil {
+			t.Errorf("%T: decoded a truncated snapshot", c)
+		}
+	}
+}
+
+func TestProtobufCodecWire(t *testing.T) {
+	data, _ := ProtobufCodec{}.EncodeSnapshot(Snapshot{Members: []SnapshotMember{{Name: "a", Replicas: 20}}})
+	want := []byte{0x0a, 0x05, 0x0a, 0x01, 'a', 0x10, 0x14}
+	if !bytes.Equal(data, want) {
+		t.Errorf("got % x, expected % x", data, want)
+	}
+	// an unknown fixed32 field 9 and varint field 10 are skipped
+	data = append(data, 0x4d, 1, 2, 3, 4, 0x50, 0x01)
+	s, err := ProtobufCodec{}.DecodeSnapshot(data)
+	if err != nil || len(s.Members) != 1 || s.Members[0].Name != "a" {
+		t.Errorf("got %+v, %v", s, err)
+	}
+}
+
+func TestBinaryCodecRingEncoding(t *testing.T) {
+	x := New(newConfig())
+	x.Add("abcdefg")
+	x.AddWithSalt("hijklmn", "gen2", 40)
+	ring, _ := x.MarshalBinary()
+	snap, _ := BinaryCodec{}.EncodeSnapshot(x.Snapshot())
+	// the version, then the members after the hashing settings of the ring
+	if snap[0] != ring[0] || !bytes.Equal(snap[1:], ring[7:]) {
+		t.Errorf("got %v, expected the members of %v", snap, ring)
+	}
+}
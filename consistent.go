@@ -17,16 +17,18 @@
 // get remapped.
 //
 // Read more about consistent hashing on wikipedia:  http://en.wikipedia.org/wiki/Consistent_hashing
-//
 package consistent // import "stathat.com/c/consistent"
 
 import (
 	"errors"
-	"hash/crc32"
-	"hash/fnv"
+	"math"
+	"runtime"
 	"sort"
-	"strconv"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jiangz222/consistent/core"
 )
 
 type uints []uint32
@@ -48,18 +50,182 @@ type Consistent struct {
 	circle                  map[uint32]string // key: [hash(i+elt)], the number of specific elt(number of i) depends on NumberOfReplicas
 	members                 map[string]bool
 	membersReplicas         map[string]int
+	salts                   map[string]string // optional per-member salt mixed into its vnode keys
+	memberHashers           map[string]Hasher // optional per-member hasher of its vnode keys
+	metas                   map[string]interface{}
+	zones                   map[string]string
+	down                    map[string]bool // members marked down, see MarkDown
+	degradedShare           float64
+	degradedPolicy          DegradedPolicy
+	capacity                *memberCap
+	keepDeltas              int
+	deltas                  []versionDelta    // the last keepDeltas changes, oldest first
+	incarnations            map[string]uint64 // kept after removal so re-adds get a higher one
+	tieBreak                TieBreak
+	contested               map[uint32][]string // point: every member claiming it, see claim
+	joined                  map[string]uint64   // member: join sequence, for TieBreakJoinTime
+	joinSeq                 uint64
 	sortedHashes            uints //key of circle store here, for quick sort
+	index                   bucketIndex
 	defaultNumberOfReplicas int
 	count                   int64
 	scratch                 [64]byte
 	customHasher            Hasher
+	keyDeriver              func(elt string, idx int) string
 	useFnv                  bool
+	algorithm               HashAlgorithm
+	ketama                  bool
+	parallelThreshold       int
+	expectedVnodes          int
+	overrides               *Overrides
+	rates                   *rateTracker
+	readPolicy              ReadPolicy
+	hedgeOwners             int
+	changes                 ChangeEvent // accumulated by add and remove until unlockAndNotify
+	listeners               listeners
+	hooks                   hooks
+	load                    loadTracker
+	ttl                     ttlTracker
+	profile                 *profileLabels // nil without Config.ProfileName
+	history                 versionHistory
+	weighted                []weightedMember // members by weight, kept only in WeightedRendezvous mode
+	weightedMode            bool
+	flaps                   *flapDetector
+	debounce                debouncer
+	guard                   *Guard
+	stats                   *ringStats
+	draining                map[string]bool
+	trackMoves              bool
+	movesBase               *movesBase // routing before the current batch of changes
+	minReplicaArc           uint32
+	onRebuild               func(vnodes int, took time.Duration)
+	onWarmup                func(member string, ranges []MovedRange)
+	maxShare                float64
+	strict                  bool
+	groups                  map[string]string // key: group ID
+	groupKeys               map[string][]string
+	groupPins               map[string]string
+	tracked                 map[string]struct{} // keys fed by Track, for KeysOwnedBy
+	view                    atomic.Value        // *readView, read by Get without the lock
 	sync.RWMutex
 }
 type Config struct {
 	DefaultNumberOfReplicas int
 	UseFnv                  bool
 	CustomHasher            Hasher
+	// Hash selects the builtin hash function placing vnodes and hashing keys, CRC-32 by
+	// default. xxHash and Murmur3 spread keys more evenly than CRC-32 and FNV. It is ignored
+	// when CustomHasher is set, and UseFnv is the same as HashFnv.
+	Hash HashAlgorithm
+	// DegradedShare is the share of members, between 0 and 1, that must be marked down with
+	// MarkDown for the ring to be degraded, Get, GetTwo and GetN then following
+	// DegradedPolicy instead of concentrating the keys of the down members on the few
+	// survivors next to them. 0 disables degraded mode.
+	DegradedShare  float64
+	DegradedPolicy DegradedPolicy
+	// KetamaCompatible places members and hashes keys like libketama and spymemcached, so
+	// the ring maps keys as existing memcached clients do: the points of a member named
+	// "host:port" come 4 per MD5 digest of "host:port-n", and keys are hashed with MD5.
+	// The number of replicas is the number of points, DefaultKetamaPoints by default, and
+	// should be a multiple of 4. UseFnv, CustomHasher, KeyDeriver and per-member hashers
+	// and salts are ignored.
+	KetamaCompatible bool
+	// ExpectedMembers and ExpectedReplicas, the replicas per member which default to
+	// DefaultNumberOfReplicas, size the ring up front so building a large ring does not go
+	// through repeated map growth and slice reallocations.
+	ExpectedMembers  int
+	ExpectedReplicas int
+	// KeyDeriver returns the key hashed to place vnode idx of elt, for compatibility with
+	// rings built by other libraries; package core provides common schemes. Defaults to idx
+	// in decimal followed by elt, which lets vnodes of members such as "server" and
+	// "1server" collide; new rings can opt into core.LengthPrefixedKeyDeriver to rule that
+	// out. The salt of AddWithSalt is appended to its result.
+	KeyDeriver func(elt string, idx int) string
+	// ParallelRebuildThreshold is the number of vnodes above which the sorted hash index is
+	// rebuilt with multiple goroutines. 0 means DefaultParallelRebuildThreshold, negative disables it.
+	ParallelRebuildThreshold int
+	// TrackRates enables the per-member selection rates reported by Rates.
+	TrackRates bool
+	// ReadPolicy is the policy ReadOwners uses when called without one. Defaults to PrimaryOnly.
+	ReadPolicy ReadPolicy
+	// HedgeOwners is the number of owners DoHedged may try. Defaults to 2.
+	HedgeOwners int
+	// WeightedRendezvous makes Get, GetTwo and GetN pick members with weighted rendezvous
+	// hashing instead of walking the circle: a key goes to each member with probability
+	// proportional to its number of replicas, and only keys whose ranking flips move when
+	// weights change. Lookups cost O(members).
+	WeightedRendezvous bool
+	// FlapThreshold enables the flap detector: a member added or removed more than
+	// FlapThreshold times within FlapWindow is quarantined for FlapCooldown, during which it
+	// stays a member but no key is routed to it. 0 disables it. FlapWindow and FlapCooldown
+	// default to DefaultFlapWindow and DefaultFlapCooldown.
+	FlapThreshold int
+	FlapWindow    time.Duration
+	FlapCooldown  time.Duration
+	// TrackMovedRanges makes change events report the ranges of the hash space that
+	// changed owner, see ChangeEvent.Moved.
+	TrackMovedRanges bool
+	// MinReplicaArc is the share of the circle, between 0 and 1, below which the arc a vnode
+	// owns is considered a sliver: GetN and the lookups built on its walk only pick a member
+	// reached through a sliver as a replica if no other member is left. It never changes the
+	// primary owner. 0 disables it.
+	MinReplicaArc float64
+	// OnRebuild, if set, is called after every rebuild of the sorted hashes with their
+	// number and the time the rebuild took, e.g. to feed a metrics system. It runs with
+	// the ring locked and must not use it. StatsSnapshot reports the same figures.
+	OnRebuild func(vnodes int, took time.Duration)
+	// MaxShare caps the share of the circle, between 0 and 1, any member owns: the points
+	// of members above it are left out of the sorted hashes, their ranges spilling to the
+	// following members, as long as those stay within the cap. It protects small members of
+	// heterogeneous fleets from being assigned more keys than they can take; the cap is
+	// approximate, a member keeping at least one point, and ignored in WeightedRendezvous
+	// mode. With it, every change rebuilds the sorted hashes. 0 disables it.
+	MaxShare float64
+	// Warmup, if set, is called before a member joins with the ranges of the hash space it
+	// is about to own, From being their current owner, so caches can be pre-warmed from
+	// the previous owners. Every change adding members calls it, from Add and its variants
+	// to Set, Restore, ImportMembers and ApplyDelta, and blocks until it returns, so the
+	// member only receives keys after that. It runs outside the ring lock and may read the
+	// ring. Members Config.MaxMembers would evict to make room are not accounted for.
+	Warmup func(member string, ranges []MovedRange)
+	// KeepVersions is the number of ring versions, the current one included, retained for
+	// OwnerAt and PreviousOwner. Zero retains none.
+	KeepVersions int
+	// KeepDeltas is the number of changes of membership retained for EncodeDelta.
+	KeepDeltas int
+	// KeepVersionsFor, if set, also drops the versions superseded for longer than that, the
+	// current one being always kept.
+	KeepVersionsFor time.Duration
+	// CompactVersions is the number of versions dropped by KeepVersions or KeepVersionsFor
+	// still retained as a summary of the ranges that moved, which is enough for OwnerAt.
+	CompactVersions int
+	// MaxMembers caps the number of members, e.g. of rings populated by client
+	// self-registration. Adding a member to a full ring evicts the member picked by Eviction,
+	// or does nothing if Eviction is nil or picks none. 0 means no cap.
+	MaxMembers int
+	Eviction   EvictionPolicy
+	// Strict makes New reject, by panicking with the error of Validate, any setting that
+	// could let two processes given the same config and the same changes build different
+	// rings, and AddWithTTL panic.
+	Strict bool
+	// LoadFactor bounds the load of members picked by GetLeast to LoadFactor times the
+	// average load. Defaults to DefaultLoadFactor.
+	LoadFactor float64
+	// LoadHalfLife enables Observe, recording loads that decay by half every LoadHalfLife
+	// and count towards the load of members for GetLeast along with Inc and Done.
+	LoadHalfLife time.Duration
+	// ProfileName, if set, runs rebuilds and the lookups made with a context, such as
+	// GetContext, under the pprof labels ring=ProfileName and op=<operation>, so CPU
+	// profiles of services embedding several rings attribute time to the right ring; the
+	// rings of a Manager are named ProfileName/<ring>. The labels of the calling goroutine
+	// are left alone.
+	ProfileName string
+	// TieBreak picks the owner of points of the circle claimed by several members.
+	// Defaults to TieBreakLastAdd.
+	TieBreak TieBreak
+	// Guard, if set, checks the changes made by Set, SetWithReplicas and Remove before they
+	// are applied.
+	Guard *Guard
 }
 type Hasher interface {
 	HashFunc(key string) uint32
@@ -69,55 +235,191 @@ type Hasher interface {
 //
 // To change the number of replicas, set NumberOfReplicas before adding entries.
 func New(conf Config) *Consistent {
+	if err := conf.Validate(); err != nil {
+		panic(err)
+	}
 	c := new(Consistent)
 	c.defaultNumberOfReplicas = conf.DefaultNumberOfReplicas
 	if c.defaultNumberOfReplicas == 0 {
 		c.defaultNumberOfReplicas = 43
+		if conf.KetamaCompatible {
+			c.defaultNumberOfReplicas = DefaultKetamaPoints
+		}
+	}
+	c.ketama = conf.KetamaCompatible
+	c.algorithm = conf.Hash
+	if conf.UseFnv {
+		c.algorithm = HashFnv
 	}
-	c.useFnv = conf.UseFnv
+	c.useFnv = c.algorithm == HashFnv
 	c.customHasher = conf.CustomHasher
-	c.circle = make(map[uint32]string)
-	c.members = make(map[string]bool)
-	c.membersReplicas = make(map[string]int)
+	c.keyDeriver = conf.KeyDeriver
+	c.parallelThreshold = conf.ParallelRebuildThreshold
+	if c.parallelThreshold == 0 {
+		c.parallelThreshold = DefaultParallelRebuildThreshold
+	}
+	c.weightedMode = conf.WeightedRendezvous
+	c.readPolicy = conf.ReadPolicy
+	if c.readPolicy == nil {
+		c.readPolicy = PrimaryOnly{}
+	}
+	c.hedgeOwners = conf.HedgeOwners
+	if c.hedgeOwners <= 0 {
+		c.hedgeOwners = 2
+	}
+	if conf.TrackRates {
+		c.rates = newRateTracker()
+	}
+	c.guard = conf.Guard
+	c.stats = new(ringStats)
+	c.trackMoves = conf.TrackMovedRanges
+	c.onRebuild = conf.OnRebuild
+	c.onWarmup = conf.Warmup
+	c.maxShare = conf.MaxShare
+	c.strict = conf.Strict
+	c.load.factor = conf.LoadFactor
+	c.load.halfLife = conf.LoadHalfLife
+	c.tieBreak = conf.TieBreak
+	if conf.ProfileName != "" {
+		c.profile = newProfileLabels(conf.ProfileName)
+	}
+	if conf.MaxMembers > 0 {
+		c.capacity = &memberCap{
+			max:        conf.MaxMembers,
+			policy:     conf.Eviction,
+			heartbeats: make(map[string]time.Time),
+			now:        time.Now,
+		}
+	}
+	c.keepDeltas = conf.KeepDeltas
+	c.degradedShare = conf.DegradedShare
+	c.degradedPolicy = conf.DegradedPolicy
+	c.history = versionHistory{
+		keep:    conf.KeepVersions,
+		maxAge:  conf.KeepVersionsFor,
+		compact: conf.CompactVersions,
+		now:     time.Now,
+	}
+	c.history.record(0, c.sortedHashes, c.circle)
+	if conf.MinReplicaArc > 0 {
+		c.minReplicaArc = uint32(math.Min(conf.MinReplicaArc, 1) * math.MaxUint32)
+	}
+	if conf.FlapThreshold > 0 {
+		c.flaps = newFlapDetector(conf.FlapThreshold, conf.FlapWindow, conf.FlapCooldown)
+	}
+	members := conf.ExpectedMembers
+	if members < 0 {
+		members = 0
+	}
+	if members > 0 {
+		replicas := conf.ExpectedReplicas
+		if replicas <= 0 {
+			replicas = c.defaultNumberOfReplicas
+		}
+		c.expectedVnodes = members * replicas
+		c.sortedHashes = make(uints, 0, c.expectedVnodes)
+	}
+	c.circle = make(map[uint32]string, c.expectedVnodes)
+	c.members = make(map[string]bool, members)
+	c.membersReplicas = make(map[string]int, members)
+	c.salts = make(map[string]string)
+	c.incarnations = make(map[string]uint64, members)
+	c.joined = make(map[string]uint64, members)
+	c.publish()
 	return c
 }
 
 // eltKey generates a string key for an element with an index.
 func (c *Consistent) eltKey(elt string, idx int) string {
 	// return elt + "|" + strconv.Itoa(idx)
-	return strconv.Itoa(idx) + elt
+	return core.VnodeKey(c.keyDeriver, elt, idx, c.salts[elt])
+}
+
+// vnodeHash returns the point of vnode idx of elt on the circle.
+func (c *Consistent) vnodeHash(elt string, idx int) uint32 {
+	return c.placeVnode(elt, idx, c.salts[elt], c.memberHashers[elt])
+}
+
+// placeVnode returns the point of vnode idx of elt added with salt and h, nil for the
+// hasher of the ring.
+func (c *Consistent) placeVnode(elt string, idx int, salt string, h Hasher) uint32 {
+	if c.ketama {
+		return ketamaPoint(elt, idx)
+	}
+	key := core.VnodeKey(c.keyDeriver, elt, idx, salt)
+	if h != nil {
+		return h.HashFunc(key)
+	}
+	return c.hashKey(key)
 }
 
 // Add inserts a string element in the consistent hash.
 func (c *Consistent) Add(elt string, numbersOfReplicas ...int) {
-	c.Lock()
-	defer c.Unlock()
-	if _, ok := c.members[elt]; ok {
-		return
+	var added bool
+	if hs := c.hooks.load(); hs != nil {
+		defer around(hs, "Add", elt)(nil, nil, &added)
 	}
 	numberOfReplicas := c.defaultNumberOfReplicas
 	if len(numbersOfReplicas) > 0 {
 		numberOfReplicas = numbersOfReplicas[0]
 	}
-	c.add(elt, numberOfReplicas)
+	c.warmupAdd(joiner{SetElt: SetElt{Elt: elt, NumberOfReplicas: numberOfReplicas}}, nil)
+	c.Lock()
+	defer c.unlockAndNotify()
+	if _, ok := c.members[elt]; ok {
+		return
+	}
+	added = c.add(elt, numberOfReplicas)
 }
 
 // need c.Lock() before calling
-func (c *Consistent) add(elt string, numberOfReplicas int) {
+// add inserts elt, which must not be a member, and reports whether it did: it does not if
+// the ring is full and Config.Eviction picks no member to evict for it.
+func (c *Consistent) add(elt string, numberOfReplicas int) bool {
+	if !c.makeRoom(elt, numberOfReplicas) {
+		delete(c.salts, elt)
+		delete(c.memberHashers, elt)
+		delete(c.metas, elt)
+		delete(c.zones, elt)
+		return false
+	}
+	c.captureMoves()
+	// the replicas and join order may decide the points elt collides on
+	c.membersReplicas[elt] = numberOfReplicas
+	c.joinSeq++
+	c.joined[elt] = c.joinSeq
+	points := make(uints, 0, numberOfReplicas)
 	for i := 0; i < numberOfReplicas; i++ {
-		c.circle[c.hashKey(c.eltKey(elt, i))] = elt
+		if h := c.vnodeHash(elt, i); c.claim(h, elt) {
+			points = append(points, h)
+		}
 	}
 	c.members[elt] = true
-	c.membersReplicas[elt] = numberOfReplicas
-	c.updateSortedHashes()
+	if c.flaps != nil {
+		c.recordFlap(elt)
+	}
+	c.insertSortedHashes(points)
 	c.count++
+	c.incarnations[elt]++
+	c.changes.Added = append(c.changes.Added, elt)
+	if c.changes.Incarnations == nil {
+		c.changes.Incarnations = make(map[string]uint64)
+	}
+	c.changes.Incarnations[elt] = c.incarnations[elt]
+	if c.capacity != nil {
+		c.capacity.heartbeats[elt] = c.capacity.now()
+	}
+	return true
 }
 
 // Remove removes an element from the hash.
-// return true for Remove success, false for Remove does not work
-func (c *Consistent) Remove(elt string) bool {
+// return true for Remove success, false for Remove does not work or is rejected by Config.Guard
+func (c *Consistent) Remove(elt string) (removed bool) {
+	if hs := c.hooks.load(); hs != nil {
+		defer around(hs, "Remove", elt)(nil, nil, &removed)
+	}
 	c.Lock()
-	defer c.Unlock()
+	defer c.unlockAndNotify()
 	if _, ok := c.members[elt]; !ok {
 		return false
 	}
@@ -125,28 +427,65 @@ func (c *Consistent) Remove(elt string) bool {
 	if !ok {
 		return false
 	}
+	if c.guard != nil && !c.allow([]string{elt}, nil) {
+		return false
+	}
 	c.remove(elt, numberOfReplicas)
 	return true
 }
 
 // need c.Lock() before calling
 func (c *Consistent) remove(elt string, numberOfReplicas int) {
+	c.deleteSortedHashes(c.removePoints(elt, numberOfReplicas))
+}
+
+// need c.Lock() before calling
+// removePoints removes elt like remove but leaves its points in the sorted hashes,
+// returning them for the caller to delete.
+func (c *Consistent) removePoints(elt string, numberOfReplicas int) uints {
+	c.captureMoves()
+	points := make(uints, 0, numberOfReplicas)
 	for i := 0; i < numberOfReplicas; i++ {
-		delete(c.circle, c.hashKey(c.eltKey(elt, i)))
+		if h := c.vnodeHash(elt, i); c.unclaim(h, elt) {
+			points = append(points, h)
+		}
 	}
 	delete(c.members, elt)
+	delete(c.joined, elt)
 	delete(c.membersReplicas, elt)
-	c.updateSortedHashes()
+	delete(c.salts, elt)
+	delete(c.memberHashers, elt)
+	delete(c.draining, elt)
+	delete(c.metas, elt)
+	delete(c.zones, elt)
+	delete(c.down, elt)
+	c.ttl.forget(elt)
+	if c.capacity != nil {
+		delete(c.capacity.heartbeats, elt)
+	}
+	if c.rates != nil {
+		c.rates.forget(elt)
+	}
+	c.load.forget(elt)
+	if c.flaps != nil {
+		c.recordFlap(elt)
+	}
 	c.count--
-	return
+	c.changes.Removed = append(c.changes.Removed, elt)
+	return points
 }
 
 // Set sets all the elements in the hash.  If there are existing elements not
 // present in elts, they will be removed.
 // defaultNumberOfReplicas will be used to add member
+// Set does nothing if the change is rejected by Config.Guard.
 func (c *Consistent) Set(elts []string) {
+	c.warmupSet(setElts(elts))
 	c.Lock()
-	defer c.Unlock()
+	defer c.unlockAndNotify()
+	if c.guard != nil && !c.allowSet(setElts(elts)) {
+		return
+	}
 	for k := range c.members {
 		found := false
 		for _, v := range elts {
@@ -175,11 +514,25 @@ type SetElt struct {
 	NumberOfReplicas int
 }
 
+// setElts returns elts with the default number of replicas.
+func setElts(elts []string) []SetElt {
+	set := make([]SetElt, len(elts))
+	for i, v := range elts {
+		set[i].Elt = v
+	}
+	return set
+}
+
 // SetWithReplicas sets all the elements in the hash with NumberOfReplicas.  If there are existing elements not
 // present in elts, they will be removed.
+// SetWithReplicas does nothing if the change is rejected by Config.Guard.
 func (c *Consistent) SetWithReplicas(elts []SetElt) {
+	c.warmupSet(elts)
 	c.Lock()
-	defer c.Unlock()
+	defer c.unlockAndNotify()
+	if c.guard != nil && !c.allowSet(elts) {
+		return
+	}
 	for k := range c.members {
 		found := false
 		for _, v := range elts {
@@ -206,6 +559,27 @@ func (c *Consistent) SetWithReplicas(elts []SetElt) {
 	}
 }
 
+// IsMember reports whether elt is a member, without copying the members like Members.
+func (c *Consistent) IsMember(elt string) bool {
+	c.RLock()
+	defer c.RUnlock()
+	return c.members[elt]
+}
+
+// MemberCount returns the number of members.
+func (c *Consistent) MemberCount() int {
+	c.RLock()
+	defer c.RUnlock()
+	return len(c.members)
+}
+
+// VnodeCount returns the number of vnodes keys are routed on, as Stats.Vnodes.
+func (c *Consistent) VnodeCount() int {
+	c.RLock()
+	defer c.RUnlock()
+	return len(c.sortedHashes)
+}
+
 func (c *Consistent) Members() []string {
 	c.RLock()
 	defer c.RUnlock()
@@ -226,26 +600,65 @@ func (c *Consistent) MemberReplicas() map[string]int {
 }
 
 // Get returns an element close to where name hashes to in the circle.
-func (c *Consistent) Get(name string) (string, error) {
+func (c *Consistent) Get(name string) (elt string, err error) {
+	if hs := c.hooks.load(); hs != nil {
+		defer around(hs, "Get", name)(&elt, &err, nil)
+	}
+	if v, _ := c.view.Load().(*readView); v != nil && v.plain {
+		if len(v.hashes) == 0 {
+			c.stats.lookup(ErrEmptyCircle)
+			return "", ErrEmptyCircle
+		}
+		c.stats.lookup(nil)
+		elt = v.owner(c.hashKey(name))
+		if c.rates != nil {
+			c.rates.record(elt)
+		}
+		return elt, nil
+	}
 	c.RLock()
 	defer c.RUnlock()
 	if len(c.circle) == 0 {
+		c.stats.lookup(ErrEmptyCircle)
 		return "", ErrEmptyCircle
 	}
-	key := c.hashKey(name)
-	i := c.search(key)
-	return c.circle[c.sortedHashes[i]], nil
+	elt, err = c.owner(name)
+	c.stats.lookup(err)
+	if err != nil {
+		return "", err
+	}
+	if c.rates != nil {
+		c.rates.record(elt)
+	}
+	return elt, nil
+}
+
+// need c.RLock() before calling
+// plain reports whether the owners of a key can be read from the circle alone, without
+// groups, overrides, weighted mode or members marked down.
+func (c *Consistent) plain() bool {
+	return !c.weightedMode && c.overrides == nil && len(c.groups) == 0 && len(c.down) == 0
 }
 
-func (c *Consistent) search(key uint32) (i int) {
-	f := func(x int) bool {
-		return c.sortedHashes[x] > key
+// need c.RLock() before calling
+// owner returns the owner Get returns for name, without recording rates or stats. The
+// circle must not be empty.
+func (c *Consistent) owner(name string) (string, error) {
+	if c.plain() {
+		return c.circle[c.sortedHashes[c.search(c.hashKey(name))]], nil
+	}
+	res, _, err := c.lookup(name, 1, 0, nil)
+	if err != nil {
+		return "", err
 	}
-	i = sort.Search(len(c.sortedHashes), f)
-	if i >= len(c.sortedHashes) {
-		i = 0
+	if len(res) == 0 {
+		return "", ErrEmptyCircle
 	}
-	return
+	return res[0], nil
+}
+
+func (c *Consistent) search(key uint32) int {
+	return searchHashes(c.sortedHashes, &c.index, key)
 }
 
 // GetTwo returns the two closest distinct elements to the name input in the circle.
@@ -253,11 +666,30 @@ func (c *Consistent) GetTwo(name string) (string, string, error) {
 	c.RLock()
 	defer c.RUnlock()
 	if len(c.circle) == 0 {
+		c.stats.lookup(ErrEmptyCircle)
 		return "", "", ErrEmptyCircle
 	}
+	if !c.plain() {
+		res, _, err := c.lookup(name, 2, 0, nil)
+		c.stats.lookup(err)
+		if err != nil {
+			return "", "", err
+		}
+		if c.rates != nil {
+			c.rates.record(res[0])
+		}
+		if len(res) == 1 {
+			return res[0], "", nil
+		}
+		return res[0], res[1], nil
+	}
+	c.stats.lookup(nil)
 	key := c.hashKey(name)
 	i := c.search(key)
 	a := c.circle[c.sortedHashes[i]]
+	if c.rates != nil {
+		c.rates.record(a)
+	}
 
 	if c.count == 1 {
 		return a, "", nil
@@ -274,88 +706,309 @@ func (c *Consistent) GetTwo(name string) (string, string, error) {
 			break
 		}
 	}
+	if b == a {
+		// every other member is quarantined
+		b = ""
+	}
 	return a, b, nil
 }
 
-// GetN returns the N closest distinct elements to the name input in the circle.
+// GetN returns the N closest distinct elements to the name input in the circle, or all
+// of them if n < 1.
+// The elements are in ring order: the first is the owner Get returns, the next is the
+// first distinct element after it clockwise, and so on. See GetNOrdered for other orderings.
 func (c *Consistent) GetN(name string, n int) ([]string, error) {
 	c.RLock()
 	defer c.RUnlock()
 
 	if len(c.circle) == 0 {
+		c.stats.lookup(ErrEmptyCircle)
 		return nil, ErrEmptyCircle
 	}
+	res, _, err := c.lookup(name, n, 0, nil)
+	c.stats.lookup(err)
+	if err != nil {
+		return nil, err
+	}
+	if c.rates != nil && len(res) > 0 {
+		c.rates.record(res[0])
+	}
+	return res, nil
+}
 
-	if c.count < int64(n) {
-		n = int(c.count)
+// need c.RLock() before calling
+// lookup returns up to n owners of name, all of them if n < 1, without recording rates or
+// stats: the member its group or the override table pins it to first, then the others in
+// the order of route. Members excluded for name by the override table are skipped like
+// those marked down.
+func (c *Consistent) lookup(name string, n, maxProbes int, skip func(string) bool) ([]string, bool, error) {
+	name, pin := c.grouped(name)
+	var excluded func(string) bool
+	if c.overrides != nil {
+		var override string
+		override, excluded = c.overrides.lookup(name)
+		if pin == "" && c.members[override] {
+			pin = override
+		}
 	}
+	return c.route(c.hashKey(name), n, maxProbes, pin, excluded, skip)
+}
 
-	var (
-		key   = c.hashKey(name)
-		i     = c.search(key)
-		start = i
-		res   = make([]string, 0, n)
-		elem  = c.circle[c.sortedHashes[i]]
-	)
+// need c.RLock() before calling
+// route returns up to n owners of the key hash key, all of them if n < 1: pin first if it
+// is not "", then the members met walking the circle clockwise from key, or ranked by
+// weight in WeightedRendezvous mode. It skips the members marked down, those excluded and
+// those for which skip returns true, stopping at the first n found or after maxProbes
+// points of the circle, 0 meaning no limit, which the bool reports. If every member left
+// is down or excluded, it returns them anyway rather than none; while the ring is
+// degraded, it follows Config.DegradedPolicy instead.
+func (c *Consistent) route(key uint32, n, maxProbes int, pin string, excluded, skip func(string) bool) ([]string, bool, error) {
+	if n < 1 || n > len(c.members) {
+		n = len(c.members)
+	}
+	if len(c.down) > 0 && c.degraded() {
+		res, err := c.getDegraded(key, n, skip)
+		return res, false, err
+	}
+	avoid := skip
+	if len(c.down) > 0 || excluded != nil {
+		avoid = func(m string) bool {
+			return c.down[m] || (excluded != nil && excluded(m)) || (skip != nil && skip(m))
+		}
+	}
+	if pin != "" && (c.down[pin] || (skip != nil && skip(pin))) {
+		pin = ""
+	}
+	res, cut := c.walk(key, n, maxProbes, pin, avoid)
+	if len(res) == 0 && !cut && (len(c.down) > 0 || excluded != nil) {
+		// every member left is down or excluded: route to them rather than nowhere
+		res, cut = c.walk(key, n, maxProbes, "", skip)
+	}
+	if len(res) == 0 && skip == nil {
+		// only members without weight are left in WeightedRendezvous mode
+		return nil, cut, ErrEmptyCircle
+	}
+	return res, cut, nil
+}
 
-	res = append(res, elem)
+// need c.RLock() before calling
+// walk returns pin, if not "", followed by the members getN or getWeightedHash find for
+// key, up to n in all.
+func (c *Consistent) walk(key uint32, n, maxProbes int, pin string, skip func(string) bool) ([]string, bool) {
+	if c.weightedMode {
+		return c.getWeightedHash(key, n, pin, skip), false
+	}
+	if pin == "" {
+		return c.getN(key, n, maxProbes, skip)
+	}
+	res := []string{pin}
+	if n <= 1 {
+		return res, false
+	}
+	rest, cut := c.getN(key, n-1, maxProbes, func(m string) bool {
+		return m == pin || (skip != nil && skip(m))
+	})
+	return append(res, rest...), cut
+}
 
-	if len(res) == n {
-		return res, nil
+// need c.RLock() before calling
+// getN walks the circle from key collecting up to n distinct elements, all of them if
+// n < 1, ignoring those for which skip returns true and inspecting at most maxProbes points
+// (0 means no limit). The bool result reports whether the walk was cut short by the probe
+// budget.
+func (c *Consistent) getN(key uint32, n int, maxProbes int, skip func(string) bool) ([]string, bool) {
+	if n < 1 || c.count < int64(n) {
+		n = int(c.count)
 	}
 
-	for i = start + 1; i != start; i++ {
-		if i >= len(c.sortedHashes) {
-			i = 0
+	var (
+		start   = c.search(key)
+		res     = make([]string, 0, n)
+		slivers []string
+	)
+
+	for j := 0; j < len(c.sortedHashes); j++ {
+		if maxProbes > 0 && j >= maxProbes {
+			return c.appendSlivers(res, slivers, n), true
 		}
-		elem = c.circle[c.sortedHashes[i]]
-		if !sliceContainsMember(res, elem) {
-			res = append(res, elem)
+		i := (start + j) % len(c.sortedHashes)
+		elem := c.circle[c.sortedHashes[i]]
+		if skip != nil && skip(elem) {
+			continue
+		}
+		if sliceContainsMember(res, elem) {
+			continue
+		}
+		if len(res) > 0 && c.isSliver(i) {
+			if !sliceContainsMember(slivers, elem) {
+				slivers = append(slivers, elem)
+			}
+			continue
 		}
-		if len(res) == n {
+		res = append(res, elem)
+		if len(res) >= n {
 			break
 		}
 	}
 
-	return res, nil
+	return c.appendSlivers(res, slivers, n), false
+}
+
+// need c.RLock() before calling
+// isSliver reports whether the arc owned by the vnode at position i is below MinReplicaArc.
+func (c *Consistent) isSliver(i int) bool {
+	if c.minReplicaArc == 0 || len(c.sortedHashes) < 2 {
+		return false
+	}
+	prev := c.sortedHashes[(i+len(c.sortedHashes)-1)%len(c.sortedHashes)]
+	return c.sortedHashes[i]-prev < c.minReplicaArc
+}
+
+// appendSlivers completes res up to n members with the members only reached through slivers.
+func (c *Consistent) appendSlivers(res, slivers []string, n int) []string {
+	for _, m := range slivers {
+		if len(res) >= n {
+			break
+		}
+		if !sliceContainsMember(res, m) {
+			res = append(res, m)
+		}
+	}
+	return res
 }
 
 func (c *Consistent) hashKey(key string) uint32 {
+	if c.ketama {
+		return ketamaKeyHash(key)
+	}
 	if c.customHasher != nil {
 		return c.customHasher.HashFunc(key)
 	}
 	if c.useFnv {
-		return c.hashKeyFnv(key)
+		return hashKeyFnv(key)
 	}
-	return c.hashKeyCRC32(key)
+	return c.algorithm.hash(key)
 }
 
-func (c *Consistent) hashKeyCRC32(key string) uint32 {
-	if len(key) < 64 {
-		var scratch [64]byte
-		copy(scratch[:], key)
-		return crc32.ChecksumIEEE(scratch[:len(key)])
-	}
-	return crc32.ChecksumIEEE([]byte(key))
+func hashKeyCRC32(key string) uint32 {
+	return core.HashCRC32(key)
 }
 
-func (c *Consistent) hashKeyFnv(key string) uint32 {
-	h := fnv.New32a()
-	h.Write([]byte(key))
-	return h.Sum32()
+func hashKeyFnv(key string) uint32 {
+	return core.HashFnv(key)
 }
 
 func (c *Consistent) updateSortedHashes() {
+	if p := c.profile; p != nil {
+		p.rebuilding(c.sortHashes)
+		return
+	}
+	c.sortHashes()
+}
+
+// need c.Lock() before calling
+func (c *Consistent) sortHashes() {
+	start := time.Now()
 	hashes := c.sortedHashes[:0]
-	//reallocate if we're holding on to too much (1/4th)
-	if cap(c.sortedHashes)/(c.defaultNumberOfReplicas*4) > len(c.circle) {
+	//reallocate if we're holding on to too much (1/4th), but keep the expected size
+	if cap(c.sortedHashes)/(c.defaultNumberOfReplicas*4) > len(c.circle) && cap(c.sortedHashes) > c.expectedVnodes {
 		hashes = nil
 	}
-	for k := range c.circle {
+	for k, elt := range c.circle {
+		if c.isQuarantined(elt) {
+			continue
+		}
 		hashes = append(hashes, k)
 	}
-	sort.Sort(hashes)
+	if len(hashes) == 0 && len(c.circle) > 0 {
+		// everything is quarantined: route to the quarantined members rather than nowhere
+		for k := range c.circle {
+			hashes = append(hashes, k)
+		}
+	}
+	if c.parallelThreshold > 0 && len(hashes) >= c.parallelThreshold {
+		parallelSort(hashes, runtime.GOMAXPROCS(0))
+	} else {
+		sort.Sort(hashes)
+	}
+	c.setSortedHashes(c.capShares(c.circle, hashes), start)
+}
+
+// need c.Lock() before calling
+// insertSortedHashes merges the new points of the circle into the sorted hashes, which
+// costs O(V) instead of the O(V log V) of a rebuild.
+func (c *Consistent) insertSortedHashes(points uints) {
+	if c.maxShare > 0 || c.flaps != nil && len(c.flaps.quarantined) > 0 {
+		c.updateSortedHashes()
+		return
+	}
+	if p := c.profile; p != nil {
+		p.rebuilding(func() { c.mergeSortedHashes(points) })
+		return
+	}
+	c.mergeSortedHashes(points)
+}
+
+// need c.Lock() before calling
+func (c *Consistent) mergeSortedHashes(points uints) {
+	start := time.Now()
+	sort.Sort(points)
+	n := len(c.sortedHashes)
+	hashes := append(c.sortedHashes, points...)
+	// merge from the end so the hashes already in place are moved at most once
+	i, j := n-1, len(points)-1
+	for k := len(hashes) - 1; j >= 0; k-- {
+		if i >= 0 && hashes[i] > points[j] {
+			hashes[k] = hashes[i]
+			i--
+		} else {
+			hashes[k] = points[j]
+			j--
+		}
+	}
+	c.setSortedHashes(hashes, start)
+}
+
+// need c.Lock() before calling
+// deleteSortedHashes removes the points deleted from the circle from the sorted hashes.
+func (c *Consistent) deleteSortedHashes(points uints) {
+	if c.maxShare > 0 || c.flaps != nil && len(c.flaps.quarantined) > 0 {
+		c.updateSortedHashes()
+		return
+	}
+	if p := c.profile; p != nil {
+		p.rebuilding(func() { c.pruneSortedHashes(points) })
+		return
+	}
+	c.pruneSortedHashes(points)
+}
+
+// need c.Lock() before calling
+func (c *Consistent) pruneSortedHashes(points uints) {
+	start := time.Now()
+	sort.Sort(points)
+	hashes := c.sortedHashes[:0]
+	j := 0
+	for _, h := range c.sortedHashes {
+		for j < len(points) && points[j] < h {
+			j++
+		}
+		if j < len(points) && points[j] == h {
+			continue
+		}
+		hashes = append(hashes, h)
+	}
+	c.setSortedHashes(hashes, start)
+}
+
+// need c.Lock() before calling
+func (c *Consistent) setSortedHashes(hashes uints, start time.Time) {
 	c.sortedHashes = hashes
+	c.index.build(hashes)
+	if c.weightedMode {
+		c.updateWeighted()
+	}
+	c.stats.rebuilt(len(hashes), time.Since(start), c.onRebuild)
 }
 
 func sliceContainsMember(set []string, member string) bool {
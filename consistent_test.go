@@ -53,6 +53,20 @@ func TestAdd(t *testing.T) {
 	}
 }
 
+func TestMemberCounts(t *testing.T) {
+	x := New(newConfig())
+	x.Add("abcdefg")
+	x.Add("qwer", 10)
+	if !x.IsMember("qwer") || x.IsMember("zxcv") {
+		t.Errorf("expected only qwer to be a member")
+	}
+	checkNum(x.MemberCount(), 2, t)
+	checkNum(x.VnodeCount(), 30, t)
+	x.Remove("qwer")
+	checkNum(x.MemberCount(), 1, t)
+	checkNum(x.VnodeCount(), 20, t)
+}
+
 func TestRemove(t *testing.T) {
 	x := New(newConfig())
 	x.Add("abcdefg")
@@ -322,6 +336,22 @@ func TestGetN(t *testing.T) {
 	}
 }
 
+func TestGetNZero(t *testing.T) {
+	x := New(newConfig())
+	x.Add("abcdefg")
+	x.Add("hijklmn")
+	x.Add("opqrstu")
+	for _, n := range []int{0, -1} {
+		members, err := x.GetN("9999999", n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(members) != 3 || members[0] != "opqrstu" {
+			t.Errorf("GetN(%d): expected all members, got %q", n, members)
+		}
+	}
+}
+
 func TestGetNLess(t *testing.T) {
 	x := New(newConfig())
 	x.Add("abcdefg")
@@ -0,0 +1,198 @@
+// Package consistenttest provides helpers for testing code built on package consistent.
+package consistenttest
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/jiangz222/consistent"
+	"github.com/jiangz222/consistent/core"
+)
+
+var (
+	// ErrOnRing is returned by Collide for a member or a vnode key of one, whose points
+	// would move under the ring.
+	ErrOnRing = errors.New("consistenttest: key already on the ring")
+	// ErrKetama is returned by Collide on a KetamaCompatible ring, which places members and
+	// hashes keys with MD5 whatever the hasher.
+	ErrKetama = errors.New("consistenttest: collisions cannot be forced on a KetamaCompatible ring")
+)
+
+// Chaos wraps a ring and injects deterministic faults: forced hash collisions, member
+// flaps and delayed propagation of membership changes.
+type Chaos struct {
+	ring   *consistent.Consistent
+	hasher *collidingHasher
+	conf   consistent.Config
+
+	mu      sync.Mutex
+	delay   int
+	lookups int
+	pending []pendingChange
+}
+
+type pendingChange struct {
+	due   int
+	apply func()
+}
+
+// NewChaos creates a ring from conf whose hashing can be tampered with through Collide.
+// Keys not collided hash as on a ring created from conf.
+func NewChaos(conf consistent.Config) *Chaos {
+	h := &collidingHasher{base: consistent.New(conf), forced: make(map[string]string)}
+	c := &Chaos{hasher: h, conf: conf}
+	conf.CustomHasher = h
+	c.ring = consistent.New(conf)
+	return c
+}
+
+// Ring returns the wrapped ring. Changes made on it directly are not delayed.
+func (c *Chaos) Ring() *consistent.Consistent {
+	return c.ring
+}
+
+// Collide makes key hash to the same value as other. It applies to lookup keys and to the
+// vnode keys, as derived by core.VnodeKey with Config.KeyDeriver, of members added
+// afterwards. It fails with ErrOnRing if key is a member or a vnode key of one, and with
+// ErrKetama on a KetamaCompatible ring.
+func (c *Chaos) Collide(key, other string) error {
+	if c.conf.KetamaCompatible {
+		return ErrKetama
+	}
+	for m, replicas := range c.ring.MemberReplicas() {
+		if key == m {
+			return ErrOnRing
+		}
+		salt := c.ring.Salt(m)
+		for i := 0; i < replicas; i++ {
+			if core.VnodeKey(c.conf.KeyDeriver, m, i, salt) == key {
+				return ErrOnRing
+			}
+		}
+	}
+	c.hasher.Lock()
+	defer c.hasher.Unlock()
+	c.hasher.forced[key] = other
+	return nil
+}
+
+// DelayChanges makes Add, Remove and Set take effect only after the given number of
+// further lookups. 0 applies changes immediately, and flushes nothing already queued.
+func (c *Chaos) DelayChanges(lookups int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.delay = lookups
+}
+
+// Propagate applies all queued changes now.
+func (c *Chaos) Propagate() {
+	c.mu.Lock()
+	pending := c.pending
+	c.pending = nil
+	c.mu.Unlock()
+	for _, p := range pending {
+		p.apply()
+	}
+}
+
+// Pending returns the number of queued changes.
+func (c *Chaos) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.pending)
+}
+
+// Flap removes member and adds it back with the same number of replicas, times times,
+// bypassing any propagation delay. It reports false if member is not in the ring.
+func (c *Chaos) Flap(member string, times int) bool {
+	replicas, ok := c.ring.MemberReplicas()[member]
+	if !ok {
+		return false
+	}
+	for i := 0; i < times; i++ {
+		c.ring.Remove(member)
+		c.ring.Add(member, replicas)
+	}
+	return true
+}
+
+// Add inserts elt, subject to DelayChanges.
+func (c *Chaos) Add(elt string, numbersOfReplicas ...int) {
+	c.change(func() { c.ring.Add(elt, numbersOfReplicas...) })
+}
+
+// Remove removes elt, subject to DelayChanges.
+func (c *Chaos) Remove(elt string) {
+	c.change(func() { c.ring.Remove(elt) })
+}
+
+// Set replaces the members, subject to DelayChanges.
+func (c *Chaos) Set(elts []string) {
+	elts = append([]string(nil), elts...)
+	c.change(func() { c.ring.Set(elts) })
+}
+
+// Get looks name up in the ring after applying the changes that became due.
+func (c *Chaos) Get(name string) (string, error) {
+	c.lookup()
+	return c.ring.Get(name)
+}
+
+// GetTwo looks name up in the ring after applying the changes that became due.
+func (c *Chaos) GetTwo(name string) (string, string, error) {
+	c.lookup()
+	return c.ring.GetTwo(name)
+}
+
+// GetN looks name up in the ring after applying the changes that became due.
+func (c *Chaos) GetN(name string, n int) ([]string, error) {
+	c.lookup()
+	return c.ring.GetN(name, n)
+}
+
+// Members returns the members of the wrapped ring.
+func (c *Chaos) Members() []string {
+	return c.ring.Members()
+}
+
+func (c *Chaos) change(apply func()) {
+	c.mu.Lock()
+	if c.delay <= 0 && len(c.pending) == 0 {
+		c.mu.Unlock()
+		apply()
+		return
+	}
+	c.pending = append(c.pending, pendingChange{due: c.lookups + c.delay, apply: apply})
+	c.mu.Unlock()
+}
+
+func (c *Chaos) lookup() {
+	c.mu.Lock()
+	c.lookups++
+	var due []pendingChange
+	for len(c.pending) > 0 && c.pending[0].due < c.lookups {
+		due = append(due, c.pending[0])
+		c.pending = c.pending[1:]
+	}
+	c.mu.Unlock()
+	for _, p := range due {
+		p.apply()
+	}
+}
+
+// collidingHasher hashes keys like base, an empty ring with the configured hashing, unless
+// they were redirected to another key by Collide.
+type collidingHasher struct {
+	base *consistent.Consistent
+	sync.RWMutex
+	forced map[string]string
+}
+
+func (h *collidingHasher) HashFunc(key string) uint32 {
+	h.RLock()
+	if other, ok := h.forced[key]; ok {
+		key = other
+	}
+	h.RUnlock()
+	return h.base.HashKey(key)
+}
@@ -0,0 +1,114 @@
+package consistenttest
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/jiangz222/consistent"
+	"github.com/jiangz222/consistent/core"
+)
+
+func TestChaosMatchesRing(t *testing.T) {
+	x := consistent.New(consistent.Config{DefaultNumberOfReplicas: 20})
+	c := NewChaos(consistent.Config{DefaultNumberOfReplicas: 20})
+	for _, m := range []string{"abcdefg", "hijklmn", "opqrstu"} {
+		x.Add(m)
+		c.Add(m)
+	}
+	for _, key := range []string{"ggg", "hhh", "iiiii", "99999999"} {
+		a, _ := x.Get(key)
+		b, err := c.Get(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a != b {
+			t.Errorf("%s: got %q, expected %q", key, b, a)
+		}
+	}
+}
+
+func TestChaosCollide(t *testing.T) {
+	c := NewChaos(consistent.Config{DefaultNumberOfReplicas: 20})
+	c.Add("abcdefg")
+	c.Add("hijklmn")
+	c.Add("opqrstu")
+	a, _ := c.Get("ggg")
+	b, _ := c.Get("hhh")
+	if a == b {
+		t.Fatalf("test keys should start on different members")
+	}
+	if err := c.Collide("hhh", "ggg"); err != nil {
+		t.Fatal(err)
+	}
+	b, _ = c.Get("hhh")
+	if a != b {
+		t.Errorf("expected collided keys to share %q, got %q", a, b)
+	}
+	for _, key := range []string{"abcdefg", "3hijklmn"} {
+		if err := c.Collide(key, "ggg"); err != ErrOnRing {
+			t.Errorf("%s: got %v, expected ErrOnRing", key, err)
+		}
+	}
+	if err := NewChaos(consistent.Config{KetamaCompatible: true}).Collide("hhh", "ggg"); err != ErrKetama {
+		t.Errorf("got %v, expected ErrKetama", err)
+	}
+}
+
+func TestChaosHashing(t *testing.T) {
+	for _, conf := range []consistent.Config{
+		{DefaultNumberOfReplicas: 20, Hash: consistent.HashMurmur3},
+		{DefaultNumberOfReplicas: 20, UseFnv: true, KeyDeriver: core.LengthPrefixedKeyDeriver},
+	} {
+		x := consistent.New(conf)
+		c := NewChaos(conf)
+		for _, m := range []string{"abcdefg", "hijklmn", "opqrstu"} {
+			x.Add(m)
+			c.Add(m)
+		}
+		for i := 0; i < 100; i++ {
+			key := "user" + strconv.Itoa(i)
+			a, _ := x.Get(key)
+			if b, _ := c.Get(key); a != b {
+				t.Errorf("%s: got %q, expected %q", key, b, a)
+			}
+		}
+	}
+}
+
+func TestChaosDelayChanges(t *testing.T) {
+	c := NewChaos(consistent.Config{DefaultNumberOfReplicas: 20})
+	c.Add("abcdefg")
+	c.DelayChanges(2)
+	c.Remove("abcdefg")
+	c.Add("hijklmn")
+	if c.Pending() != 2 {
+		t.Fatalf("expected 2 pending changes, got %d", c.Pending())
+	}
+	for i := 0; i < 2; i++ {
+		if m, _ := c.Get("x"); m != "abcdefg" {
+			t.Errorf("lookup %d: got %q before propagation", i, m)
+		}
+	}
+	if m, _ := c.Get("x"); m != "hijklmn" {
+		t.Errorf("got %q after propagation", m)
+	}
+	c.Add("opqrstu")
+	c.Propagate()
+	if len(c.Members()) != 2 {
+		t.Errorf("expected 2 members, got %v", c.Members())
+	}
+}
+
+func TestChaosFlap(t *testing.T) {
+	c := NewChaos(consistent.Config{DefaultNumberOfReplicas: 20})
+	c.Add("abcdefg", 30)
+	if !c.Flap("abcdefg", 3) {
+		t.Fatalf("expected flap to succeed")
+	}
+	if r := c.Ring().MemberReplicas()["abcdefg"]; r != 30 {
+		t.Errorf("expected replicas to survive flaps, got %d", r)
+	}
+	if c.Flap("missing", 1) {
+		t.Errorf("expected flap of a missing member to fail")
+	}
+}
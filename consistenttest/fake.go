@@ -0,0 +1,155 @@
+package consistenttest
+
+import (
+	"sync"
+
+	"github.com/jiangz222/consistent"
+)
+
+var (
+	_ consistent.Locator = (*Fake)(nil)
+	_ consistent.Locator = (*Chaos)(nil)
+)
+
+// Call records one lookup made on a Fake.
+type Call struct {
+	Method string // "Get", "GetTwo", "GetN" or "Members"
+	Name   string
+	N      int
+}
+
+// Fake is a consistent.Locator returning scripted answers and recording every call.
+// The zero value answers every lookup with consistent.ErrEmptyCircle.
+type Fake struct {
+	mu       sync.Mutex
+	answers  map[string][]string
+	fallback []string
+	err      error
+	members  []string
+	calls    []Call
+}
+
+// Script makes lookups of name return owners, in order.
+func (f *Fake) Script(name string, owners ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.answers == nil {
+		f.answers = make(map[string][]string)
+	}
+	f.answers[name] = owners
+}
+
+// Default sets the owners returned for names that were not scripted.
+func (f *Fake) Default(owners ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fallback = owners
+}
+
+// Fail makes every lookup return err. A nil err restores the scripted answers.
+func (f *Fake) Fail(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+}
+
+// SetMembers sets the result of Members. By default it is every scripted owner.
+func (f *Fake) SetMembers(members ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.members = members
+}
+
+// Calls returns the calls made so far.
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+// Reset forgets the recorded calls.
+func (f *Fake) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = nil
+}
+
+// Get returns the first scripted owner of name.
+func (f *Fake) Get(name string) (string, error) {
+	owners, err := f.lookup(Call{Method: "Get", Name: name, N: 1})
+	if err != nil {
+		return "", err
+	}
+	return owners[0], nil
+}
+
+// GetTwo returns the first two scripted owners of name.
+func (f *Fake) GetTwo(name string) (string, string, error) {
+	owners, err := f.lookup(Call{Method: "GetTwo", Name: name, N: 2})
+	if err != nil {
+		return "", "", err
+	}
+	if len(owners) < 2 {
+		return owners[0], "", nil
+	}
+	return owners[0], owners[1], nil
+}
+
+// GetN returns up to n scripted owners of name, all of them if n < 1.
+func (f *Fake) GetN(name string, n int) ([]string, error) {
+	owners, err := f.lookup(Call{Method: "GetN", Name: name, N: n})
+	if err != nil {
+		return nil, err
+	}
+	if n >= 1 && n < len(owners) {
+		owners = owners[:n]
+	}
+	return append([]string(nil), owners...), nil
+}
+
+// Members returns the members set with SetMembers, or every scripted owner.
+func (f *Fake) Members() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, Call{Method: "Members"})
+	if f.members != nil {
+		return append([]string(nil), f.members...)
+	}
+	var m []string
+	add := func(owners []string) {
+		for _, o := range owners {
+			found := false
+			for _, v := range m {
+				if v == o {
+					found = true
+					break
+				}
+			}
+			if !found {
+				m = append(m, o)
+			}
+		}
+	}
+	add(f.fallback)
+	for _, owners := range f.answers {
+		add(owners)
+	}
+	return m
+}
+
+func (f *Fake) lookup(call Call) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, call)
+	if f.err != nil {
+		return nil, f.err
+	}
+	owners, ok := f.answers[call.Name]
+	if !ok {
+		owners = f.fallback
+	}
+	if len(owners) == 0 {
+		return nil, consistent.ErrEmptyCircle
+	}
+	return owners, nil
+}
@@ -0,0 +1,69 @@
+package consistenttest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jiangz222/consistent"
+)
+
+func TestFakeScript(t *testing.T) {
+	var f Fake
+	if _, err := f.Get("x"); err != consistent.ErrEmptyCircle {
+		t.Errorf("expected empty circle error from zero Fake, got %v", err)
+	}
+	f.Script("user:1", "cacheA", "cacheB")
+	f.Default("cacheC")
+	if m, _ := f.Get("user:1"); m != "cacheA" {
+		t.Errorf("got %q, expected cacheA", m)
+	}
+	if a, b, _ := f.GetTwo("user:1"); a != "cacheA" || b != "cacheB" {
+		t.Errorf("got %q, %q", a, b)
+	}
+	if a, b, _ := f.GetTwo("user:2"); a != "cacheC" || b != "" {
+		t.Errorf("got %q, %q", a, b)
+	}
+	if m, _ := f.GetN("user:1", 1); len(m) != 1 || m[0] != "cacheA" {
+		t.Errorf("got %q", m)
+	}
+	for _, n := range []int{0, -1} {
+		if m, _ := f.GetN("user:1", n); len(m) != 2 || m[0] != "cacheA" || m[1] != "cacheB" {
+			t.Errorf("n=%d: got %q, expected every scripted owner", n, m)
+		}
+	}
+	if len(f.Members()) != 3 {
+		t.Errorf("expected all scripted owners as members, got %q", f.Members())
+	}
+}
+
+func TestFakeCalls(t *testing.T) {
+	var f Fake
+	f.Default("cacheA")
+	f.Get("a")
+	f.GetN("b", 3)
+	calls := f.Calls()
+	if len(calls) != 2 {
+		t.Fatalf("expected 2 calls, got %d", len(calls))
+	}
+	if calls[0] != (Call{Method: "Get", Name: "a", N: 1}) || calls[1] != (Call{Method: "GetN", Name: "b", N: 3}) {
+		t.Errorf("unexpected calls %+v", calls)
+	}
+	f.Reset()
+	if len(f.Calls()) != 0 {
+		t.Errorf("expected no calls after Reset")
+	}
+}
+
+func TestFakeFail(t *testing.T) {
+	var f Fake
+	f.Default("cacheA")
+	boom := errors.New("boom")
+	f.Fail(boom)
+	if _, err := f.Get("a"); err != boom {
+		t.Errorf("expected scripted error, got %v", err)
+	}
+	f.Fail(nil)
+	if _, err := f.Get("a"); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package consistenttest
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/jiangz222/consistent"
+)
+
+// RunOps decodes data into a sequence of Add, Remove, Set and lookup calls on c and checks
+// after every call that c passes Verify, that lookups are deterministic and that they only
+// return members. It returns the first violation. Every 3 bytes of data are one call, with
+// member names drawn from a small set so calls interact. Fuzz targets can feed it arbitrary
+// input, see FuzzOps in this package's tests.
+func RunOps(c *consistent.Consistent, data []byte) error {
+	for i := 0; i+3 <= len(data); i += 3 {
+		op, a, b := data[i]%6, data[i+1], data[i+2]
+		elt := "m" + strconv.Itoa(int(a%16))
+		var desc string
+		switch op {
+		case 0:
+			desc = "Add " + elt
+			c.Add(elt)
+		case 1:
+			desc = "Add " + elt + " with " + strconv.Itoa(int(b%64)) + " replicas"
+			c.Add(elt, int(b%64))
+		case 2:
+			desc = "Remove " + elt
+			c.Remove(elt)
+		case 3:
+			var elts []string
+			for j := uint(0); j < 8; j++ {
+				if b&(1<<j) != 0 {
+					elts = append(elts, "m"+strconv.Itoa(int(a%8+byte(j))))
+				}
+			}
+			desc = fmt.Sprintf("Set %v", elts)
+			c.Set(elts)
+		case 4:
+			desc = "AddWithSalt " + elt
+			c.AddWithSalt(elt, strconv.Itoa(int(b%4)))
+		default:
+			desc = "lookups"
+			if err := checkLookups(c, "key"+strconv.Itoa(int(a)<<8|int(b)), int(b%5)); err != nil {
+				return fmt.Errorf("op %d (%s): %v", i/3, desc, err)
+			}
+		}
+		if err := c.Verify(); err != nil {
+			return fmt.Errorf("op %d (%s): %v", i/3, desc, err)
+		}
+	}
+	return nil
+}
+
+func checkLookups(c *consistent.Consistent, key string, n int) error {
+	members := make(map[string]bool)
+	for _, m := range c.Members() {
+		members[m] = true
+	}
+	a, errA := c.Get(key)
+	b, errB := c.Get(key)
+	if a != b || errA != errB {
+		return fmt.Errorf("Get(%q) returned %q, %v then %q, %v", key, a, errA, b, errB)
+	}
+	if len(members) == 0 && errA != consistent.ErrEmptyCircle {
+		return fmt.Errorf("Get(%q) on an empty ring returned %q, %v", key, a, errA)
+	}
+	if errA == consistent.ErrEmptyCircle {
+		// members added with 0 replicas leave the circle empty
+		return nil
+	}
+	if errA != nil || !members[a] {
+		return fmt.Errorf("Get(%q) returned %q, %v", key, a, errA)
+	}
+	res, err := c.GetN(key, n)
+	if err != nil {
+		return err
+	}
+	seen := make(map[string]bool)
+	for _, m := range res {
+		if !members[m] || seen[m] {
+			return fmt.Errorf("GetN(%q, %d) returned %v", key, n, res)
+		}
+		seen[m] = true
+	}
+	if n > 0 && (len(res) == 0 || res[0] != a) {
+		return fmt.Errorf("GetN(%q, %d) returned %v, Get returned %q", key, n, res, a)
+	}
+	return nil
+}
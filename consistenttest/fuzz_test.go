@@ -0,0 +1,29 @@
+package consistenttest
+
+import (
+	"testing"
+
+	"github.com/jiangz222/consistent"
+)
+
+func FuzzOps(f *testing.F) {
+	f.Add([]byte{0, 1, 0, 0, 2, 0, 5, 7, 9, 2, 1, 0, 5, 3, 3})
+	f.Add([]byte{3, 0, 255, 5, 1, 2, 3, 2, 6, 5, 9, 9, 1, 3, 63, 4, 3, 1})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		c := consistent.New(consistent.Config{DefaultNumberOfReplicas: 20})
+		if err := RunOps(c, data); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestRunOpsLargeRing(t *testing.T) {
+	c := consistent.New(consistent.Config{DefaultNumberOfReplicas: 200})
+	data := make([]byte, 0, 300)
+	for i := 0; i < 100; i++ {
+		data = append(data, byte(i%6), byte(i*7), byte(i*13))
+	}
+	if err := RunOps(c, data); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,240 @@
+// Package consulsync keeps the membership of a ring equal to the passing instances of a
+// Consul service, with replicas in proportion to their Consul weights.
+//
+// It talks to the HTTP API of the Consul agent directly, through the health endpoint
+// /v1/health/service/<service>?passing, either with blocking queries returning as soon as
+// the instances change or by polling, and does not depend on the Consul client library.
+package consulsync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jiangz222/consistent"
+)
+
+const (
+	// DefaultAddress is the address of the Consul agent when Address is not set.
+	DefaultAddress = "http://127.0.0.1:8500"
+	// DefaultReplicasPerWeight is the number of replicas per unit of Consul weight when
+	// ReplicasPerWeight is not set, so instances of the default weight 1 get the usual 20.
+	DefaultReplicasPerWeight = 20
+	// DefaultPollInterval is the time between polls when neither Wait nor PollInterval is set.
+	DefaultPollInterval = 10 * time.Second
+	// DefaultRetryDelay is the time to wait after a failed query when RetryDelay is not set.
+	DefaultRetryDelay = time.Second
+)
+
+// Instance is a passing instance of the service.
+type Instance struct {
+	ID      string
+	Node    string
+	Address string // of the service, or of its node if the service has none
+	Port    int
+	Tags    []string
+	// Weight is the passing weight of the instance, 1 unless set in its registration.
+	Weight int
+}
+
+// Syncer applies the passing instances of Service to Ring with Restore, each instance
+// getting Weight * ReplicasPerWeight replicas, so weight changes move keys too.
+type Syncer struct {
+	// Address is the base URL of the Consul agent. Defaults to DefaultAddress.
+	Address string
+	Service string
+	Ring    *consistent.Consistent
+	// Client makes the requests. Defaults to http.DefaultClient.
+	Client *http.Client
+	// Token, Datacenter and Tag, if set, are the ACL token of the requests, the
+	// datacenter to query and the tag instances must have.
+	Token      string
+	Datacenter string
+	Tag        string
+	// Wait enables blocking queries waiting at most Wait for a change. With 0, the
+	// instances are polled every PollInterval, or DefaultPollInterval.
+	Wait         time.Duration
+	PollInterval time.Duration
+	// ReplicasPerWeight defaults to DefaultReplicasPerWeight.
+	ReplicasPerWeight int
+	// Name returns the member name of an instance. Defaults to "address:port".
+	Name func(Instance) string
+	// OnError, if set, is called with the errors of the queries.
+	OnError func(error)
+	// RetryDelay is the time to wait after a failed query. Defaults to DefaultRetryDelay.
+	RetryDelay time.Duration
+}
+
+// New creates a Syncer applying the passing instances of service, as seen by the agent at
+// address, to ring.
+func New(address, service string, ring *consistent.Consistent) *Syncer {
+	return &Syncer{Address: address, Service: service, Ring: ring}
+}
+
+// Run applies the passing instances and follows their changes until ctx is done. It
+// returns ctx.Err().
+func (s *Syncer) Run(ctx context.Context) error {
+	var index uint64
+	for {
+		next, err := s.sync(ctx, index)
+		delay := s.PollInterval
+		if delay <= 0 {
+			delay = DefaultPollInterval
+		}
+		switch {
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case err != nil:
+			if s.OnError != nil {
+				s.OnError(err)
+			}
+			delay = s.RetryDelay
+			if delay <= 0 {
+				delay = DefaultRetryDelay
+			}
+		case s.Wait > 0:
+			// an index going backwards means the agent lost its state: start over
+			if next < index {
+				next = 0
+			}
+			index = next
+			continue
+		}
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// Sync queries the passing instances once and applies them.
+func (s *Syncer) Sync(ctx context.Context) error {
+	_, err := s.sync(ctx, 0)
+	return err
+}
+
+// sync queries the passing instances, blocking while the Consul index is index, applies
+// them and returns the new index.
+func (s *Syncer) sync(ctx context.Context, index uint64) (uint64, error) {
+	instances, next, err := s.query(ctx, index)
+	if err != nil {
+		return 0, err
+	}
+	if index != 0 && next == index {
+		// the blocking query timed out without a change
+		return next, nil
+	}
+	perWeight := s.ReplicasPerWeight
+	if perWeight <= 0 {
+		perWeight = DefaultReplicasPerWeight
+	}
+	snap := consistent.Snapshot{Members: make([]consistent.SnapshotMember, 0, len(instances))}
+	seen := make(map[string]bool, len(instances))
+	for _, in := range instances {
+		name := s.name(in)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		snap.Members = append(snap.Members, consistent.SnapshotMember{Name: name, Replicas: in.Weight * perWeight})
+	}
+	s.Ring.Restore(snap)
+	return next, nil
+}
+
+func (s *Syncer) name(in Instance) string {
+	if s.Name != nil {
+		return s.Name(in)
+	}
+	return in.Address + ":" + strconv.Itoa(in.Port)
+}
+
+// healthEntry is the part of an entry of /v1/health/service the Syncer reads.
+type healthEntry struct {
+	Node struct {
+		Node    string
+		Address string
+	}
+	Service struct {
+		ID      string
+		Address string
+		Port    int
+		Tags    []string
+		Weights struct {
+			Passing int
+		}
+	}
+}
+
+// query returns the passing instances and the Consul index of the answer.
+func (s *Syncer) query(ctx context.Context, index uint64) ([]Instance, uint64, error) {
+	addr := s.Address
+	if addr == "" {
+		addr = DefaultAddress
+	}
+	q := url.Values{"passing": {"true"}}
+	if s.Datacenter != "" {
+		q.Set("dc", s.Datacenter)
+	}
+	if s.Tag != "" {
+		q.Set("tag", s.Tag)
+	}
+	if s.Wait > 0 && index > 0 {
+		q.Set("index", strconv.FormatUint(index, 10))
+		q.Set("wait", strconv.FormatInt(int64(s.Wait/time.Millisecond), 10)+"ms")
+	}
+	u := strings.TrimSuffix(addr, "/") + "/v1/health/service/" + url.PathEscape(s.Service) + "?" + q.Encode()
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	req = req.WithContext(ctx)
+	if s.Token != "" {
+		req.Header.Set("X-Consul-Token", s.Token)
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("consulsync: %s: %s", u, resp.Status)
+	}
+	var entries []healthEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, 0, fmt.Errorf("consulsync: %s: %v", u, err)
+	}
+	next, _ := strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64)
+	res := make([]Instance, 0, len(entries))
+	for _, e := range entries {
+		in := Instance{
+			ID:      e.Service.ID,
+			Node:    e.Node.Node,
+			Address: e.Service.Address,
+			Port:    e.Service.Port,
+			Tags:    e.Service.Tags,
+			Weight:  e.Service.Weights.Passing,
+		}
+		if in.Address == "" {
+			in.Address = e.Node.Address
+		}
+		if in.Weight <= 0 {
+			// registered by an agent predating weights
+			in.Weight = 1
+		}
+		res = append(res, in)
+	}
+	return res, next, nil
+}
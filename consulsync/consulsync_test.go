@@ -0,0 +1,141 @@
+package consulsync
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jiangz222/consistent"
+)
+
+// agent serves /v1/health/service/web, answering blocking queries when the index changes.
+type agent struct {
+	mu      sync.Mutex
+	index   uint64
+	body    string
+	changed chan struct{}
+	queries []string
+}
+
+func newAgent(body string) *agent {
+	return &agent{index: 1, body: body, changed: make(chan struct{})}
+}
+
+func (a *agent) set(body string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.index++
+	a.body = body
+	close(a.changed)
+	a.changed = make(chan struct{})
+}
+
+func (a *agent) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/v1/health/service/web" || r.URL.Query().Get("passing") != "true" {
+		http.NotFound(w, r)
+		return
+	}
+	a.mu.Lock()
+	a.queries = append(a.queries, r.URL.RawQuery)
+	changed := a.changed
+	index := a.index
+	a.mu.Unlock()
+	if i, _ := strconv.ParseUint(r.URL.Query().Get("index"), 10, 64); i == index {
+		select {
+		case <-changed:
+		case <-time.After(50 * time.Millisecond):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	w.Header().Set("X-Consul-Index", strconv.FormatUint(a.index, 10))
+	w.Write([]byte(a.body))
+}
+
+const (
+	twoInstances = `[
+		{"Node": {"Node": "n1", "Address": "10.0.0.1"}, "Service": {"ID": "web1", "Port": 80, "Weights": {"Passing": 1}}},
+		{"Node": {"Node": "n2", "Address": "10.0.0.2"}, "Service": {"ID": "web2", "Address": "10.1.0.2", "Port": 80, "Weights": {"Passing": 3}}}
+	]`
+	oneInstance = `[
+		{"Node": {"Node": "n2", "Address": "10.0.0.2"}, "Service": {"ID": "web2", "Address": "10.1.0.2", "Port": 80, "Weights": {"Passing": 2}}}
+	]`
+)
+
+func TestSync(t *testing.T) {
+	srv := httptest.NewServer(newAgent(twoInstances))
+	defer srv.Close()
+	c := consistent.New(consistent.Config{DefaultNumberOfReplicas: 20})
+	s := New(srv.URL, "web", c)
+	s.ReplicasPerWeight = 10
+	if err := s.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	r := c.MemberReplicas()
+	if len(r) != 2 || r["10.0.0.1:80"] != 10 || r["10.1.0.2:80"] != 30 {
+		t.Errorf("unexpected members %v", r)
+	}
+
+	s.Service = "api"
+	if err := s.Sync(context.Background()); err == nil {
+		t.Error("expected an error for an unknown endpoint")
+	}
+}
+
+func TestRunBlocking(t *testing.T) {
+	a := newAgent(twoInstances)
+	srv := httptest.NewServer(a)
+	defer srv.Close()
+	c := consistent.New(consistent.Config{DefaultNumberOfReplicas: 20})
+	s := New(srv.URL, "web", c)
+	s.Wait = time.Minute
+	s.Name = func(in Instance) string { return in.ID }
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+
+	waitReplicas(t, c, map[string]int{"web1": 20, "web2": 60})
+	// a weight change is applied too
+	a.set(oneInstance)
+	waitReplicas(t, c, map[string]int{"web2": 40})
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("got %v, expected context.Canceled", err)
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	blocking := false
+	for _, q := range a.queries {
+		blocking = blocking || strings.Contains(q, "index=") && strings.Contains(q, "wait=60000ms")
+	}
+	if !blocking {
+		t.Errorf("no blocking query in %v", a.queries)
+	}
+}
+
+func waitReplicas(t *testing.T, c *consistent.Consistent, want map[string]int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		got := c.MemberReplicas()
+		same := len(got) == len(want)
+		for m, n := range want {
+			same = same && got[m] == n
+		}
+		if same {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("members %v, expected %v", got, want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
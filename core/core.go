@@ -0,0 +1,246 @@
+// Package core is the bare hash ring of package consistent: the same hashes, vnode keys
+// and lookups, without locking, events, timers or I/O. It only depends on hashing, sort
+// and strconv from the standard library, so it builds with TinyGo and for WebAssembly, e.g.
+// to make routing decisions at the edge or in a browser that match those of the servers.
+//
+// A Ring is not safe for concurrent use.
+package core
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"sort"
+	"strconv"
+)
+
+// HashCRC32 is the default hash of package consistent, CRC-32 IEEE.
+func HashCRC32(key string) uint32 {
+	if len(key) < 64 {
+		var scratch [64]byte
+		copy(scratch[:], key)
+		return crc32.ChecksumIEEE(scratch[:len(key)])
+	}
+	return crc32.ChecksumIEEE([]byte(key))
+}
+
+// HashCRC32Bytes is HashCRC32 of b.
+func HashCRC32Bytes(b []byte) uint32 {
+	return crc32.ChecksumIEEE(b)
+}
+
+// HashFnv is the 32-bit FNV-1a hash used with consistent.Config.UseFnv.
+func HashFnv(key string) uint32 {
+	return HashFnvBytes([]byte(key))
+}
+
+// HashFnvBytes is HashFnv of b, computed inline as hash/fnv allocates.
+func HashFnvBytes(b []byte) uint32 {
+	h := uint32(2166136261)
+	for _, c := range b {
+		h ^= uint32(c)
+		h *= 16777619
+	}
+	return h
+}
+
+// KeyDeriver returns the key hashed to place vnode idx of elt.
+type KeyDeriver func(elt string, idx int) string
+
+// DefaultKeyDeriver is the vnode key scheme of package consistent: idx in decimal followed
+// by elt.
+func DefaultKeyDeriver(elt string, idx int) string {
+	return strconv.Itoa(idx) + elt
+}
+
+// LengthPrefixedKeyDeriver returns the length of elt, ":", elt, ":" and idx, all numbers in
+// decimal. Unlike DefaultKeyDeriver, whose "21server" is both vnode 21 of "server" and vnode
+// 2 of "1server", it never derives the same key for two different vnodes.
+func LengthPrefixedKeyDeriver(elt string, idx int) string {
+	return strconv.Itoa(len(elt)) + ":" + elt + ":" + strconv.Itoa(idx)
+}
+
+// SeparatorKeyDeriver returns elt, sep and idx in decimal, such as "elt-3" or "elt#3", the
+// schemes of several other consistent hashing libraries.
+func SeparatorKeyDeriver(sep string) KeyDeriver {
+	return func(elt string, idx int) string {
+		return elt + sep + strconv.Itoa(idx)
+	}
+}
+
+// BinaryKeyDeriver returns elt followed by idx as a 4-byte integer in order.
+func BinaryKeyDeriver(order binary.ByteOrder) KeyDeriver {
+	return func(elt string, idx int) string {
+		var buf [4]byte
+		order.PutUint32(buf[:], uint32(idx))
+		return elt + string(buf[:])
+	}
+}
+
+// VnodeKey is the key hashed to place vnode idx of elt, derived by derive, DefaultKeyDeriver
+// if nil, and followed by salt if not empty.
+func VnodeKey(derive KeyDeriver, elt string, idx int, salt string) string {
+	if derive == nil {
+		derive = DefaultKeyDeriver
+	}
+	if salt != "" {
+		return derive(elt, idx) + "|" + salt
+	}
+	return derive(elt, idx)
+}
+
+type uints []uint32
+
+func (x uints) Len() int           { return len(x) }
+func (x uints) Less(i, j int) bool { return x[i] < x[j] }
+func (x uints) Swap(i, j int)      { x[i], x[j] = x[j], x[i] }
+
+// Ring is a consistent hash circle.
+type Ring struct {
+	hash      func(string) uint32
+	derive    KeyDeriver
+	tieBreak  TieBreak
+	circle    map[uint32]string
+	contested map[uint32][]string
+	members   map[string]member
+	joins     uint64
+	sorted    uints
+	replicas  int
+}
+
+type member struct {
+	replicas int
+	salt     string
+	joined   uint64
+}
+
+// New creates a Ring adding replicas vnodes per member by default and hashing with hash,
+// HashCRC32 if nil.
+func New(replicas int, hash func(string) uint32) *Ring {
+	if hash == nil {
+		hash = HashCRC32
+	}
+	return &Ring{
+		hash:      hash,
+		circle:    make(map[uint32]string),
+		contested: make(map[uint32][]string),
+		members:   make(map[string]member),
+		replicas:  replicas,
+	}
+}
+
+// SetKeyDeriver makes r place vnodes with derive, which must happen before adding members.
+func (r *Ring) SetKeyDeriver(derive KeyDeriver) {
+	r.derive = derive
+}
+
+// SetTieBreak makes r give the points claimed by several members to the winner under t,
+// like consistent.Config.TieBreak, which must happen before adding members.
+func (r *Ring) SetTieBreak(t TieBreak) {
+	r.tieBreak = t
+}
+
+// Add inserts elt with replicas vnodes, the default number if replicas is 0. It does
+// nothing if elt is already a member.
+func (r *Ring) Add(elt string, replicas int) {
+	r.AddWithSalt(elt, "", replicas)
+}
+
+// AddWithSalt inserts elt like consistent.Consistent.AddWithSalt.
+func (r *Ring) AddWithSalt(elt, salt string, replicas int) {
+	if m, ok := r.members[elt]; ok {
+		if m.salt == salt {
+			return
+		}
+		r.Remove(elt)
+	}
+	if replicas == 0 {
+		replicas = r.replicas
+	}
+	r.joins++
+	r.members[elt] = member{replicas, salt, r.joins}
+	for i := 0; i < replicas; i++ {
+		r.tieBreak.Claim(r.circle, r.contested, r.hash(VnodeKey(r.derive, elt, i, salt)), elt, r.claimant)
+	}
+	r.update()
+}
+
+// Remove removes elt, reporting whether it was a member.
+func (r *Ring) Remove(elt string) bool {
+	m, ok := r.members[elt]
+	if !ok {
+		return false
+	}
+	for i := 0; i < m.replicas; i++ {
+		r.tieBreak.Unclaim(r.circle, r.contested, r.hash(VnodeKey(r.derive, elt, i, m.salt)), elt, r.claimant)
+	}
+	delete(r.members, elt)
+	r.update()
+	return true
+}
+
+func (r *Ring) claimant(elt string) Claimant {
+	m := r.members[elt]
+	return Claimant{Name: elt, Replicas: m.replicas, Joined: m.joined}
+}
+
+// Members returns the members, sorted.
+func (r *Ring) Members() []string {
+	res := make([]string, 0, len(r.members))
+	for m := range r.members {
+		res = append(res, m)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Get returns the owner of name, reporting false if the ring is empty.
+func (r *Ring) Get(name string) (string, bool) {
+	if len(r.sorted) == 0 {
+		return "", false
+	}
+	return r.circle[r.sorted[r.search(r.hash(name))]], true
+}
+
+// GetN returns the n distinct members closest to name in ring order.
+func (r *Ring) GetN(name string, n int) []string {
+	if n > len(r.members) {
+		n = len(r.members)
+	}
+	if len(r.sorted) == 0 || n < 1 {
+		return nil
+	}
+	start := r.search(r.hash(name))
+	res := make([]string, 0, n)
+	for j := 0; j < len(r.sorted) && len(res) < n; j++ {
+		elt := r.circle[r.sorted[(start+j)%len(r.sorted)]]
+		if !contains(res, elt) {
+			res = append(res, elt)
+		}
+	}
+	return res
+}
+
+func (r *Ring) search(key uint32) int {
+	i := sort.Search(len(r.sorted), func(x int) bool { return r.sorted[x] > key })
+	if i >= len(r.sorted) {
+		i = 0
+	}
+	return i
+}
+
+func (r *Ring) update() {
+	r.sorted = r.sorted[:0]
+	for k := range r.circle {
+		r.sorted = append(r.sorted, k)
+	}
+	sort.Sort(r.sorted)
+}
+
+func contains(set []string, s string) bool {
+	for _, v := range set {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
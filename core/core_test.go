@@ -0,0 +1,123 @@
+package core_test
+
+import (
+	"encoding/binary"
+	"strconv"
+	"testing"
+
+	"github.com/jiangz222/consistent"
+	"github.com/jiangz222/consistent/core"
+)
+
+func TestMatchesConsistent(t *testing.T) {
+	for _, fnv := range []bool{false, true} {
+		c := consistent.New(consistent.Config{DefaultNumberOfReplicas: 20, UseFnv: fnv})
+		hash := core.HashCRC32
+		if fnv {
+			hash = core.HashFnv
+		}
+		r := core.New(20, hash)
+		for i := 0; i < 10; i++ {
+			m := "server" + strconv.Itoa(i)
+			c.Add(m, 10+i)
+			r.Add(m, 10+i)
+		}
+		c.AddWithSalt("server3", "gen2")
+		r.AddWithSalt("server3", "gen2", 0)
+		c.Remove("server5")
+		if !r.Remove("server5") || r.Remove("server5") {
+			t.Errorf("unexpected Remove results")
+		}
+		if len(r.Members()) != 9 || r.Members()[0] != "server0" {
+			t.Errorf("unexpected members %v", r.Members())
+		}
+		for i := 0; i < 1000; i++ {
+			k := "user" + strconv.Itoa(i)
+			want, _ := c.GetN(k, 3)
+			got := r.GetN(k, 3)
+			owner, ok := r.Get(k)
+			if !ok || owner != want[0] || len(got) != 3 || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+				t.Fatalf("%s: got %s and %v, expected %v", k, owner, got, want)
+			}
+		}
+	}
+}
+
+func TestEmpty(t *testing.T) {
+	r := core.New(20, nil)
+	if _, ok := r.Get("ggg"); ok {
+		t.Errorf("expected no owner in an empty ring")
+	}
+	if r.GetN("ggg", 2) != nil {
+		t.Errorf("expected no owners in an empty ring")
+	}
+}
+
+func TestKeyDerivers(t *testing.T) {
+	if k := core.DefaultKeyDeriver("elt", 12); k != "12elt" {
+		t.Errorf("got %q", k)
+	}
+	if k := core.LengthPrefixedKeyDeriver("elt", 12); k != "3:elt:12" {
+		t.Errorf("got %q", k)
+	}
+	if core.DefaultKeyDeriver("server", 21) != core.DefaultKeyDeriver("1server", 2) {
+		t.Errorf("expected the default scheme to collide")
+	}
+	if core.LengthPrefixedKeyDeriver("server", 21) == core.LengthPrefixedKeyDeriver("1server", 2) {
+		t.Errorf("expected length-prefixed keys not to collide")
+	}
+	if k := core.SeparatorKeyDeriver("#")("elt", 12); k != "elt#12" {
+		t.Errorf("got %q", k)
+	}
+	if k := core.BinaryKeyDeriver(binary.BigEndian)("elt", 258); k != "elt\x00\x00\x01\x02" {
+		t.Errorf("got %q", k)
+	}
+	if k := core.VnodeKey(nil, "elt", 1, "s"); k != "1elt|s" {
+		t.Errorf("got %q", k)
+	}
+
+	derive := core.SeparatorKeyDeriver("-")
+	c := consistent.New(consistent.Config{DefaultNumberOfReplicas: 20, KeyDeriver: derive})
+	r := core.New(20, nil)
+	r.SetKeyDeriver(derive)
+	for _, m := range []string{"a", "b", "c"} {
+		c.Add(m)
+		r.Add(m, 0)
+	}
+	for i := 0; i < 500; i++ {
+		k := "user" + strconv.Itoa(i)
+		want, _ := c.Get(k)
+		if got, _ := r.Get(k); got != want {
+			t.Fatalf("%s: got %s, expected %s", k, got, want)
+		}
+	}
+}
+
+type collidingHasher struct{}
+
+func (collidingHasher) HashFunc(key string) uint32 {
+	return core.HashCRC32(key) % 50 * 80000000
+}
+
+func TestTieBreakMatchesConsistent(t *testing.T) {
+	for _, tb := range []core.TieBreak{core.TieBreakLastAdd, core.TieBreakLexicographic, core.TieBreakWeight, core.TieBreakJoinTime} {
+		c := consistent.New(consistent.Config{DefaultNumberOfReplicas: 20, CustomHasher: collidingHasher{}, TieBreak: tb})
+		r := core.New(20, collidingHasher{}.HashFunc)
+		r.SetTieBreak(tb)
+		for _, m := range []string{"c", "a", "b"} {
+			c.Add(m, 10+len(m))
+			r.Add(m, 10+len(m))
+		}
+		c.Add("d", 30)
+		r.Add("d", 30)
+		c.Remove("a")
+		r.Remove("a")
+		for i := 0; i < 1000; i++ {
+			k := "user" + strconv.Itoa(i)
+			want, _ := c.Get(k)
+			if got, _ := r.Get(k); got != want {
+				t.Fatalf("%s: %s owned by %s, expected %s", tb, k, got, want)
+			}
+		}
+	}
+}
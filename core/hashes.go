@@ -0,0 +1,180 @@
+package core
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+const (
+	xxh32Prime1 uint32 = 2654435761
+	xxh32Prime2 uint32 = 2246822519
+	xxh32Prime3 uint32 = 3266489917
+	xxh32Prime4 uint32 = 668265263
+	xxh32Prime5 uint32 = 374761393
+
+	xxh64Prime1 uint64 = 11400714785074694791
+	xxh64Prime2 uint64 = 14029467366897019727
+	xxh64Prime3 uint64 = 1609587929392839161
+	xxh64Prime4 uint64 = 9650029242287828579
+	xxh64Prime5 uint64 = 2870177450012600261
+)
+
+// HashXXH32 is xxHash32 with seed 0.
+func HashXXH32(key string) uint32 {
+	return HashXXH32Bytes([]byte(key))
+}
+
+// HashXXH32Bytes is HashXXH32 of b.
+func HashXXH32Bytes(b []byte) uint32 {
+	n := len(b)
+	var h uint32
+	if n >= 16 {
+		v1, v2, v3, v4 := xxh32Prime1, xxh32Prime2, uint32(0), uint32(0)
+		v1 += xxh32Prime2
+		v4 -= xxh32Prime1
+		for len(b) >= 16 {
+			v1 = xxh32Round(v1, binary.LittleEndian.Uint32(b[0:]))
+			v2 = xxh32Round(v2, binary.LittleEndian.Uint32(b[4:]))
+			v3 = xxh32Round(v3, binary.LittleEndian.Uint32(b[8:]))
+			v4 = xxh32Round(v4, binary.LittleEndian.Uint32(b[12:]))
+			b = b[16:]
+		}
+		h = bits.RotateLeft32(v1, 1) + bits.RotateLeft32(v2, 7) + bits.RotateLeft32(v3, 12) + bits.RotateLeft32(v4, 18)
+	} else {
+		h = xxh32Prime5
+	}
+	h += uint32(n)
+	for len(b) >= 4 {
+		h += binary.LittleEndian.Uint32(b) * xxh32Prime3
+		h = bits.RotateLeft32(h, 17) * xxh32Prime4
+		b = b[4:]
+	}
+	for _, c := range b {
+		h += uint32(c) * xxh32Prime5
+		h = bits.RotateLeft32(h, 11) * xxh32Prime1
+	}
+	h ^= h >> 15
+	h *= xxh32Prime2
+	h ^= h >> 13
+	h *= xxh32Prime3
+	h ^= h >> 16
+	return h
+}
+
+func xxh32Round(acc, input uint32) uint32 {
+	acc += input * xxh32Prime2
+	return bits.RotateLeft32(acc, 13) * xxh32Prime1
+}
+
+// HashXXH64 is xxHash64 with seed 0.
+func HashXXH64(key string) uint64 {
+	return HashXXH64Bytes([]byte(key))
+}
+
+// HashXXH64Bytes is HashXXH64 of b.
+func HashXXH64Bytes(b []byte) uint64 {
+	n := len(b)
+	var h uint64
+	if n >= 32 {
+		v1, v2, v3, v4 := xxh64Prime1, xxh64Prime2, uint64(0), uint64(0)
+		v1 += xxh64Prime2
+		v4 -= xxh64Prime1
+		for len(b) >= 32 {
+			v1 = xxh64Round(v1, binary.LittleEndian.Uint64(b[0:]))
+			v2 = xxh64Round(v2, binary.LittleEndian.Uint64(b[8:]))
+			v3 = xxh64Round(v3, binary.LittleEndian.Uint64(b[16:]))
+			v4 = xxh64Round(v4, binary.LittleEndian.Uint64(b[24:]))
+			b = b[32:]
+		}
+		h = bits.RotateLeft64(v1, 1) + bits.RotateLeft64(v2, 7) + bits.RotateLeft64(v3, 12) + bits.RotateLeft64(v4, 18)
+		h = xxh64Merge(h, v1)
+		h = xxh64Merge(h, v2)
+		h = xxh64Merge(h, v3)
+		h = xxh64Merge(h, v4)
+	} else {
+		h = xxh64Prime5
+	}
+	h += uint64(n)
+	for len(b) >= 8 {
+		h ^= xxh64Round(0, binary.LittleEndian.Uint64(b))
+		h = bits.RotateLeft64(h, 27)*xxh64Prime1 + xxh64Prime4
+		b = b[8:]
+	}
+	if len(b) >= 4 {
+		h ^= uint64(binary.LittleEndian.Uint32(b)) * xxh64Prime1
+		h = bits.RotateLeft64(h, 23)*xxh64Prime2 + xxh64Prime3
+		b = b[4:]
+	}
+	for _, c := range b {
+		h ^= uint64(c) * xxh64Prime5
+		h = bits.RotateLeft64(h, 11) * xxh64Prime1
+	}
+	h ^= h >> 33
+	h *= xxh64Prime2
+	h ^= h >> 29
+	h *= xxh64Prime3
+	h ^= h >> 32
+	return h
+}
+
+func xxh64Round(acc, input uint64) uint64 {
+	acc += input * xxh64Prime2
+	return bits.RotateLeft64(acc, 31) * xxh64Prime1
+}
+
+func xxh64Merge(acc, v uint64) uint64 {
+	acc ^= xxh64Round(0, v)
+	return acc*xxh64Prime1 + xxh64Prime4
+}
+
+// HashXXH64Low is the low 32 bits of HashXXH64, for rings.
+func HashXXH64Low(key string) uint32 {
+	return uint32(HashXXH64(key))
+}
+
+// HashMurmur3 is MurmurHash3 x86 32-bit with seed 0.
+func HashMurmur3(key string) uint32 {
+	return HashMurmur3Bytes([]byte(key))
+}
+
+// HashMurmur3Bytes is HashMurmur3 of b.
+func HashMurmur3Bytes(b []byte) uint32 {
+	const (
+		c1 uint32 = 0xcc9e2d51
+		c2 uint32 = 0x1b873593
+	)
+	n := len(b)
+	var h uint32
+	for len(b) >= 4 {
+		k := binary.LittleEndian.Uint32(b)
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+		h = bits.RotateLeft32(h, 13)
+		h = h*5 + 0xe6546b64
+		b = b[4:]
+	}
+	var k uint32
+	switch len(b) {
+	case 3:
+		k ^= uint32(b[2]) << 16
+		fallthrough
+	case 2:
+		k ^= uint32(b[1]) << 8
+		fallthrough
+	case 1:
+		k ^= uint32(b[0])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+	}
+	h ^= uint32(n)
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+	return h
+}
@@ -0,0 +1,26 @@
+package core
+
+import "testing"
+
+func TestHashVectors(t *testing.T) {
+	long := "The quick brown fox jumps over the lazy dog"
+	for _, c := range []struct {
+		name string
+		got  uint64
+		want uint64
+	}{
+		{"xxh32 empty", uint64(HashXXH32("")), 0x02cc5d05},
+		{"xxh32 a", uint64(HashXXH32("a")), 0x550d7456},
+		{"xxh32 abc", uint64(HashXXH32("abc")), 0x32d153ff},
+		{"xxh64 empty", HashXXH64(""), 0xef46db3751d8e999},
+		{"xxh64 a", HashXXH64("a"), 0xd24ec4f1a98c6e5b},
+		{"xxh64 abc", HashXXH64("abc"), 0x44bc2cf5ad770999},
+		{"murmur3 empty", uint64(HashMurmur3("")), 0},
+		{"murmur3 hello", uint64(HashMurmur3("hello")), 0x248bfa47},
+		{"murmur3 fox", uint64(HashMurmur3(long)), 0x2e4ff723},
+	} {
+		if c.got != c.want {
+			t.Errorf("%s: got %#x, expected %#x", c.name, c.got, c.want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package core
+
+// TieBreak decides which member owns a point of the circle claimed by several members, as
+// happens on hash collisions. The other claimants keep their claim: when the owner leaves,
+// the point goes to the next winner rather than disappearing.
+type TieBreak int
+
+const (
+	// TieBreakLastAdd gives the point to the claimant that joined last, as a member added
+	// to the ring always took over the points it collided on.
+	TieBreakLastAdd TieBreak = iota
+	// TieBreakLexicographic gives the point to the claimant with the smallest name, so
+	// every process agrees on the owner whatever the order the members joined in.
+	TieBreakLexicographic
+	// TieBreakWeight gives the point to the claimant with the most replicas, then the
+	// smallest name.
+	TieBreakWeight
+	// TieBreakJoinTime gives the point to the claimant that joined first, then the
+	// smallest name. Processes agree as long as they apply the same changes in the same
+	// order.
+	TieBreakJoinTime
+)
+
+func (t TieBreak) String() string {
+	switch t {
+	case TieBreakLastAdd:
+		return "last-add"
+	case TieBreakLexicographic:
+		return "lexicographic"
+	case TieBreakWeight:
+		return "weight"
+	case TieBreakJoinTime:
+		return "join-time"
+	}
+	return "unknown"
+}
+
+// Claimant is what a TieBreak knows of a member claiming a point.
+type Claimant struct {
+	Name     string
+	Replicas int
+	// Joined orders the members by the time they joined, later ones being greater.
+	Joined uint64
+}
+
+// Beats reports whether a wins a point claimed by b too.
+func (t TieBreak) Beats(a, b Claimant) bool {
+	switch t {
+	case TieBreakLastAdd:
+		if a.Joined != b.Joined {
+			return a.Joined > b.Joined
+		}
+	case TieBreakWeight:
+		if a.Replicas != b.Replicas {
+			return a.Replicas > b.Replicas
+		}
+	case TieBreakJoinTime:
+		if a.Joined != b.Joined {
+			return a.Joined < b.Joined
+		}
+	}
+	return a.Name < b.Name
+}
+
+// Claim records that elt claims the point h of circle and gives the point to the winner of
+// its claimants, described by claimant. contested, which must not be nil, holds every
+// claimant of the points claimed by several members. Claim reports whether h is a new
+// point of circle.
+func (t TieBreak) Claim(circle map[uint32]string, contested map[uint32][]string, h uint32, elt string, claimant func(string) Claimant) bool {
+	owner, ok := circle[h]
+	if !ok {
+		circle[h] = elt
+		return true
+	}
+	if owner == elt {
+		return false
+	}
+	claimants := contested[h]
+	if claimants == nil {
+		claimants = []string{owner}
+	}
+	if !contains(claimants, elt) {
+		claimants = append(claimants[:len(claimants):len(claimants)], elt)
+	}
+	contested[h] = claimants
+	circle[h] = t.winner(claimants, claimant)
+	return false
+}
+
+// Unclaim withdraws the claim of elt on the point h of circle, giving it to the winner of
+// the remaining claimants. It reports whether h left circle.
+func (t TieBreak) Unclaim(circle map[uint32]string, contested map[uint32][]string, h uint32, elt string, claimant func(string) Claimant) bool {
+	claimants, ok := contested[h]
+	if !ok {
+		if owner, ok := circle[h]; ok && owner == elt {
+			delete(circle, h)
+			return true
+		}
+		return false
+	}
+	for i, m := range claimants {
+		if m == elt {
+			claimants = append(claimants[:i:i], claimants[i+1:]...)
+			break
+		}
+	}
+	if len(claimants) == 1 {
+		delete(contested, h)
+	} else {
+		contested[h] = claimants
+	}
+	circle[h] = t.winner(claimants, claimant)
+	return false
+}
+
+func (t TieBreak) winner(claimants []string, claimant func(string) Claimant) string {
+	best := claimant(claimants[0])
+	for _, m := range claimants[1:] {
+		if c := claimant(m); t.Beats(c, best) {
+			best = c
+		}
+	}
+	return best.Name
+}
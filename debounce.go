@@ -0,0 +1,43 @@
+package consistent
+
+import (
+	"sync"
+	"time"
+)
+
+type debouncer struct {
+	mu      sync.Mutex
+	timer   *time.Timer
+	elts    []string
+	pending bool
+	gen     uint64 // bumped by every call, so only the timer of the last one applies elts
+}
+
+// SetDebounced is like Set but waits for window without further SetDebounced calls before
+// applying the membership of the last call, so the bursts of updates a discovery feed sends
+// during a rolling deploy cause a single remap. Each call restarts the wait. The membership
+// is applied on another goroutine; a Set made meanwhile is overwritten when it is.
+func (c *Consistent) SetDebounced(elts []string, window time.Duration) {
+	d := &c.debounce
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.elts = append([]string(nil), elts...)
+	d.pending = true
+	d.gen++
+	gen := d.gen
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+	d.timer = time.AfterFunc(window, func() {
+		d.mu.Lock()
+		if gen != d.gen || !d.pending {
+			// a later call restarted the wait
+			d.mu.Unlock()
+			return
+		}
+		elts := d.elts
+		d.elts, d.pending, d.timer = nil, false, nil
+		d.mu.Unlock()
+		c.Set(elts)
+	})
+}
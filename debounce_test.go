@@ -0,0 +1,51 @@
+package consistent
+
+import (
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestSetDebounced(t *testing.T) {
+	x := New(newConfig())
+	events := make(chan ChangeEvent, 4)
+	x.OnChange(func(ev ChangeEvent) { events <- ev })
+	x.SetDebounced([]string{"abcdefg"}, 30*time.Millisecond)
+	x.SetDebounced([]string{"abcdefg", "hijklmn"}, 30*time.Millisecond)
+	x.SetDebounced([]string{"hijklmn", "opqrstu"}, 30*time.Millisecond)
+	if len(x.Members()) != 0 {
+		t.Fatalf("expected nothing to be applied before the window")
+	}
+	select {
+	case ev := <-events:
+		sort.Strings(ev.Added)
+		if len(ev.Added) != 2 || ev.Added[0] != "hijklmn" || ev.Added[1] != "opqrstu" {
+			t.Errorf("unexpected event %+v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("membership was not applied")
+	}
+	select {
+	case ev := <-events:
+		t.Errorf("unexpected second event %+v", ev)
+	case <-time.After(60 * time.Millisecond):
+	}
+}
+
+func TestSetDebouncedEmpty(t *testing.T) {
+	x := New(newConfig())
+	x.Set([]string{"abcdefg"})
+	x.SetDebounced([]string{"abcdefg", "hijklmn"}, 30*time.Millisecond)
+	x.SetDebounced(nil, 30*time.Millisecond)
+	deadline := time.Now().Add(time.Second)
+	for len(x.Members()) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("got members %q, expected the empty membership applied", x.Members())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if len(x.Members()) != 0 {
+		t.Errorf("got members %q, expected no earlier membership applied", x.Members())
+	}
+}
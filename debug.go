@@ -0,0 +1,170 @@
+package consistent
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"sort"
+	"time"
+)
+
+// debugConfig is what a debug bundle records of the settings of a ring. Hashers, key
+// derivers, policies and callbacks are only recorded as being used.
+type debugConfig struct {
+	Replicas         int     `json:"replicas"`
+	Hasher           string  `json:"hasher"`
+	CustomKeyDeriver bool    `json:"customKeyDeriver,omitempty"`
+	Ketama           bool    `json:"ketama,omitempty"`
+	Weighted         bool    `json:"weightedRendezvous,omitempty"`
+	TieBreak         string  `json:"tieBreak"`
+	LoadFactor       float64 `json:"loadFactor,omitempty"`
+	LoadHalfLife     string  `json:"loadHalfLife,omitempty"`
+	MaxMembers       int     `json:"maxMembers,omitempty"`
+	KeepDeltas       int     `json:"keepDeltas,omitempty"`
+	KeepVersions     int     `json:"keepVersions,omitempty"`
+	DegradedShare    float64 `json:"degradedShare,omitempty"`
+	Guard            bool    `json:"guard,omitempty"`
+}
+
+// debugState is the runtime routing state of a ring beyond its membership.
+type debugState struct {
+	Down      []string          `json:"down"`
+	Draining  []string          `json:"draining"`
+	Groups    map[string]string `json:"groups,omitempty"`    // key: group ID
+	GroupPins map[string]string `json:"groupPins,omitempty"` // group ID: member
+	Tracked   int               `json:"trackedKeys,omitempty"`
+}
+
+// debugChange is one retained change of membership.
+type debugChange struct {
+	Version uint64           `json:"version"`
+	Removed []string         `json:"removed,omitempty"`
+	Added   []SnapshotMember `json:"added,omitempty"`
+}
+
+// debugHistory is the recent changes of a ring.
+type debugHistory struct {
+	Version    uint64           `json:"version"`
+	LastChange time.Time        `json:"lastChange"`
+	Changes    []debugChange    `json:"changes"`
+	Compacted  []VersionSummary `json:"compacted,omitempty"`
+}
+
+// DebugBundle writes to w a zip archive capturing the ring at one instant, to attach to
+// bug reports so placement anomalies can be reproduced exactly:
+//
+//	ring.json       the membership and hashing settings, as written by MarshalJSON
+//	config.json     the other settings of the ring
+//	state.json      the members marked down or draining, groups and group pins
+//	stats.json      the Stats of the ring
+//	history.json    the current version and the changes retained by Config.KeepDeltas
+//	                and Config.CompactVersions
+//	overrides.json  the overrides installed with SetOverrides, as written by
+//	                Overrides.Save, if any
+//
+// Feeding ring.json to UnmarshalJSON of a ring created with the same Config rebuilds the
+// same circle.
+func (c *Consistent) DebugBundle(w io.Writer) error {
+	c.RLock()
+	files := []struct {
+		name string
+		v    interface{}
+	}{
+		{"ring.json", c.encoding()},
+		{"config.json", c.debugConfig()},
+		{"state.json", c.debugState()},
+		{"stats.json", c.statsSnapshot()},
+		{"history.json", c.debugHistory()},
+	}
+	overrides := c.overrides
+	c.RUnlock()
+
+	zw := zip.NewWriter(w)
+	for _, f := range files {
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(fw)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f.v); err != nil {
+			return err
+		}
+	}
+	if overrides != nil {
+		fw, err := zw.Create("overrides.json")
+		if err != nil {
+			return err
+		}
+		if err := overrides.Save(fw); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// need c.RLock() before calling
+func (c *Consistent) debugConfig() debugConfig {
+	conf := debugConfig{
+		Replicas:         c.defaultNumberOfReplicas,
+		Hasher:           hasherNames[c.hasherKind()],
+		CustomKeyDeriver: c.keyDeriver != nil,
+		Ketama:           c.ketama,
+		Weighted:         c.weightedMode,
+		TieBreak:         c.tieBreak.String(),
+		LoadFactor:       c.load.factor,
+		KeepDeltas:       c.keepDeltas,
+		KeepVersions:     c.history.keep,
+		DegradedShare:    c.degradedShare,
+		Guard:            c.guard != nil,
+	}
+	if c.load.halfLife > 0 {
+		conf.LoadHalfLife = c.load.halfLife.String()
+	}
+	if c.capacity != nil {
+		conf.MaxMembers = c.capacity.max
+	}
+	return conf
+}
+
+// need c.RLock() before calling
+func (c *Consistent) debugState() debugState {
+	s := debugState{Down: sortedSet(c.down), Draining: sortedSet(c.draining), Tracked: len(c.tracked)}
+	if len(c.groups) > 0 {
+		s.Groups = make(map[string]string, len(c.groups))
+		for k, g := range c.groups {
+			s.Groups[k] = g
+		}
+	}
+	if len(c.groupPins) > 0 {
+		s.GroupPins = make(map[string]string, len(c.groupPins))
+		for g, m := range c.groupPins {
+			s.GroupPins[g] = m
+		}
+	}
+	return s
+}
+
+// need c.RLock() before calling
+func (c *Consistent) debugHistory() debugHistory {
+	h := debugHistory{
+		Version:    c.stats.version,
+		LastChange: c.stats.lastChange,
+		Changes:    make([]debugChange, 0, len(c.deltas)),
+		Compacted:  c.history.summaries,
+	}
+	for _, vd := range c.deltas {
+		h.Changes = append(h.Changes, debugChange{Version: vd.version, Removed: vd.delta.Removed, Added: vd.delta.Added})
+	}
+	return h
+}
+
+// sortedSet returns the members of set, sorted.
+func sortedSet(set map[string]bool) []string {
+	res := make([]string, 0, len(set))
+	for m := range set {
+		res = append(res, m)
+	}
+	sort.Strings(res)
+	return res
+}
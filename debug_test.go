@@ -0,0 +1,81 @@
+package consistent
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+)
+
+func TestDebugBundle(t *testing.T) {
+	conf := Config{DefaultNumberOfReplicas: 20, Hash: HashXXH64, KeepDeltas: 10}
+	x := New(conf)
+	x.Set([]string{"a", "b", "c"})
+	x.AddWithSalt("d", "v2")
+	x.Remove("b")
+	x.MarkDown("c")
+	o := NewOverrides()
+	o.Pin("vip", "a", 0, "test")
+	x.SetOverrides(o)
+
+	var buf bytes.Buffer
+	if err := x.DebugBundle(&buf); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string][]byte)
+	for _, f := range zr.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name], _ = ioutil.ReadAll(r)
+		r.Close()
+	}
+	for _, name := range []string{"ring.json", "config.json", "state.json", "stats.json", "history.json", "overrides.json"} {
+		if len(files[name]) == 0 {
+			t.Errorf("missing %s", name)
+		}
+	}
+
+	// the ring can be rebuilt from the bundle
+	y := New(conf)
+	if err := y.UnmarshalJSON(files["ring.json"]); err != nil {
+		t.Fatal(err)
+	}
+	if len(y.sortedHashes) != len(x.sortedHashes) {
+		t.Fatalf("rebuilt ring has %d vnodes, expected %d", len(y.sortedHashes), len(x.sortedHashes))
+	}
+	for i, h := range x.sortedHashes {
+		if y.sortedHashes[i] != h || y.circle[h] != x.circle[h] {
+			t.Fatalf("vnode %d differs in the rebuilt ring", i)
+		}
+	}
+
+	var h debugHistory
+	if err := json.Unmarshal(files["history.json"], &h); err != nil {
+		t.Fatal(err)
+	}
+	// marking c down is a change of the routing too
+	if h.Version != x.Version() || len(h.Changes) != 4 {
+		t.Errorf("unexpected history %+v", h)
+	}
+	var s debugState
+	if err := json.Unmarshal(files["state.json"], &s); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Down) != 1 || s.Down[0] != "c" {
+		t.Errorf("unexpected state %+v", s)
+	}
+	var c debugConfig
+	if err := json.Unmarshal(files["config.json"], &c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Hasher != "xxh64" || c.Replicas != 20 || c.KeepDeltas != 10 {
+		t.Errorf("unexpected config %+v", c)
+	}
+}
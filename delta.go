@@ -0,0 +1,113 @@
+package consistent
+
+// Encoding of a Delta written by MarshalBinary, all integers little endian:
+//
+//	version  byte    deltaEncodingVersion
+//	from, to uint64  versions of the ring the delta goes between
+//	removed  uint32  count, then for each: name uint32 length, bytes
+//	added    uint32  count, then for each: name uint32 length, bytes, replicas uint32,
+//	                 salt uint32 length, bytes
+const deltaEncodingVersion = 1
+
+// versionDelta is the change of membership that made a version of the ring.
+type versionDelta struct {
+	version uint64
+	delta   Delta
+}
+
+// need c.Lock() before calling
+// recordDelta remembers the change of membership ev made, see Config.KeepDeltas.
+func (c *Consistent) recordDelta(ev ChangeEvent) {
+	if c.keepDeltas <= 0 {
+		return
+	}
+	d := Delta{Removed: ev.Removed}
+	for _, m := range ev.Added {
+		if c.members[m] {
+			d.Added = append(d.Added, SnapshotMember{Name: m, Replicas: c.membersReplicas[m], Salt: c.salts[m]})
+		}
+	}
+	c.deltas = append(c.deltas, versionDelta{ev.Version, d})
+	if n := len(c.deltas) - c.keepDeltas; n > 0 {
+		c.deltas = append(c.deltas[:0], c.deltas[n:]...)
+	}
+}
+
+// EncodeDelta returns the change of membership from version fromVersion of the ring to
+// its current version, encoded by Delta.MarshalBinary, so replicas of the ring can be
+// kept in sync by shipping deltas rather than snapshots; they apply it with ApplyDelta.
+// Only the changes of the last Config.KeepDeltas versions are retained; older versions
+// give ErrUnknownVersion.
+func (c *Consistent) EncodeDelta(fromVersion uint64) ([]byte, error) {
+	c.RLock()
+	defer c.RUnlock()
+	d := Delta{From: fromVersion, To: c.stats.version}
+	if fromVersion == c.stats.version {
+		return d.MarshalBinary()
+	}
+	if fromVersion > c.stats.version || len(c.deltas) == 0 || c.deltas[0].version > fromVersion+1 {
+		return nil, ErrUnknownVersion
+	}
+	// keep the last change of every member touched since fromVersion
+	last := make(map[string]*SnapshotMember)
+	var order []string
+	for _, vd := range c.deltas {
+		if vd.version <= fromVersion {
+			continue
+		}
+		for _, m := range vd.delta.Removed {
+			if _, ok := last[m]; !ok {
+				order = append(order, m)
+			}
+			last[m] = nil
+		}
+		for i, m := range vd.delta.Added {
+			if _, ok := last[m.Name]; !ok {
+				order = append(order, m.Name)
+			}
+			last[m.Name] = &vd.delta.Added[i]
+		}
+	}
+	for _, name := range order {
+		if m := last[name]; m != nil {
+			d.Added = append(d.Added, *m)
+		} else {
+			d.Removed = append(d.Removed, name)
+		}
+	}
+	return d.MarshalBinary()
+}
+
+// MarshalBinary encodes d compactly, the members added as in Consistent.MarshalBinary.
+func (d Delta) MarshalBinary() ([]byte, error) {
+	w := binaryWriter{buf: []byte{deltaEncodingVersion}}
+	w.uint64(d.From)
+	w.uint64(d.To)
+	w.int(len(d.Removed))
+	for _, name := range d.Removed {
+		w.string(name)
+	}
+	w.members(d.Added)
+	return w.buf, nil
+}
+
+// UnmarshalBinary decodes a delta encoded by MarshalBinary.
+func (d *Delta) UnmarshalBinary(data []byte) error {
+	if len(data) < 1 {
+		return ErrBadEncoding
+	}
+	if data[0] != deltaEncodingVersion {
+		return ErrEncodingVersion
+	}
+	r := binaryReader{data: data[1:]}
+	res := Delta{From: r.uint64(), To: r.uint64()}
+	for n := r.count(4); !r.bad && n > 0; n-- {
+		res.Removed = append(res.Removed, r.string())
+	}
+	res.Added = r.members()
+	if err := r.done(); err != nil {
+		return err
+	}
+	*d = res
+	return nil
+}
@@ -0,0 +1,65 @@
+package consistent
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEncodeDelta(t *testing.T) {
+	x := New(Config{DefaultNumberOfReplicas: 20, KeepDeltas: 10})
+	x.Set([]string{"a", "b", "c"})
+	y := New(newConfig())
+	y.Restore(x.Snapshot())
+	from := x.Version()
+
+	x.Add("d", 30)
+	x.Remove("a")
+	x.AddWithSalt("b", "v2")
+	x.Remove("d")
+	x.Add("e")
+	data, err := x.EncodeDelta(from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var d Delta
+	if err := d.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	want := Delta{
+		From:    from,
+		To:      x.Version(),
+		Removed: []string{"d", "a"},
+		Added:   []SnapshotMember{{"b", 20, "v2"}, {"e", 20, ""}},
+	}
+	if !reflect.DeepEqual(d, want) {
+		t.Errorf("got %+v, expected %+v", d, want)
+	}
+	y.ApplyDelta(d)
+	if x.Fingerprint() != y.Fingerprint() {
+		t.Errorf("got %+v, expected %+v", y.Snapshot(), x.Snapshot())
+	}
+
+	data, _ = x.EncodeDelta(x.Version())
+	if err := d.UnmarshalBinary(data); err != nil || len(d.Added)+len(d.Removed) != 0 {
+		t.Errorf("expected an empty delta, got %+v, %v", d, err)
+	}
+	if _, err := x.EncodeDelta(x.Version() + 1); err != ErrUnknownVersion {
+		t.Errorf("got %v, expected ErrUnknownVersion for a future version", err)
+	}
+	if err := d.UnmarshalBinary(data[:len(data)-1]); err != ErrBadEncoding {
+		t.Errorf("got %v, expected ErrBadEncoding", err)
+	}
+}
+
+func TestEncodeDeltaRetention(t *testing.T) {
+	x := New(Config{DefaultNumberOfReplicas: 20, KeepDeltas: 2})
+	x.Add("a")
+	x.Add("b")
+	x.Add("c")
+	if _, err := x.EncodeDelta(0); err != ErrUnknownVersion {
+		t.Errorf("got %v, expected ErrUnknownVersion beyond KeepDeltas", err)
+	}
+	if _, err := x.EncodeDelta(1); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
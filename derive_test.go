@@ -0,0 +1,32 @@
+package consistent
+
+import (
+	"testing"
+
+	"github.com/jiangz222/consistent/core"
+)
+
+func TestKeyDeriver(t *testing.T) {
+	x := New(Config{DefaultNumberOfReplicas: 3, KeyDeriver: core.SeparatorKeyDeriver("-")})
+	x.Add("server")
+	x.AddWithSalt("other", "gen2")
+	for _, k := range []string{"server-0", "server-1", "server-2", "other-0|gen2"} {
+		if _, ok := x.circle[hashKeyCRC32(k)]; !ok {
+			t.Errorf("expected a vnode at the hash of %q", k)
+		}
+	}
+	if err := x.VerifyRoundTrip(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLengthPrefixedKeys(t *testing.T) {
+	for _, derive := range []func(string, int) string{nil, core.LengthPrefixedKeyDeriver} {
+		x := New(Config{DefaultNumberOfReplicas: 30, KeyDeriver: derive})
+		x.Add("server")
+		x.Add("1server")
+		if collided := len(x.circle) < 60; collided != (derive == nil) {
+			t.Errorf("got %d points, collision expected: %v", len(x.circle), derive == nil)
+		}
+	}
+}
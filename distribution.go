@@ -0,0 +1,66 @@
+package consistent
+
+import (
+	"math"
+	"sort"
+)
+
+// LoadDistribution returns the share of the hash space each member owns, 0 for members
+// without vnodes, so operators can check the balance the replica counts give. Only the
+// circle is considered: groups, overrides and weighted mode are ignored.
+func (c *Consistent) LoadDistribution() map[string]float64 {
+	c.RLock()
+	defer c.RUnlock()
+	res := make(map[string]float64, len(c.members))
+	for m := range c.members {
+		res[m] = 0
+	}
+	if len(c.sortedHashes) == 0 {
+		return res
+	}
+	for m, share := range ownershipShares(c.sortedHashes, c.circle) {
+		res[m] = share
+	}
+	return res
+}
+
+// Balance summarizes a distribution such as LoadDistribution returns.
+type Balance struct {
+	Min, Max             float64
+	MinMember, MaxMember string // the first by name on ties
+	Mean, StdDev         float64
+}
+
+// Summarize returns the minimum, maximum, mean and standard deviation of the shares of d,
+// the zero Balance if d is empty.
+func Summarize(d map[string]float64) Balance {
+	var b Balance
+	if len(d) == 0 {
+		return b
+	}
+	names := make([]string, 0, len(d))
+	for m := range d {
+		names = append(names, m)
+	}
+	sort.Strings(names)
+	b.Min, b.MinMember = d[names[0]], names[0]
+	b.Max, b.MaxMember = b.Min, b.MinMember
+	sum := 0.0
+	for _, m := range names {
+		v := d[m]
+		sum += v
+		if v < b.Min {
+			b.Min, b.MinMember = v, m
+		}
+		if v > b.Max {
+			b.Max, b.MaxMember = v, m
+		}
+	}
+	b.Mean = sum / float64(len(d))
+	sq := 0.0
+	for _, v := range d {
+		sq += (v - b.Mean) * (v - b.Mean)
+	}
+	b.StdDev = math.Sqrt(sq / float64(len(d)))
+	return b
+}
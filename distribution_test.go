@@ -0,0 +1,32 @@
+package consistent
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLoadDistribution(t *testing.T) {
+	x := New(newConfig())
+	x.Add("abcdefg")
+	x.Add("hijklmn", 60)
+	x.Add("opqrstu")
+	d := x.LoadDistribution()
+	checkNum(len(d), 3, t)
+	sum := 0.0
+	for _, share := range d {
+		sum += share
+	}
+	if math.Abs(sum-1) > 1e-9 {
+		t.Errorf("shares sum to %v", sum)
+	}
+	b := Summarize(d)
+	if b.MaxMember != "hijklmn" || b.Max != d["hijklmn"] {
+		t.Errorf("got max %s %v, expected hijklmn", b.MaxMember, b.Max)
+	}
+	if math.Abs(b.Mean-1.0/3) > 1e-9 || b.StdDev <= 0 || b.Min > b.Mean {
+		t.Errorf("got %+v", b)
+	}
+	if b := Summarize(map[string]float64{"a": 0.5, "b": 0.5}); b.StdDev != 0 || b.MinMember != "a" || b.MaxMember != "a" {
+		t.Errorf("got %+v", b)
+	}
+}
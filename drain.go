@@ -0,0 +1,61 @@
+package consistent
+
+// Drain marks elt as draining: it keeps owning its keys, but GetNDraining also returns the
+// members that will own them once elt is removed, so data can be written to both until
+// then. It reports false if elt is not a member. Remove ends the drain.
+func (c *Consistent) Drain(elt string) bool {
+	c.Lock()
+	defer c.Unlock()
+	if !c.members[elt] {
+		return false
+	}
+	if c.draining == nil {
+		c.draining = make(map[string]bool)
+	}
+	c.draining[elt] = true
+	return true
+}
+
+// Undrain cancels the drain of elt.
+func (c *Consistent) Undrain(elt string) {
+	c.Lock()
+	defer c.Unlock()
+	delete(c.draining, elt)
+}
+
+// Draining returns the draining members, sorted.
+func (c *Consistent) Draining() []string {
+	c.RLock()
+	defer c.RUnlock()
+	return sortedSet(c.draining)
+}
+
+// GetNDraining returns the n owners name will have once the draining members are removed,
+// followed by the draining members among its n current owners. Without draining members it
+// returns the same as GetN.
+func (c *Consistent) GetNDraining(name string, n int) ([]string, error) {
+	c.RLock()
+	defer c.RUnlock()
+	if len(c.circle) == 0 {
+		return nil, ErrEmptyCircle
+	}
+	if n < 1 {
+		n = len(c.members)
+	}
+	all, _, err := c.lookup(name, n+len(c.draining), 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]string, 0, n+1)
+	for _, m := range all {
+		if len(res) < n && !c.draining[m] {
+			res = append(res, m)
+		}
+	}
+	for i, m := range all {
+		if i < n && c.draining[m] {
+			res = append(res, m)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,71 @@
+package consistent
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestGetNDraining(t *testing.T) {
+	x := New(newConfig())
+	x.Set([]string{"a", "b", "c"})
+	if x.Drain("missing") {
+		t.Errorf("expected draining a non-member to fail")
+	}
+	if !x.Drain("c") {
+		t.Fatalf("expected c to drain")
+	}
+	if d := x.Draining(); len(d) != 1 || d[0] != "c" {
+		t.Errorf("got draining %v", d)
+	}
+	y := New(newConfig())
+	y.Set([]string{"a", "b"})
+	for i := 0; i < 200; i++ {
+		k := "user" + strconv.Itoa(i)
+		current, _ := x.Get(k)
+		future, _ := y.Get(k)
+		res, err := x.GetNDraining(k, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if current == "c" {
+			if len(res) != 2 || res[0] != future || res[1] != "c" {
+				t.Errorf("%s: got %v, expected [%s c]", k, res, future)
+			}
+		} else if len(res) != 1 || res[0] != current {
+			t.Errorf("%s: got %v, expected [%s]", k, res, current)
+		}
+	}
+	x.Remove("c")
+	if len(x.Draining()) != 0 {
+		t.Errorf("expected Remove to end the drain")
+	}
+	res, _ := x.GetNDraining("ggg", 2)
+	expected, _ := x.GetN("ggg", 2)
+	if len(res) != 2 || res[0] != expected[0] || res[1] != expected[1] {
+		t.Errorf("got %v, expected %v", res, expected)
+	}
+}
+
+func TestGetNDrainingAll(t *testing.T) {
+	x := New(newConfig())
+	x.Set([]string{"a", "b", "c"})
+	for _, n := range []int{0, -1, -3} {
+		res, err := x.GetNDraining("ggg", n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, _ := x.GetN("ggg", n)
+		if !reflect.DeepEqual(res, expected) {
+			t.Errorf("n=%d: got %v, expected %v", n, res, expected)
+		}
+	}
+	x.Drain("b")
+	res, err := x.GetNDraining("ggg", -3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 3 || res[2] != "b" {
+		t.Errorf("got %v, expected every member with b last", res)
+	}
+}
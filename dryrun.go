@@ -0,0 +1,137 @@
+package consistent
+
+import "sort"
+
+// Effect describes what a change to a ring does or would do.
+type Effect struct {
+	Added   []string
+	Removed []string
+	// MovedShare is the share of the hash space, between 0 and 1, that changes owner, and
+	// Moved the ranges that do, sorted by End.
+	MovedShare float64
+	Moved      []MovedRange
+	// Members and Vnodes are the number of members and points of the resulting circle.
+	Members int
+	Vnodes  int
+	// Shares is the share of the hash space each member of the resulting circle owns.
+	Shares map[string]float64
+}
+
+// DryRun computes the effects of changes to a ring without applying them, e.g. to check a
+// topology change in CI before it is merged. Every call is relative to the current state
+// of the ring.
+type DryRun struct {
+	c *Consistent
+}
+
+// DryRun returns a DryRun of c.
+func (c *Consistent) DryRun() DryRun {
+	return DryRun{c}
+}
+
+// Add returns the effect of c.Add(elt, numbersOfReplicas...).
+func (d DryRun) Add(elt string, numbersOfReplicas ...int) Effect {
+	d.c.RLock()
+	defer d.c.RUnlock()
+	if d.c.members[elt] {
+		return d.effect(nil, nil)
+	}
+	v := SetElt{Elt: elt}
+	if len(numbersOfReplicas) > 0 {
+		v.NumberOfReplicas = numbersOfReplicas[0]
+	}
+	return d.effect(nil, []joiner{{SetElt: v}})
+}
+
+// Remove returns the effect of c.Remove(elt), ignoring Config.Guard.
+func (d DryRun) Remove(elt string) Effect {
+	d.c.RLock()
+	defer d.c.RUnlock()
+	if !d.c.members[elt] {
+		return d.effect(nil, nil)
+	}
+	return d.effect([]string{elt}, nil)
+}
+
+// Set returns the effect of c.Set(elts), ignoring Config.Guard.
+func (d DryRun) Set(elts []string) Effect {
+	return d.SetWithReplicas(setElts(elts))
+}
+
+// SetWithReplicas returns the effect of c.SetWithReplicas(elts), ignoring Config.Guard.
+func (d DryRun) SetWithReplicas(elts []SetElt) Effect {
+	d.c.RLock()
+	defer d.c.RUnlock()
+	removed, added := d.c.setDiff(elts)
+	return d.effect(removed, joiners(added))
+}
+
+// need c.RLock() before calling
+func (d DryRun) effect(removed []string, added []joiner) Effect {
+	circle, hashes := d.c.simulate(removed, added)
+	e := Effect{
+		Removed:    removed,
+		MovedShare: movedShare(d.c.sortedHashes, d.c.circle, hashes, circle),
+		Moved:      movedRanges(d.c.sortedHashes, owners(d.c.sortedHashes, d.c.circle), hashes, owners(hashes, circle)),
+		Members:    len(d.c.members) - len(removed) + len(added),
+		Vnodes:     len(hashes),
+		Shares:     ownershipShares(hashes, circle),
+	}
+	for _, v := range added {
+		e.Added = append(e.Added, v.Elt)
+	}
+	return e
+}
+
+// KeyMove is a key that changes owner.
+type KeyMove struct {
+	Key      string
+	From, To string
+}
+
+// MovedKeys returns the keys produced by keys that change owner with the change whose
+// effect e is, in the order they are produced, e.g. to size a migration before changing
+// the ring. Only the circle is considered: groups, overrides and weighted mode are ignored.
+func (d DryRun) MovedKeys(e Effect, keys KeyIterator) []KeyMove {
+	var res []KeyMove
+	keys(func(key string) bool {
+		if r, ok := movedRangeOf(e.Moved, d.c.hashKey(key)); ok {
+			res = append(res, KeyMove{Key: key, From: r.From, To: r.To})
+		}
+		return true
+	})
+	return res
+}
+
+// movedRangeOf returns the range of ranges, sorted by End, holding h.
+func movedRangeOf(ranges []MovedRange, h uint32) (MovedRange, bool) {
+	if len(ranges) == 0 {
+		return MovedRange{}, false
+	}
+	i := sort.Search(len(ranges), func(x int) bool { return ranges[x].End > h })
+	for _, j := range []int{i, len(ranges) - 1, 0} {
+		// the range wrapping around zero comes first or last
+		if j < len(ranges) && uint64(h-ranges[j].Start) < ranges[j].size() {
+			return ranges[j], true
+		}
+	}
+	return MovedRange{}, false
+}
+
+// ownershipShares returns the share of the hash space each member of a circle owns.
+func ownershipShares(hashes uints, circle map[uint32]string) map[string]float64 {
+	res := make(map[string]float64)
+	if len(hashes) == 0 {
+		return res
+	}
+	if len(hashes) == 1 {
+		res[circle[hashes[0]]] = 1
+		return res
+	}
+	prev := hashes[len(hashes)-1]
+	for _, h := range hashes {
+		res[circle[h]] += float64(h-prev) / (1 << 32)
+		prev = h
+	}
+	return res
+}
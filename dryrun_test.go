@@ -0,0 +1,99 @@
+package consistent
+
+import (
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestDryRun(t *testing.T) {
+	x := New(newConfig())
+	x.Set([]string{"a", "b", "c", "d"})
+
+	e := x.DryRun().Set([]string{"a", "b", "c", "e"})
+	if len(e.Added) != 1 || e.Added[0] != "e" || len(e.Removed) != 1 || e.Removed[0] != "d" {
+		t.Errorf("unexpected diff %+v", e)
+	}
+	checkNum(e.Members, 4, t)
+	checkNum(e.Vnodes, 80, t)
+	if _, ok := e.Shares["d"]; ok {
+		t.Errorf("expected d to own nothing")
+	}
+	var total float64
+	for _, s := range e.Shares {
+		total += s
+	}
+	if math.Abs(total-1) > 1e-9 {
+		t.Errorf("shares add up to %v", total)
+	}
+	if e.MovedShare < 0.2 || e.MovedShare > 0.8 {
+		t.Errorf("unexpected moved share %v", e.MovedShare)
+	}
+	checkNum(len(x.Members()), 4, t)
+	if _, ok := x.members["e"]; ok {
+		t.Errorf("expected the ring to be left untouched")
+	}
+
+	if e := x.DryRun().Add("a"); e.MovedShare != 0 || len(e.Added) != 0 {
+		t.Errorf("expected adding a member twice to do nothing, got %+v", e)
+	}
+	if e := x.DryRun().Add("e", 40); e.Vnodes != 120 || e.Shares["e"] == 0 {
+		t.Errorf("unexpected Add effect %+v", e)
+	}
+	if e := x.DryRun().Remove("a"); e.Members != 3 || e.MovedShare != x.DryRun().Remove("a").MovedShare {
+		t.Errorf("unexpected Remove effect %+v", e)
+	}
+}
+
+func TestDryRunRemoveLast(t *testing.T) {
+	x := New(newConfig())
+	x.Add("a")
+	for _, e := range []Effect{x.DryRun().Remove("a"), x.DryRun().Set(nil)} {
+		checkNum(e.Members, 0, t)
+		checkNum(e.Vnodes, 0, t)
+		checkNum(len(e.Shares), 0, t)
+		if e.MovedShare != 1 {
+			t.Errorf("expected everything to move, got %v", e.MovedShare)
+		}
+	}
+	checkNum(x.MemberCount(), 1, t)
+}
+
+func TestDryRunMovedKeys(t *testing.T) {
+	x := New(newConfig())
+	x.Set([]string{"a", "b", "c", "d"})
+	keys := make([]string, 1000)
+	for i := range keys {
+		keys[i] = "key" + strconv.Itoa(i)
+	}
+	before := make(map[string]string)
+	for _, k := range keys {
+		before[k] = mustGet(t, x, k)
+	}
+	e := x.DryRun().Set([]string{"a", "b", "c", "e"})
+	if len(e.Moved) == 0 {
+		t.Fatalf("expected moved ranges")
+	}
+	moves := x.DryRun().MovedKeys(e, func(yield func(string) bool) {
+		for _, k := range keys {
+			if !yield(k) {
+				return
+			}
+		}
+	})
+	x.Set([]string{"a", "b", "c", "e"})
+	moved := make(map[string]KeyMove)
+	for _, m := range moves {
+		moved[m.Key] = m
+	}
+	for _, k := range keys {
+		after := mustGet(t, x, k)
+		m, ok := moved[k]
+		if ok != (before[k] != after) {
+			t.Errorf("%s: reported moving %v, owner %s -> %s", k, ok, before[k], after)
+		}
+		if ok && (m.From != before[k] || m.To != after) {
+			t.Errorf("%s: got move %+v, expected %s -> %s", k, m, before[k], after)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package consistent
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math"
+)
+
+// Encoding written by MarshalBinary, all integers little endian:
+//
+//	version  byte    encodingVersion
+//	hasher   byte    (mappedCRC32 ... mappedMurmur3)
+//	deriver  byte    1 if Config.KeyDeriver is set, else 0
+//	replicas uint32  default number of replicas
+//	members  uint32
+//	then for each member, sorted by name:
+//	name     uint32 length, bytes
+//	replicas uint32
+//	salt     uint32 length, bytes
+//
+// Snapshot.MarshalBinary writes the version and the members only.
+const encodingVersion = 1
+
+var (
+	// ErrBadEncoding is returned when data is not a ring encoded by MarshalBinary or MarshalJSON.
+	ErrBadEncoding = errors.New("consistent: invalid ring encoding")
+	// ErrEncodingVersion is returned for a ring encoded by a newer format version.
+	ErrEncodingVersion = errors.New("consistent: unsupported ring encoding version")
+	// ErrConfigMismatch is returned when a ring is decoded into a ring hashing differently.
+	ErrConfigMismatch = errors.New("consistent: encoded ring hashes differently")
+	// ErrDecodeRejected is returned when Config.Guard rejects the membership of a decoded ring.
+	ErrDecodeRejected = errors.New("consistent: decoded ring rejected by guard")
+)
+
+// ringEncoding is what MarshalBinary and MarshalJSON capture of a ring.
+type ringEncoding struct {
+	Version       int              `json:"version"`
+	Hasher        string           `json:"hasher"`
+	CustomDeriver bool             `json:"customKeyDeriver,omitempty"`
+	Replicas      int              `json:"replicas"`
+	Members       []SnapshotMember `json:"members"`
+}
+
+var hasherNames = [...]string{mappedCRC32: "crc32", mappedFnv: "fnv", mappedCustom: "custom", mappedKetama: "ketama",
+	mappedXXH32: "xxh32", mappedXXH64: "xxh64", mappedMurmur3: "murmur3"}
+
+// need c.RLock() before calling
+func (c *Consistent) encoding() ringEncoding {
+	hasher := c.hasherKind()
+	return ringEncoding{
+		Version:       encodingVersion,
+		Hasher:        hasherNames[hasher],
+		CustomDeriver: c.keyDeriver != nil,
+		Replicas:      c.defaultNumberOfReplicas,
+		Members:       c.snapshot().Members,
+	}
+}
+
+// MarshalBinary encodes the members of the ring, their replicas and salts, and its hashing
+// settings. Encoding the same membership always gives the same bytes. Custom hashers and
+// key derivers are only recorded as being used, so they must be configured again.
+func (c *Consistent) MarshalBinary() ([]byte, error) {
+	c.RLock()
+	e := c.encoding()
+	c.RUnlock()
+	var hasher byte
+	for i, name := range hasherNames {
+		if name == e.Hasher {
+			hasher = byte(i)
+		}
+	}
+	var deriver byte
+	if e.CustomDeriver {
+		deriver = 1
+	}
+	w := binaryWriter{buf: []byte{encodingVersion, hasher, deriver}}
+	w.int(e.Replicas)
+	w.members(e.Members)
+	return w.buf, nil
+}
+
+// UnmarshalBinary restores the membership encoded by MarshalBinary, like Restore. The ring
+// must have been created with the same hasher, key deriver and default number of replicas,
+// or ErrConfigMismatch is returned. ErrDecodeRejected is returned if Config.Guard rejects
+// the change.
+func (c *Consistent) UnmarshalBinary(data []byte) error {
+	if len(data) < 1 {
+		return ErrBadEncoding
+	}
+	if data[0] != encodingVersion {
+		return ErrEncodingVersion
+	}
+	if len(data) < 3 || int(data[1]) >= len(hasherNames) || data[2] > 1 {
+		return ErrBadEncoding
+	}
+	e := ringEncoding{Version: int(data[0]), Hasher: hasherNames[data[1]], CustomDeriver: data[2] == 1}
+	r := binaryReader{data: data[3:]}
+	e.Replicas = r.int()
+	e.Members = r.members()
+	if err := r.done(); err != nil {
+		return err
+	}
+	return c.decode(e)
+}
+
+// MarshalBinary encodes s as the members of Consistent.MarshalBinary, after the version of
+// the encoding: the bytes of BinaryCodec.
+func (s Snapshot) MarshalBinary() ([]byte, error) {
+	w := binaryWriter{buf: []byte{encodingVersion}}
+	w.members(s.Members)
+	return w.buf, nil
+}
+
+// UnmarshalBinary decodes a snapshot encoded by MarshalBinary.
+func (s *Snapshot) UnmarshalBinary(data []byte) error {
+	if len(data) < 1 {
+		return ErrBadEncoding
+	}
+	if data[0] != encodingVersion {
+		return ErrEncodingVersion
+	}
+	r := binaryReader{data: data[1:]}
+	res := Snapshot{Members: r.members()}
+	if err := r.done(); err != nil {
+		return err
+	}
+	if res.Members == nil {
+		res.Members = []SnapshotMember{}
+	}
+	*s = res
+	return nil
+}
+
+// binaryWriter appends the integers and strings of the binary encodings to buf.
+type binaryWriter struct {
+	buf []byte
+}
+
+func (w *binaryWriter) uint64(v uint64) {
+	var tmp [8]byte
+	binary.LittleEndian.PutUint64(tmp[:], v)
+	w.buf = append(w.buf, tmp[:]...)
+}
+
+func (w *binaryWriter) int(v int) {
+	var tmp [4]byte
+	binary.LittleEndian.PutUint32(tmp[:], uint32(v))
+	w.buf = append(w.buf, tmp[:]...)
+}
+
+func (w *binaryWriter) string(s string) {
+	w.int(len(s))
+	w.buf = append(w.buf, s...)
+}
+
+func (w *binaryWriter) members(ms []SnapshotMember) {
+	w.int(len(ms))
+	for _, m := range ms {
+		w.string(m.Name)
+		w.int(m.Replicas)
+		w.string(m.Salt)
+	}
+}
+
+// binaryReader reads what binaryWriter writes from data. Reading past the end or an
+// invalid value makes it bad, and every later read return zero values.
+type binaryReader struct {
+	data []byte
+	bad  bool
+}
+
+func (r *binaryReader) uint64() uint64 {
+	if r.bad || len(r.data) < 8 {
+		r.bad = true
+		return 0
+	}
+	v := binary.LittleEndian.Uint64(r.data)
+	r.data = r.data[8:]
+	return v
+}
+
+func (r *binaryReader) uint32() uint32 {
+	if r.bad || len(r.data) < 4 {
+		r.bad = true
+		return 0
+	}
+	v := binary.LittleEndian.Uint32(r.data)
+	r.data = r.data[4:]
+	return v
+}
+
+// int fails for values not fitting an int32, so as not to overflow an int on 32-bit
+// platforms.
+func (r *binaryReader) int() int {
+	v := r.uint32()
+	if v > math.MaxInt32 {
+		r.bad = true
+		return 0
+	}
+	return int(v)
+}
+
+// count reads a number of items taking at least size bytes each.
+func (r *binaryReader) count(size int) int {
+	v := r.uint32()
+	if uint64(v) > uint64(len(r.data)/size) {
+		r.bad = true
+		return 0
+	}
+	return int(v)
+}
+
+func (r *binaryReader) string() string {
+	n := r.uint32()
+	if r.bad || uint64(n) > uint64(len(r.data)) {
+		r.bad = true
+		return ""
+	}
+	s := string(r.data[:n])
+	r.data = r.data[n:]
+	return s
+}
+
+func (r *binaryReader) members() []SnapshotMember {
+	var res []SnapshotMember
+	for n := r.count(12); !r.bad && n > 0; n-- {
+		m := SnapshotMember{Name: r.string()}
+		m.Replicas = r.int()
+		m.Salt = r.string()
+		res = append(res, m)
+	}
+	return res
+}
+
+// done returns ErrBadEncoding if the data was bad or not read to the end.
+func (r *binaryReader) done() error {
+	if r.bad || len(r.data) != 0 {
+		return ErrBadEncoding
+	}
+	return nil
+}
+
+// MarshalJSON encodes the same as MarshalBinary as JSON.
+func (c *Consistent) MarshalJSON() ([]byte, error) {
+	c.RLock()
+	e := c.encoding()
+	c.RUnlock()
+	return json.Marshal(e)
+}
+
+// UnmarshalJSON restores the membership encoded by MarshalJSON, like UnmarshalBinary.
+func (c *Consistent) UnmarshalJSON(data []byte) error {
+	var e ringEncoding
+	if err := json.Unmarshal(data, &e); err != nil {
+		return err
+	}
+	if e.Version != encodingVersion {
+		return ErrEncodingVersion
+	}
+	return c.decode(e)
+}
+
+func (c *Consistent) decode(e ringEncoding) error {
+	c.RLock()
+	want := c.encoding()
+	c.RUnlock()
+	if e.Hasher != want.Hasher || e.CustomDeriver != want.CustomDeriver || e.Replicas != want.Replicas {
+		return ErrConfigMismatch
+	}
+	s := Snapshot{Members: e.Members}
+	c.warmupRestore(s)
+	c.Lock()
+	defer c.unlockAndNotify()
+	if !c.restore(s) {
+		return ErrDecodeRejected
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package consistent
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMarshalBinary(t *testing.T) {
+	x := New(newConfig())
+	x.Add("abcdefg")
+	x.Add("hijklmn", 7)
+	x.AddWithSalt("opqrstu", "v2")
+	data, err := x.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data[0] != encodingVersion {
+		t.Errorf("got format version %d", data[0])
+	}
+	y := New(newConfig())
+	y.Add("other")
+	if err := y.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(x.Snapshot(), y.Snapshot()) || x.Fingerprint() != y.Fingerprint() {
+		t.Errorf("got %v, expected %v", y.Snapshot(), x.Snapshot())
+	}
+	again, _ := y.MarshalBinary()
+	if !bytes.Equal(data, again) {
+		t.Errorf("expected identical bytes after a round trip")
+	}
+
+	if err := New(Config{DefaultNumberOfReplicas: 20, UseFnv: true}).UnmarshalBinary(data); err != ErrConfigMismatch {
+		t.Errorf("got %v, expected ErrConfigMismatch", err)
+	}
+	if err := y.UnmarshalBinary(data[:len(data)-1]); err != ErrBadEncoding {
+		t.Errorf("got %v, expected ErrBadEncoding for truncated data", err)
+	}
+	bad := append([]byte{encodingVersion + 1}, data[1:]...)
+	if err := y.UnmarshalBinary(bad); err != ErrEncodingVersion {
+		t.Errorf("got %v, expected ErrEncodingVersion", err)
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	x := New(newConfig())
+	x.Add("abcdefg")
+	x.AddWithSalt("hijklmn", "v2", 5)
+	data, err := json.Marshal(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	y := New(newConfig())
+	if err := json.Unmarshal(data, y); err != nil {
+		t.Fatal(err)
+	}
+	if x.Fingerprint() != y.Fingerprint() {
+		t.Errorf("got %v, expected %v", y.Snapshot(), x.Snapshot())
+	}
+	if err := y.UnmarshalJSON([]byte(`{"version":2}`)); err != ErrEncodingVersion {
+		t.Errorf("got %v, expected ErrEncodingVersion", err)
+	}
+}
+
+func TestUnmarshalBinaryRejected(t *testing.T) {
+	x := New(newConfig())
+	x.Add("abcdefg")
+	data, _ := x.MarshalBinary()
+	cfg := newConfig()
+	cfg.Guard = &Guard{MinMembers: 3}
+	y := New(cfg)
+	y.Set([]string{"abcdefg", "hijklmn", "opqrstu"})
+	if err := y.UnmarshalBinary(data); err != ErrDecodeRejected {
+		t.Errorf("got %v, expected ErrDecodeRejected", err)
+	}
+	checkNum(len(y.Members()), 3, t)
+}
+
+func TestUnmarshalBinaryLengths(t *testing.T) {
+	x := New(newConfig())
+	x.Add("abcdefg")
+	data, _ := x.MarshalBinary()
+	for name, off := range map[string]int{"replicas": 3, "members": 7, "name": 11, "member replicas": 22} {
+		bad := append([]byte(nil), data...)
+		copy(bad[off:], []byte{0xff, 0xff, 0xff, 0xff})
+		if err := New(newConfig()).UnmarshalBinary(bad); err != ErrBadEncoding {
+			t.Errorf("%s: got %v, expected ErrBadEncoding", name, err)
+		}
+	}
+}
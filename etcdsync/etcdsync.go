@@ -0,0 +1,221 @@
+// Package etcdsync keeps the membership of a ring equal to the members registered under an
+// etcd prefix, for sharding driven by service discovery: each member registers a key
+// <prefix><name>, typically bound to a lease, whose value is its number of replicas or
+// empty for the default.
+//
+// It does not depend on the etcd client library. A Client adapts one, typically:
+//
+//	type etcdClient struct{ c *clientv3.Client }
+//
+//	func (e etcdClient) List(ctx context.Context, prefix string) (map[string][]byte, error) {
+//		resp, err := e.c.Get(ctx, prefix, clientv3.WithPrefix())
+//		if err != nil {
+//			return nil, err
+//		}
+//		res := make(map[string][]byte, len(resp.Kvs))
+//		for _, kv := range resp.Kvs {
+//			res[string(kv.Key)] = kv.Value
+//		}
+//		return res, nil
+//	}
+//
+//	func (e etcdClient) Watch(ctx context.Context, prefix string) <-chan etcdsync.Event {
+//		ch := make(chan etcdsync.Event)
+//		go func() {
+//			defer close(ch)
+//			for resp := range e.c.Watch(ctx, prefix, clientv3.WithPrefix()) {
+//				for _, ev := range resp.Events {
+//					ch <- etcdsync.Event{Key: string(ev.Kv.Key), Value: ev.Kv.Value,
+//						Deleted: ev.Type == clientv3.EventTypeDelete}
+//				}
+//			}
+//		}()
+//		return ch
+//	}
+package etcdsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jiangz222/consistent"
+)
+
+// DefaultRetryDelay is the time a Watcher waits before listing the prefix again after an
+// error when RetryDelay is not set.
+const DefaultRetryDelay = time.Second
+
+// ErrWatchClosed is reported when the watch of the prefix ends before the context is done.
+var ErrWatchClosed = errors.New("etcdsync: watch closed")
+
+// Event is a change of a key under the prefix.
+type Event struct {
+	Key     string
+	Value   []byte
+	Deleted bool
+}
+
+// Client is the subset of an etcd client a Watcher uses.
+type Client interface {
+	// List returns the keys under prefix and their values.
+	List(ctx context.Context, prefix string) (map[string][]byte, error)
+	// Watch returns a channel receiving the changes of the keys under prefix, closed when
+	// ctx is done or the watch fails.
+	Watch(ctx context.Context, prefix string) <-chan Event
+}
+
+// Watcher applies the members registered under Prefix to Ring with SetWithReplicas. The
+// replicas of a member already in the ring are not changed until it registers again.
+type Watcher struct {
+	Client Client
+	Prefix string
+	Ring   *consistent.Consistent
+	// Debounce is the time to wait without further changes before applying them, so a
+	// rolling deploy causes a single remap. 0 applies every change at once.
+	Debounce time.Duration
+	// Replicas parses the value of a member key into its number of replicas, 0 meaning the
+	// default of the ring. Defaults to a decimal integer, empty meaning 0.
+	Replicas func(value []byte) (int, error)
+	// OnError, if set, is called with the errors of the client and the values Replicas
+	// rejects, whose members are left out.
+	OnError func(error)
+	// RetryDelay is the time to wait before listing the prefix again after the client
+	// failed. Defaults to DefaultRetryDelay.
+	RetryDelay time.Duration
+}
+
+// New creates a Watcher applying the members registered under prefix to ring.
+func New(client Client, prefix string, ring *consistent.Consistent) *Watcher {
+	return &Watcher{Client: client, Prefix: prefix, Ring: ring}
+}
+
+// Run lists the members under the prefix, applies them and follows their changes until
+// ctx is done, listing them again whenever the client fails. It returns ctx.Err().
+func (w *Watcher) Run(ctx context.Context) error {
+	for {
+		err := w.follow(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		w.report(err)
+		delay := w.RetryDelay
+		if delay <= 0 {
+			delay = DefaultRetryDelay
+		}
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// follow lists the prefix and follows its changes until the watch ends, returning the
+// error that ended it.
+func (w *Watcher) follow(ctx context.Context) error {
+	// watch before listing so no change is missed in between
+	wctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	events := w.Client.Watch(wctx, w.Prefix)
+	kvs, err := w.Client.List(ctx, w.Prefix)
+	if err != nil {
+		return err
+	}
+	members := make(map[string]int, len(kvs))
+	for k, v := range kvs {
+		w.update(members, Event{Key: k, Value: v})
+	}
+	w.apply(members)
+
+	var (
+		timer *time.Timer
+		fire  <-chan time.Time
+	)
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				if fire != nil {
+					w.apply(members)
+				}
+				return ErrWatchClosed
+			}
+			w.update(members, ev)
+			if w.Debounce <= 0 {
+				w.apply(members)
+				continue
+			}
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = time.NewTimer(w.Debounce)
+			fire = timer.C
+		case <-fire:
+			fire = nil
+			w.apply(members)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// update records ev in members.
+func (w *Watcher) update(members map[string]int, ev Event) {
+	name := strings.TrimPrefix(ev.Key, w.Prefix)
+	if name == "" || !strings.HasPrefix(ev.Key, w.Prefix) {
+		return
+	}
+	if ev.Deleted {
+		delete(members, name)
+		return
+	}
+	n, err := w.replicas(ev.Value)
+	if err != nil {
+		delete(members, name)
+		w.report(fmt.Errorf("etcdsync: member %s: %v", name, err))
+		return
+	}
+	members[name] = n
+}
+
+func (w *Watcher) replicas(value []byte) (int, error) {
+	if w.Replicas != nil {
+		return w.Replicas(value)
+	}
+	if len(value) == 0 {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(string(value)))
+	if err == nil && n < 0 {
+		err = fmt.Errorf("negative replicas %d", n)
+	}
+	return n, err
+}
+
+// apply makes members the membership of the ring.
+func (w *Watcher) apply(members map[string]int) {
+	elts := make([]consistent.SetElt, 0, len(members))
+	for m, n := range members {
+		elts = append(elts, consistent.SetElt{Elt: m, NumberOfReplicas: n})
+	}
+	sort.Slice(elts, func(i, j int) bool { return elts[i].Elt < elts[j].Elt })
+	w.Ring.SetWithReplicas(elts)
+}
+
+func (w *Watcher) report(err error) {
+	if err != nil && w.OnError != nil {
+		w.OnError(err)
+	}
+}
@@ -0,0 +1,165 @@
+package etcdsync
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jiangz222/consistent"
+)
+
+// fake implements Client over a map, failing List while fail is set.
+type fake struct {
+	mu   sync.Mutex
+	data map[string][]byte
+	subs []chan Event
+	fail error
+}
+
+func (f *fake) List(ctx context.Context, prefix string) (map[string][]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fail != nil {
+		return nil, f.fail
+	}
+	res := make(map[string][]byte)
+	for k, v := range f.data {
+		if strings.HasPrefix(k, prefix) {
+			res[k] = v
+		}
+	}
+	return res, nil
+}
+
+func (f *fake) Watch(ctx context.Context, prefix string) <-chan Event {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ch := make(chan Event, 16)
+	f.subs = append(f.subs, ch)
+	return ch
+}
+
+func (f *fake) put(key, value string) {
+	f.send(Event{Key: key, Value: []byte(value)})
+}
+
+func (f *fake) delete(key string) {
+	f.send(Event{Key: key, Deleted: true})
+}
+
+func (f *fake) send(ev Event) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if ev.Deleted {
+		delete(f.data, ev.Key)
+	} else {
+		f.data[ev.Key] = ev.Value
+	}
+	for _, ch := range f.subs {
+		ch <- ev
+	}
+}
+
+// closeWatches ends the current watches, as a lost connection would.
+func (f *fake) closeWatches() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, ch := range f.subs {
+		close(ch)
+	}
+	f.subs = nil
+}
+
+func waitMembers(t *testing.T, c *consistent.Consistent, want ...string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		got := c.Members()
+		sort.Strings(got)
+		if strings.Join(got, ",") == strings.Join(want, ",") {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("members %v, expected %v", got, want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	f := &fake{data: map[string][]byte{
+		"/ring/a":  []byte("10"),
+		"/ring/b":  nil,
+		"/other/x": nil,
+	}}
+	c := consistent.New(consistent.Config{DefaultNumberOfReplicas: 20})
+	var (
+		mu   sync.Mutex
+		errs []error
+	)
+	w := New(f, "/ring/", c)
+	w.Debounce = 20 * time.Millisecond
+	w.RetryDelay = 10 * time.Millisecond
+	w.OnError = func(err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- w.Run(ctx) }()
+
+	waitMembers(t, c, "a", "b")
+	if r := c.MemberReplicas(); r["a"] != 10 || r["b"] != 20 {
+		t.Errorf("unexpected replicas %v", r)
+	}
+
+	// a burst of changes is applied at once
+	var changes int32
+	cancelChange := c.OnChange(func(consistent.ChangeEvent) { atomic.AddInt32(&changes, 1) })
+	f.put("/ring/c", "")
+	f.put("/ring/d", "")
+	f.delete("/ring/a")
+	waitMembers(t, c, "b", "c", "d")
+	cancelChange()
+	if n := atomic.LoadInt32(&changes); n != 1 {
+		t.Errorf("got %d changes, expected the burst to be debounced into 1", n)
+	}
+
+	f.put("/ring/bad", "many")
+	time.Sleep(50 * time.Millisecond)
+	waitMembers(t, c, "b", "c", "d")
+
+	// after a lost watch the prefix is listed again
+	f.mu.Lock()
+	f.fail = errors.New("unavailable")
+	f.mu.Unlock()
+	f.closeWatches()
+	time.Sleep(30 * time.Millisecond)
+	f.mu.Lock()
+	f.fail = nil
+	f.data["/ring/e"] = nil
+	f.mu.Unlock()
+	waitMembers(t, c, "b", "c", "d", "e")
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("got %v, expected context.Canceled", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	var closed, unavailable, bad bool
+	for _, err := range errs {
+		closed = closed || err == ErrWatchClosed
+		unavailable = unavailable || err.Error() == "unavailable"
+		bad = bad || strings.Contains(err.Error(), "member bad")
+	}
+	if !closed || !unavailable || !bad {
+		t.Errorf("missing errors in %v", errs)
+	}
+}
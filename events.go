@@ -0,0 +1,115 @@
+package consistent
+
+import (
+	"sync"
+	"time"
+)
+
+// ChangeEvent describes a change of the ring membership made by one call to Add, Remove,
+// Set or SetWithReplicas, the start or end of the quarantine of a flapping member, or a
+// member marked down or up.
+type ChangeEvent struct {
+	Added   []string
+	Removed []string
+	// Version is the version of the ring after the change, see Consistent.Version.
+	Version uint64
+	// Incarnations holds the incarnation of each added member, see Consistent.Incarnation.
+	Incarnations map[string]uint64
+	// Quarantined and Released list the members whose quarantine started or ended, see
+	// Config.FlapThreshold.
+	Quarantined []string
+	Released    []string
+	// Down and Up list the members marked down or up, see MarkDown.
+	Down []string
+	Up   []string
+	// Evicted lists the members, also in Removed, evicted to make room for added ones, see
+	// Config.MaxMembers.
+	Evicted []string
+	// Expired lists the members, also in Removed, removed for not being touched within
+	// their ttl, see AddWithTTL.
+	Expired []string
+	// Moved lists the ranges of the hash space that changed owner, sorted by End. It is
+	// only set with Config.TrackMovedRanges.
+	Moved []MovedRange
+}
+
+func (e ChangeEvent) empty() bool {
+	return len(e.Added) == 0 && len(e.Removed) == 0 && len(e.Quarantined) == 0 && len(e.Released) == 0 &&
+		len(e.Down) == 0 && len(e.Up) == 0
+}
+
+// Incarnation returns the incarnation of elt: 1 the first time it is added to the ring,
+// bumped every time it is added again after a removal. It stays readable after elt is
+// removed, and is 0 for names that were never members. Consumers can compare it with the
+// incarnation they last saw to tell a member that stayed from one that left and came back.
+func (c *Consistent) Incarnation(elt string) uint64 {
+	c.RLock()
+	defer c.RUnlock()
+	return c.incarnations[elt]
+}
+
+// OnChange registers fn to be called after every membership change. fn runs synchronously
+// on the goroutine that made the change, after the ring is unlocked, so it may use the ring.
+// The returned function unregisters fn.
+func (c *Consistent) OnChange(fn func(ChangeEvent)) (cancel func()) {
+	return c.listeners.add(fn)
+}
+
+// need c.Lock() before calling
+// unlockAndNotify unlocks the ring and delivers the changes accumulated under the lock.
+func (c *Consistent) unlockAndNotify() {
+	ev := c.changes
+	c.changes = ChangeEvent{}
+	ev.Moved = c.takeMoves()
+	c.stats.writes++
+	if !ev.empty() {
+		c.stats.version++
+		ev.Version = c.stats.version
+		c.stats.lastChange = time.Now()
+		c.history.record(c.stats.version, c.sortedHashes, c.circle)
+		c.recordDelta(ev)
+		c.publish()
+	}
+	c.Unlock()
+	if !ev.empty() {
+		c.listeners.notify(ev)
+	}
+}
+
+type listener struct {
+	id int
+	fn func(ChangeEvent)
+}
+
+type listeners struct {
+	mu   sync.Mutex
+	next int
+	list []listener
+}
+
+func (l *listeners) add(fn func(ChangeEvent)) func() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	id := l.next
+	l.next++
+	l.list = append(l.list, listener{id, fn})
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		for i, v := range l.list {
+			if v.id == id {
+				l.list = append(l.list[:i:i], l.list[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func (l *listeners) notify(ev ChangeEvent) {
+	l.mu.Lock()
+	list := l.list
+	l.mu.Unlock()
+	for _, v := range list {
+		v.fn(ev)
+	}
+}
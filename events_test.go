@@ -0,0 +1,68 @@
+package consistent
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestOnChange(t *testing.T) {
+	x := New(newConfig())
+	var events []ChangeEvent
+	cancel := x.OnChange(func(ev ChangeEvent) {
+		if _, err := x.Get("ggg"); err != nil && err != ErrEmptyCircle {
+			t.Error(err)
+		}
+		events = append(events, ev)
+	})
+	x.Add("abcdefg")
+	x.Add("abcdefg")
+	x.Set([]string{"hijklmn", "opqrstu"})
+	x.Remove("missing")
+	x.Remove("hijklmn")
+	cancel()
+	x.Remove("opqrstu")
+
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d: %+v", len(events), events)
+	}
+	if len(events[0].Added) != 1 || events[0].Added[0] != "abcdefg" {
+		t.Errorf("unexpected first event %+v", events[0])
+	}
+	sort.Strings(events[1].Added)
+	if len(events[1].Removed) != 1 || len(events[1].Added) != 2 || events[1].Added[0] != "hijklmn" {
+		t.Errorf("unexpected Set event %+v", events[1])
+	}
+	if len(events[2].Removed) != 1 || events[2].Removed[0] != "hijklmn" {
+		t.Errorf("unexpected Remove event %+v", events[2])
+	}
+	for i, ev := range events {
+		if ev.Version != uint64(i+1) {
+			t.Errorf("event %d: got version %d, expected %d", i, ev.Version, i+1)
+		}
+	}
+}
+
+func TestIncarnation(t *testing.T) {
+	x := New(newConfig())
+	var events []ChangeEvent
+	x.OnChange(func(ev ChangeEvent) { events = append(events, ev) })
+	x.Add("abcdefg")
+	x.Add("abcdefg")
+	if x.Incarnation("abcdefg") != 1 || x.Incarnation("missing") != 0 {
+		t.Fatalf("got incarnations %d and %d", x.Incarnation("abcdefg"), x.Incarnation("missing"))
+	}
+	x.Remove("abcdefg")
+	if x.Incarnation("abcdefg") != 1 {
+		t.Errorf("expected the incarnation to survive removal")
+	}
+	x.Set([]string{"abcdefg", "hijklmn"})
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(events))
+	}
+	if events[0].Incarnations["abcdefg"] != 1 || events[1].Incarnations != nil {
+		t.Errorf("unexpected events %+v", events[:2])
+	}
+	if events[2].Incarnations["abcdefg"] != 2 || events[2].Incarnations["hijklmn"] != 1 {
+		t.Errorf("unexpected Set event %+v", events[2])
+	}
+}
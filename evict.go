@@ -0,0 +1,36 @@
+package consistent
+
+// EvictUnowned keeps a node-local cache in line with the ring: after every change that may
+// take keys away from self, it lists the cached keys with keys and calls evict for each key
+// self is no longer one of the owners GetN(key, owners) returns, so 1 keeps only the keys
+// self is the primary owner of. The returned function stops it.
+func EvictUnowned(c *Consistent, self string, owners int, keys func() []string, evict func(key string)) (cancel func()) {
+	if owners < 1 {
+		owners = 1
+	}
+	return c.OnChange(func(ev ChangeEvent) {
+		// keys only move away from self when members join, come back from quarantine or
+		// are marked up, or when self leaves, is quarantined or is marked down
+		if len(ev.Added) == 0 && len(ev.Released) == 0 && len(ev.Up) == 0 &&
+			!sliceContainsMember(ev.Removed, self) && !sliceContainsMember(ev.Quarantined, self) &&
+			!sliceContainsMember(ev.Down, self) {
+			return
+		}
+		cached := keys()
+		var unowned []string
+		c.RLock()
+		for _, k := range cached {
+			var res []string
+			if len(c.circle) > 0 {
+				res, _, _ = c.lookup(k, owners, 0, nil)
+			}
+			if !sliceContainsMember(res, self) {
+				unowned = append(unowned, k)
+			}
+		}
+		c.RUnlock()
+		for _, k := range unowned {
+			evict(k)
+		}
+	})
+}
@@ -0,0 +1,44 @@
+package consistent
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestEvictUnowned(t *testing.T) {
+	x := New(newConfig())
+	x.Set([]string{"a", "b"})
+	cache := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		k := "user" + strconv.Itoa(i)
+		if m, _ := x.Get(k); m == "a" {
+			cache[k] = true
+		}
+	}
+	listed := 0
+	cancel := EvictUnowned(x, "a", 1, func() []string {
+		listed++
+		var res []string
+		for k := range cache {
+			res = append(res, k)
+		}
+		return res
+	}, func(k string) { delete(cache, k) })
+
+	x.Remove("b")
+	checkNum(listed, 0, t)
+	x.Add("b")
+	x.Add("c")
+	checkNum(listed, 2, t)
+	if len(cache) == 0 {
+		t.Fatalf("expected a to keep some keys")
+	}
+	for k := range cache {
+		if m, _ := x.Get(k); m != "a" {
+			t.Errorf("%s is owned by %s but still cached", k, m)
+		}
+	}
+	x.Remove("a")
+	checkNum(len(cache), 0, t)
+	cancel()
+}
@@ -0,0 +1,102 @@
+package consistent
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var (
+	// ErrInvalidImport is returned by ImportMembers for a payload naming a member twice,
+	// naming none or giving a negative number of replicas.
+	ErrInvalidImport = errors.New("consistent: invalid member import")
+	// ErrImportRejected is returned by ImportMembers when Config.Guard rejects the change.
+	ErrImportRejected = errors.New("consistent: member import rejected by guard")
+)
+
+// ExportedMember is one member of a MemberExport with all it was added with. Tags and
+// other attributes of a member belong in Meta.
+type ExportedMember struct {
+	Name     string      `json:"name"`
+	Replicas int         `json:"replicas"`
+	Salt     string      `json:"salt,omitempty"`
+	Zone     string      `json:"zone,omitempty"`
+	Meta     interface{} `json:"meta,omitempty"`
+}
+
+// MemberExport is the membership of a ring with the weight, salt, zone and meta of each
+// member, sorted by name, for tooling to edit the topology offline, e.g. as JSON, and
+// apply it back with ImportMembers. A Meta decoded from JSON holds the generic values of
+// encoding/json rather than the type it was added with.
+type MemberExport struct {
+	Members []ExportedMember `json:"members"`
+}
+
+// ExportMembers returns the membership of the ring with the attributes of its members.
+func (c *Consistent) ExportMembers() MemberExport {
+	c.RLock()
+	defer c.RUnlock()
+	e := MemberExport{Members: make([]ExportedMember, 0, len(c.members))}
+	for m := range c.members {
+		e.Members = append(e.Members, ExportedMember{
+			Name:     m,
+			Replicas: c.membersReplicas[m],
+			Salt:     c.salts[m],
+			Zone:     c.zones[m],
+			Meta:     c.metas[m],
+		})
+	}
+	sort.Slice(e.Members, func(i, j int) bool { return e.Members[i].Name < e.Members[j].Name })
+	return e
+}
+
+// ImportMembers makes the membership of the ring that of e in a single change, like
+// Restore, and sets the zone and meta of every member. A member with 0 replicas gets the
+// default. It returns an error wrapping ErrInvalidImport or ErrImportRejected, leaving the
+// ring as it is, if e is invalid or the change is rejected by Config.Guard.
+func (c *Consistent) ImportMembers(e MemberExport) error {
+	s := Snapshot{Members: make([]SnapshotMember, 0, len(e.Members))}
+	seen := make(map[string]bool, len(e.Members))
+	for _, m := range e.Members {
+		switch {
+		case m.Name == "":
+			return fmt.Errorf("%w: member without a name", ErrInvalidImport)
+		case seen[m.Name]:
+			return fmt.Errorf("%w: %q appears twice", ErrInvalidImport, m.Name)
+		case m.Replicas < 0:
+			return fmt.Errorf("%w: %q has %d replicas", ErrInvalidImport, m.Name, m.Replicas)
+		}
+		seen[m.Name] = true
+		s.Members = append(s.Members, SnapshotMember{Name: m.Name, Replicas: m.Replicas, Salt: m.Salt})
+	}
+
+	c.warmupRestore(s)
+	c.Lock()
+	defer c.unlockAndNotify()
+	if !c.restore(s) {
+		return ErrImportRejected
+	}
+	for _, m := range e.Members {
+		if !c.members[m.Name] {
+			// not admitted by Config.MaxMembers
+			continue
+		}
+		if m.Zone != "" {
+			if c.zones == nil {
+				c.zones = make(map[string]string)
+			}
+			c.zones[m.Name] = m.Zone
+		} else {
+			delete(c.zones, m.Name)
+		}
+		if m.Meta != nil {
+			if c.metas == nil {
+				c.metas = make(map[string]interface{})
+			}
+			c.metas[m.Name] = m.Meta
+		} else {
+			delete(c.metas, m.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package consistent
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestExportImportMembers(t *testing.T) {
+	x := New(newConfig())
+	x.AddWithZone("a", "us-east-1a", 30)
+	x.AddWithMeta("b", map[string]interface{}{"rack": "r1"})
+	x.AddWithSalt("c", "v2")
+	e := x.ExportMembers()
+	want := []ExportedMember{
+		{Name: "a", Replicas: 30, Zone: "us-east-1a"},
+		{Name: "b", Replicas: 20, Meta: map[string]interface{}{"rack": "r1"}},
+		{Name: "c", Replicas: 20, Salt: "v2"},
+	}
+	if !reflect.DeepEqual(e.Members, want) {
+		t.Fatalf("exported %+v, expected %+v", e.Members, want)
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded MemberExport
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	y := New(newConfig())
+	if err := y.ImportMembers(decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(y.ExportMembers(), e) {
+		t.Errorf("imported %+v, expected %+v", y.ExportMembers(), e)
+	}
+	if y.Fingerprint() != x.Fingerprint() {
+		t.Errorf("expected the imported ring to route like the exported one")
+	}
+
+	// edited offline: a leaves, b moves zone and loses its meta, d joins
+	var changes int
+	x.OnChange(func(ChangeEvent) { changes++ })
+	err = x.ImportMembers(MemberExport{Members: []ExportedMember{
+		{Name: "b", Zone: "us-east-1b"},
+		{Name: "c", Replicas: 20, Salt: "v2"},
+		{Name: "d", Replicas: 10, Meta: "new"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkNum(changes, 1, t)
+	want = []ExportedMember{
+		{Name: "b", Replicas: 20, Zone: "us-east-1b"},
+		{Name: "c", Replicas: 20, Salt: "v2"},
+		{Name: "d", Replicas: 10, Meta: "new"},
+	}
+	if got := x.ExportMembers().Members; !reflect.DeepEqual(got, want) {
+		t.Errorf("after import %+v, expected %+v", got, want)
+	}
+	if err := x.Verify(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestImportMembersErrors(t *testing.T) {
+	conf := newConfig()
+	conf.Guard = &Guard{MinMembers: 2}
+	x := New(conf)
+	x.Set([]string{"a", "b"})
+	before := x.ExportMembers()
+	for _, e := range []MemberExport{
+		{Members: []ExportedMember{{Name: "a"}, {Name: "a"}}},
+		{Members: []ExportedMember{{Name: ""}}},
+		{Members: []ExportedMember{{Name: "a", Replicas: -1}}},
+	} {
+		if err := x.ImportMembers(e); !errors.Is(err, ErrInvalidImport) {
+			t.Errorf("import of %+v: expected ErrInvalidImport, got %v", e, err)
+		}
+	}
+	err := x.ImportMembers(MemberExport{Members: []ExportedMember{{Name: "a", Zone: "z"}}})
+	if !errors.Is(err, ErrImportRejected) {
+		t.Errorf("expected ErrImportRejected, got %v", err)
+	}
+	if !reflect.DeepEqual(x.ExportMembers(), before) {
+		t.Errorf("expected a failed import to leave the ring as it was")
+	}
+}
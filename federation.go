@@ -0,0 +1,110 @@
+package consistent
+
+// Precedence decides how a Federation merges the owners returned by its rings.
+type Precedence int
+
+const (
+	// PreferFirst takes every owner of the first ring before those of the next one, e.g.
+	// local replicas before remote ones.
+	PreferFirst Precedence = iota
+	// Interleave takes the first owner of every ring, then the second one, and so on.
+	Interleave
+)
+
+// Federation looks keys up in several rings, e.g. one per datacenter, and merges the
+// owners with a precedence policy. Rings are listed most preferred first; empty rings are
+// skipped, so reads fall back to the next ring transparently. A member that is in several
+// rings is returned once.
+type Federation struct {
+	rings      []Locator
+	precedence Precedence
+}
+
+var _ Locator = (*Federation)(nil)
+
+// NewFederation creates a federation of rings merged with p.
+func NewFederation(p Precedence, rings ...Locator) *Federation {
+	return &Federation{rings: rings, precedence: p}
+}
+
+// Get returns the first owner of name across the rings.
+func (f *Federation) Get(name string) (string, error) {
+	res, err := f.GetN(name, 1)
+	if err != nil {
+		return "", err
+	}
+	return res[0], nil
+}
+
+// GetTwo returns the two first owners of name across the rings. The second one is empty if
+// there is only one member overall.
+func (f *Federation) GetTwo(name string) (string, string, error) {
+	res, err := f.GetN(name, 2)
+	if err != nil {
+		return "", "", err
+	}
+	if len(res) < 2 {
+		return res[0], "", nil
+	}
+	return res[0], res[1], nil
+}
+
+// GetN returns the n first owners of name across the rings, merged according to the
+// precedence of f, all of them if n < 1. It returns ErrEmptyCircle if every ring is empty.
+func (f *Federation) GetN(name string, n int) ([]string, error) {
+	var lists [][]string
+	for _, r := range f.rings {
+		res, err := r.GetN(name, n)
+		if err == ErrEmptyCircle {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, res)
+	}
+	if len(lists) == 0 {
+		return nil, ErrEmptyCircle
+	}
+	if n < 1 {
+		n = 0
+		for _, list := range lists {
+			n += len(list)
+		}
+	}
+	res := make([]string, 0, n)
+	add := func(m string) {
+		if len(res) < n && !sliceContainsMember(res, m) {
+			res = append(res, m)
+		}
+	}
+	if f.precedence == Interleave {
+		for i := 0; len(res) < n && i < n; i++ {
+			for _, list := range lists {
+				if i < len(list) {
+					add(list[i])
+				}
+			}
+		}
+		return res, nil
+	}
+	for _, list := range lists {
+		for _, m := range list {
+			add(m)
+		}
+	}
+	return res, nil
+}
+
+// Members returns the members of every ring, each once.
+func (f *Federation) Members() []string {
+	var res []string
+	for _, r := range f.rings {
+		for _, m := range r.Members() {
+			if !sliceContainsMember(res, m) {
+				res = append(res, m)
+			}
+		}
+	}
+	return res
+}
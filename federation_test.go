@@ -0,0 +1,65 @@
+package consistent
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFederation(t *testing.T) {
+	local := New(newConfig())
+	local.Set([]string{"l1", "l2"})
+	remote := New(newConfig())
+	remote.Set([]string{"r1", "r2", "l1"})
+	lo, _ := local.GetN("ggg", 2)
+	re, _ := remote.GetN("ggg", 3)
+
+	f := NewFederation(PreferFirst, local, remote)
+	res, err := f.GetN("ggg", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := lo
+	for _, m := range re {
+		if !sliceContainsMember(want, m) {
+			want = append(want, m)
+		}
+	}
+	if !reflect.DeepEqual(res, want) {
+		t.Errorf("got %v, expected %v", res, want)
+	}
+	if m, _ := f.Get("ggg"); m != lo[0] {
+		t.Errorf("got %s, expected local owner %s", m, lo[0])
+	}
+
+	for _, n := range []int{0, -1} {
+		for _, p := range []Precedence{PreferFirst, Interleave} {
+			if res, _ := NewFederation(p, local, remote).GetN("ggg", n); len(res) != 4 {
+				t.Errorf("n=%d: got %v, expected every member", n, res)
+			}
+		}
+	}
+
+	res, _ = NewFederation(Interleave, local, remote).GetN("ggg", 2)
+	if res[0] != lo[0] || (res[1] != re[0] && re[0] != lo[0]) {
+		t.Errorf("got %v, expected first owners of each ring from %v and %v", res, lo, re)
+	}
+
+	local.Set(nil)
+	if m, _ := f.Get("ggg"); m != re[0] {
+		t.Errorf("got %s, expected fallback to remote owner %s", m, re[0])
+	}
+	remote.Set(nil)
+	if _, err := f.Get("ggg"); err != ErrEmptyCircle {
+		t.Errorf("got %v, expected ErrEmptyCircle", err)
+	}
+}
+
+func TestFederationMembers(t *testing.T) {
+	a := New(newConfig())
+	a.Set([]string{"x", "y"})
+	b := New(newConfig())
+	b.Set([]string{"y", "z"})
+	if got := len(NewFederation(PreferFirst, a, b).Members()); got != 3 {
+		t.Errorf("got %d members, expected 3", got)
+	}
+}
@@ -0,0 +1,23 @@
+package consistent
+
+// GetNFiltered is like GetN but skips the members for which exclude returns true, e.g.
+// members that are draining or overloaded, without changing the ring: it returns the n closest
+// members to name that are not excluded, fewer if not enough are left. exclude is called
+// with the ring read-locked and must not change it.
+func (c *Consistent) GetNFiltered(name string, n int, exclude func(member string) bool) ([]string, error) {
+	c.RLock()
+	defer c.RUnlock()
+	if len(c.circle) == 0 {
+		c.stats.lookup(ErrEmptyCircle)
+		return nil, ErrEmptyCircle
+	}
+	res, _, err := c.lookup(name, n, 0, exclude)
+	c.stats.lookup(err)
+	if err != nil {
+		return nil, err
+	}
+	if c.rates != nil && len(res) > 0 {
+		c.rates.record(res[0])
+	}
+	return res, nil
+}
@@ -0,0 +1,60 @@
+package consistent
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestGetNFiltered(t *testing.T) {
+	x := New(newConfig())
+	for i := 0; i < 10; i++ {
+		x.Add("member" + strconv.Itoa(i))
+	}
+	all, _ := x.GetN("ggg", 10)
+	down := map[string]bool{all[0]: true, all[2]: true}
+	for _, m := range all[4:] {
+		down[m] = true
+	}
+	res, err := x.GetNFiltered("ggg", 3, func(m string) bool { return down[m] })
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{all[1], all[3]}
+	if !reflect.DeepEqual(res, want) {
+		t.Errorf("got %v, expected %v", res, want)
+	}
+	res, _ = x.GetNFiltered("ggg", 3, func(string) bool { return false })
+	if !reflect.DeepEqual(res, all[:3]) {
+		t.Errorf("got %v, expected %v", res, all[:3])
+	}
+	if _, err := New(newConfig()).GetNFiltered("ggg", 1, func(string) bool { return false }); err != ErrEmptyCircle {
+		t.Errorf("got %v, expected ErrEmptyCircle", err)
+	}
+}
+
+func TestGetNFilteredOverrides(t *testing.T) {
+	x := New(newConfig())
+	x.Set([]string{"a", "b", "c", "d"})
+	o := NewOverrides()
+	x.SetOverrides(o)
+	o.Pin("ggg", "d", 0, "")
+	res, _ := x.GetNFiltered("ggg", 2, func(m string) bool { return m == "a" })
+	if len(res) != 2 || res[0] != "d" || res[1] == "a" {
+		t.Errorf("got %v, expected the pin first and a skipped", res)
+	}
+	res, _ = x.GetNFiltered("ggg", 2, func(m string) bool { return m == "d" })
+	if len(res) != 2 || sliceContainsMember(res, "d") {
+		t.Errorf("got %v, expected the excluded pin to be skipped", res)
+	}
+}
+
+func TestGetNFilteredWeighted(t *testing.T) {
+	x := New(Config{DefaultNumberOfReplicas: 20, WeightedRendezvous: true})
+	x.Set([]string{"a", "b", "c"})
+	all, _ := x.GetN("ggg", 3)
+	res, _ := x.GetNFiltered("ggg", 2, func(m string) bool { return m == all[0] })
+	if !reflect.DeepEqual(res, all[1:]) {
+		t.Errorf("got %v, expected %v", res, all[1:])
+	}
+}
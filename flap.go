@@ -0,0 +1,122 @@
+package consistent
+
+import (
+	"sort"
+	"time"
+)
+
+// Defaults of the flap detector enabled by Config.FlapThreshold.
+const (
+	DefaultFlapWindow   = time.Minute
+	DefaultFlapCooldown = 5 * time.Minute
+)
+
+// flapDetector counts the membership changes of every member and quarantines the members
+// that change too often. A quarantined member stays in the ring but its vnodes are left out
+// of the sorted hashes, so no key is routed to it until it is released.
+type flapDetector struct {
+	threshold   int
+	window      time.Duration
+	cooldown    time.Duration
+	now         func() time.Time
+	changes     map[string][]time.Time
+	quarantined map[string]*time.Timer
+	nextSweep   time.Time // when to drop the changes of the members that stopped changing
+}
+
+func newFlapDetector(threshold int, window, cooldown time.Duration) *flapDetector {
+	if window <= 0 {
+		window = DefaultFlapWindow
+	}
+	if cooldown <= 0 {
+		cooldown = DefaultFlapCooldown
+	}
+	return &flapDetector{
+		threshold:   threshold,
+		window:      window,
+		cooldown:    cooldown,
+		now:         time.Now,
+		changes:     make(map[string][]time.Time),
+		quarantined: make(map[string]*time.Timer),
+	}
+}
+
+// Quarantined returns the members currently kept out of routing for flapping, sorted. It
+// returns nil unless Config.FlapThreshold was set.
+func (c *Consistent) Quarantined() []string {
+	c.RLock()
+	defer c.RUnlock()
+	if c.flaps == nil {
+		return nil
+	}
+	res := make([]string, 0, len(c.flaps.quarantined))
+	for m := range c.flaps.quarantined {
+		res = append(res, m)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// need c.Lock() before calling
+// recordFlap records a membership change of elt and quarantines it if it changed more than
+// the threshold within the window.
+func (c *Consistent) recordFlap(elt string) {
+	f := c.flaps
+	now := f.now()
+	f.sweep(now)
+	list := f.changes[elt]
+	for len(list) > 0 && now.Sub(list[0]) >= f.window {
+		list = list[1:]
+	}
+	list = append(list, now)
+	f.changes[elt] = list
+	if len(list) <= f.threshold {
+		return
+	}
+	if _, ok := f.quarantined[elt]; ok {
+		return
+	}
+	f.quarantined[elt] = time.AfterFunc(f.cooldown, func() { c.release(elt) })
+	c.changes.Quarantined = append(c.changes.Quarantined, elt)
+}
+
+// sweep drops, once per window, the changes that all left the window, so members that
+// leave for good, e.g. pods replaced under new names, are forgotten.
+func (f *flapDetector) sweep(now time.Time) {
+	if now.Before(f.nextSweep) {
+		return
+	}
+	f.nextSweep = now.Add(f.window)
+	for m, list := range f.changes {
+		if now.Sub(list[len(list)-1]) >= f.window {
+			delete(f.changes, m)
+		}
+	}
+}
+
+// release ends the quarantine of elt and puts it back in the sorted hashes if it is still
+// a member.
+func (c *Consistent) release(elt string) {
+	c.Lock()
+	defer c.unlockAndNotify()
+	if _, ok := c.flaps.quarantined[elt]; !ok {
+		return
+	}
+	delete(c.flaps.quarantined, elt)
+	if c.members[elt] {
+		c.captureMoves()
+		c.updateSortedHashes()
+	} else {
+		delete(c.flaps.changes, elt)
+	}
+	c.changes.Released = append(c.changes.Released, elt)
+}
+
+// need c.RLock() before calling
+func (c *Consistent) isQuarantined(elt string) bool {
+	if c.flaps == nil {
+		return false
+	}
+	_, ok := c.flaps.quarantined[elt]
+	return ok
+}
@@ -0,0 +1,81 @@
+package consistent
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestFlapQuarantine(t *testing.T) {
+	x := New(Config{DefaultNumberOfReplicas: 20, FlapThreshold: 3, FlapWindow: time.Minute, FlapCooldown: 50 * time.Millisecond})
+	events := make(chan ChangeEvent, 16)
+	x.OnChange(func(ev ChangeEvent) { events <- ev })
+	x.Add("stable")
+	x.Add("flappy")
+	x.Remove("flappy")
+	x.Add("flappy")
+	if q := x.Quarantined(); len(q) != 0 {
+		t.Fatalf("unexpected quarantine %v", q)
+	}
+	x.Remove("flappy")
+	x.Add("flappy")
+	if q := x.Quarantined(); len(q) != 1 || q[0] != "flappy" {
+		t.Fatalf("got quarantined %v, expected [flappy]", q)
+	}
+	for i := 0; i < 100; i++ {
+		if m, _ := x.Get("user" + strconv.Itoa(i)); m != "stable" {
+			t.Fatalf("got %s while flappy is quarantined", m)
+		}
+	}
+	if a, b, _ := x.GetTwo("ggg"); a != "stable" || b != "" {
+		t.Errorf("GetTwo returned %s, %s", a, b)
+	}
+
+	var quarantined, released bool
+	timeout := time.After(time.Second)
+	for !released {
+		select {
+		case ev := <-events:
+			quarantined = quarantined || len(ev.Quarantined) == 1
+			released = len(ev.Released) == 1 && ev.Released[0] == "flappy"
+		case <-timeout:
+			t.Fatal("flappy was not released")
+		}
+	}
+	if !quarantined {
+		t.Errorf("expected a quarantine event")
+	}
+	if q := x.Quarantined(); len(q) != 0 {
+		t.Errorf("unexpected quarantine %v after release", q)
+	}
+	checkNum(len(x.sortedHashes), 40, t)
+}
+
+func TestFlapQuarantineEverything(t *testing.T) {
+	x := New(Config{DefaultNumberOfReplicas: 20, FlapThreshold: 1})
+	x.Add("abcdefg")
+	x.Remove("abcdefg")
+	x.Add("abcdefg")
+	if m, err := x.Get("ggg"); err != nil || m != "abcdefg" {
+		t.Errorf("got %q, %v; expected quarantined members to be used as a last resort", m, err)
+	}
+	if x.Quarantined() == nil || New(newConfig()).Quarantined() != nil {
+		t.Errorf("unexpected Quarantined results")
+	}
+}
+
+func TestFlapForgetsDeparted(t *testing.T) {
+	x := New(Config{DefaultNumberOfReplicas: 20, FlapThreshold: 3, FlapWindow: time.Minute})
+	now := time.Unix(1000, 0)
+	x.flaps.now = func() time.Time { return now }
+	for i := 0; i < 100; i++ {
+		pod := "pod-" + strconv.Itoa(i)
+		x.Add(pod)
+		x.Remove(pod)
+	}
+	now = now.Add(time.Minute)
+	x.Add("pod-new")
+	if n := len(x.flaps.changes); n != 1 {
+		t.Errorf("got changes of %d members, expected only pod-new remembered", n)
+	}
+}
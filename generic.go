@@ -0,0 +1,144 @@
+//go:build go1.18
+// +build go1.18
+
+package consistent
+
+import "sync"
+
+// ConsistentMap is a ring whose members carry a value, e.g. a connection pool or host
+// metadata, so lookups return the value directly instead of going through a side map from
+// member names. It needs Go 1.18.
+type ConsistentMap[T any] struct {
+	write sync.Mutex // serializes the changes made through the map
+
+	mu     sync.RWMutex // guards values, never held while the ring changes
+	ring   *Consistent
+	values map[string]T
+}
+
+// NewConsistentMap creates an empty map whose ring is configured by conf.
+func NewConsistentMap[T any](conf Config) *ConsistentMap[T] {
+	m := &ConsistentMap[T]{ring: New(conf), values: make(map[string]T)}
+	// members evicted by Config.MaxMembers or expired take their value with them
+	m.ring.OnChange(func(ev ChangeEvent) { m.prune(ev.Removed) })
+	return m
+}
+
+// Add inserts elt with its value, or replaces the value if elt is already a member. The
+// value is dropped if the ring refuses elt, e.g. because of Config.MaxMembers.
+func (m *ConsistentMap[T]) Add(elt string, v T, replicas ...int) {
+	m.write.Lock()
+	defer m.write.Unlock()
+	// stored first so lookups never find elt without its value
+	m.mu.Lock()
+	m.values[elt] = v
+	m.mu.Unlock()
+	if !m.ring.IsMember(elt) {
+		m.ring.Add(elt, replicas...)
+	}
+	m.prune([]string{elt})
+}
+
+// Remove removes elt and returns its value, if it was a member and Config.Guard allowed
+// its removal.
+func (m *ConsistentMap[T]) Remove(elt string) (T, bool) {
+	m.write.Lock()
+	defer m.write.Unlock()
+	m.mu.RLock()
+	v, ok := m.values[elt]
+	m.mu.RUnlock()
+	if !ok || !m.ring.Remove(elt) {
+		var zero T
+		return zero, false
+	}
+	return v, true
+}
+
+// Set replaces the members and their values with elts. If Config.Guard refuses the
+// change, the members keep their values.
+func (m *ConsistentMap[T]) Set(elts map[string]T) {
+	m.write.Lock()
+	defer m.write.Unlock()
+	names := make([]string, 0, len(elts))
+	m.mu.Lock()
+	old := m.values
+	values := make(map[string]T, len(old)+len(elts))
+	for elt, v := range old {
+		values[elt] = v
+	}
+	for elt, v := range elts {
+		names = append(names, elt)
+		values[elt] = v
+	}
+	m.values = values
+	m.mu.Unlock()
+	m.ring.Set(names)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	values = make(map[string]T, len(elts))
+	for _, elt := range m.ring.Members() {
+		if v, ok := elts[elt]; ok {
+			values[elt] = v
+		} else if v, ok := old[elt]; ok {
+			values[elt] = v
+		}
+	}
+	m.values = values
+}
+
+// prune drops the values of the elts that are not members.
+func (m *ConsistentMap[T]) prune(elts []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, elt := range elts {
+		if !m.ring.IsMember(elt) {
+			delete(m.values, elt)
+		}
+	}
+}
+
+// Value returns the value of elt, if it is a member.
+func (m *ConsistentMap[T]) Value(elt string) (T, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	v, ok := m.values[elt]
+	return v, ok
+}
+
+// Get returns the value of the member owning name.
+func (m *ConsistentMap[T]) Get(name string) (T, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	elt, err := m.ring.Get(name)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return m.values[elt], nil
+}
+
+// GetN returns the values of the n members closest to name.
+func (m *ConsistentMap[T]) GetN(name string, n int) ([]T, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	elts, err := m.ring.GetN(name, n)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]T, len(elts))
+	for i, elt := range elts {
+		res[i] = m.values[elt]
+	}
+	return res, nil
+}
+
+// Members returns the names of the members.
+func (m *ConsistentMap[T]) Members() []string {
+	return m.ring.Members()
+}
+
+// Locator returns the ring of m for lookups by member name. Members must be changed
+// through m so their values stay in sync.
+func (m *ConsistentMap[T]) Locator() Locator {
+	return m.ring
+}
@@ -0,0 +1,67 @@
+//go:build go1.18
+// +build go1.18
+
+package consistent
+
+import "testing"
+
+type host struct {
+	addr string
+}
+
+func TestConsistentMap(t *testing.T) {
+	m := NewConsistentMap[*host](newConfig())
+	if _, err := m.Get("ggg"); err != ErrEmptyCircle {
+		t.Errorf("got %v, expected ErrEmptyCircle", err)
+	}
+	m.Add("a", &host{"10.0.0.1"})
+	m.Add("b", &host{"10.0.0.2"})
+	elt, _ := m.Locator().Get("ggg")
+	h, err := m.Get("ggg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := m.Value(elt); v != h {
+		t.Errorf("got %v, expected the value of %s", h, elt)
+	}
+	m.Add(elt, &host{"10.0.0.3"})
+	if h, _ := m.Get("ggg"); h.addr != "10.0.0.3" {
+		t.Errorf("expected the replaced value, got %v", h)
+	}
+	if len(m.Members()) != 2 {
+		t.Errorf("expected re-adding to keep 2 members")
+	}
+	hs, _ := m.GetN("ggg", 2)
+	if len(hs) != 2 || hs[0].addr != "10.0.0.3" {
+		t.Errorf("unexpected values %v", hs)
+	}
+	if v, ok := m.Remove(elt); !ok || v.addr != "10.0.0.3" {
+		t.Errorf("unexpected removed value %v", v)
+	}
+	if _, ok := m.Value(elt); ok {
+		t.Errorf("expected %s to be gone", elt)
+	}
+	m.Set(map[string]*host{"c": {"10.0.0.4"}})
+	if h, _ := m.Get("ggg"); h.addr != "10.0.0.4" {
+		t.Errorf("unexpected value after Set %v", h)
+	}
+}
+
+func TestConsistentMapCapacity(t *testing.T) {
+	m := NewConsistentMap[*host](Config{DefaultNumberOfReplicas: 20, MaxMembers: 1})
+	m.Add("a", &host{"10.0.0.1"})
+	m.Add("b", &host{"10.0.0.2"})
+	if _, ok := m.Value("b"); ok {
+		t.Errorf("expected no value for b, refused by MaxMembers")
+	}
+
+	m = NewConsistentMap[*host](Config{DefaultNumberOfReplicas: 20, MaxMembers: 1, Eviction: EvictLRU{}})
+	m.Add("a", &host{"10.0.0.1"})
+	m.Add("b", &host{"10.0.0.2"})
+	if _, ok := m.Value("a"); ok {
+		t.Errorf("expected the value of a to go with its eviction")
+	}
+	if h, _ := m.Get("ggg"); h == nil || h.addr != "10.0.0.2" {
+		t.Errorf("got %v, expected the value of b", h)
+	}
+}
@@ -0,0 +1,212 @@
+package consistent
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Governor limits how much of the hash space automated integrations, such as discovery or
+// auto-rebalancing, may move per time window, to protect stateful backends from migration
+// storms. They tell it the membership they want; it applies the changes one member at a
+// time, additions first, as long as the share of the hash space moved over the last window
+// stays under the limit, and queues the rest until older moves leave the window. A single
+// change larger than the limit is applied alone in an otherwise empty window.
+type Governor struct {
+	c        *Consistent
+	maxShare float64
+	window   time.Duration
+
+	mu     sync.Mutex
+	target map[string]int // member: replicas, 0 for the default
+	spent  []governorSpend
+	timer  *time.Timer
+	closed bool
+	// stepping is set while step runs, and again when it has to look at the pending
+	// changes afresh because they were asked for meanwhile.
+	stepping bool
+	again    bool
+}
+
+type governorSpend struct {
+	at    time.Time
+	share float64
+}
+
+// NewGovernor creates a Governor for c letting changes move at most maxShare, between 0
+// and 1, of the hash space per window. It starts from the current membership of c.
+func NewGovernor(c *Consistent, maxShare float64, window time.Duration) *Governor {
+	g := &Governor{c: c, maxShare: maxShare, window: window, target: make(map[string]int)}
+	c.RLock()
+	for m := range c.members {
+		g.target[m] = c.membersReplicas[m]
+	}
+	c.RUnlock()
+	return g
+}
+
+// Add asks for elt to join the ring, with numberOfReplicas or the default of the ring.
+func (g *Governor) Add(elt string, numbersOfReplicas ...int) {
+	g.mu.Lock()
+	n := 0
+	if len(numbersOfReplicas) > 0 {
+		n = numbersOfReplicas[0]
+	}
+	g.target[elt] = n
+	g.mu.Unlock()
+	g.step()
+}
+
+// Remove asks for elt to leave the ring.
+func (g *Governor) Remove(elt string) {
+	g.mu.Lock()
+	delete(g.target, elt)
+	g.mu.Unlock()
+	g.step()
+}
+
+// Set asks for the membership of the ring to become elts, with the default replicas for
+// the members joining.
+func (g *Governor) Set(elts []string) {
+	g.mu.Lock()
+	target := make(map[string]int, len(elts))
+	for _, e := range elts {
+		target[e] = g.target[e]
+	}
+	g.target = target
+	g.mu.Unlock()
+	g.step()
+}
+
+// Pending returns the members waiting to join and to leave the ring, sorted.
+func (g *Governor) Pending() (add, remove []string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.pending()
+}
+
+// Close stops applying queued changes.
+func (g *Governor) Close() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.closed = true
+	if g.timer != nil {
+		g.timer.Stop()
+		g.timer = nil
+	}
+}
+
+// need g.mu locked before calling
+func (g *Governor) pending() (add, remove []string) {
+	g.c.RLock()
+	defer g.c.RUnlock()
+	for m := range g.target {
+		if !g.c.members[m] {
+			add = append(add, m)
+		}
+	}
+	for m := range g.c.members {
+		if _, ok := g.target[m]; !ok {
+			remove = append(remove, m)
+		}
+	}
+	sort.Strings(add)
+	sort.Strings(remove)
+	return add, remove
+}
+
+// step applies the pending changes the budget allows, and schedules itself again when
+// some have to wait. A change refused by Config.Guard or Config.MaxMembers is retried
+// after the other changes, and stays pending if they leave it refused. The ring is changed without
+// g.mu held, so its listeners may call g; a step called while another runs is left to it.
+func (g *Governor) step() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.stepping {
+		g.again = true
+		return
+	}
+	g.stepping = true
+	defer func() { g.stepping = false }()
+	var refused map[string]bool
+	for !g.closed {
+		if g.again || refused == nil {
+			g.again = false
+			refused = make(map[string]bool)
+		}
+		now := time.Now()
+		for len(g.spent) > 0 && now.Sub(g.spent[0].at) >= g.window {
+			g.spent = g.spent[1:]
+		}
+		add, remove := g.pending()
+		var (
+			removed []string
+			added   []SetElt
+		)
+		for _, m := range add {
+			if !refused[m] {
+				added = []SetElt{{Elt: m, NumberOfReplicas: g.target[m]}}
+				break
+			}
+		}
+		for _, m := range remove {
+			if len(added) == 0 && !refused[m] {
+				removed = []string{m}
+				break
+			}
+		}
+		if len(added) == 0 && len(removed) == 0 {
+			return
+		}
+		g.c.RLock()
+		circle, hashes := g.c.simulate(removed, joiners(added))
+		share := movedShare(g.c.sortedHashes, g.c.circle, hashes, circle)
+		g.c.RUnlock()
+
+		used := 0.0
+		for _, s := range g.spent {
+			used += s.share
+		}
+		if len(g.spent) > 0 && used+share > g.maxShare {
+			if g.timer == nil {
+				g.timer = time.AfterFunc(g.spent[0].at.Add(g.window).Sub(now), func() {
+					g.mu.Lock()
+					g.timer = nil
+					g.mu.Unlock()
+					g.step()
+				})
+			}
+			return
+		}
+		g.mu.Unlock()
+		var elt string
+		if len(added) > 0 {
+			elt = added[0].Elt
+			g.c.Add(elt, replicasOrDefault(added[0].NumberOfReplicas)...)
+		} else {
+			elt = removed[0]
+			g.c.Remove(elt)
+		}
+		g.c.RLock()
+		applied := g.c.members[elt] == (len(added) > 0)
+		g.c.RUnlock()
+		g.mu.Lock()
+		if !applied {
+			// rejected by Config.Guard or Config.MaxMembers: retry with the next call
+			refused[elt] = true
+			continue
+		}
+		g.spent = append(g.spent, governorSpend{now, share})
+		// the ring changed, so may accept what it refused
+		refused = nil
+	}
+}
+
+// replicasOrDefault returns the variadic replicas argument of Add for n, 0 meaning the
+// default.
+func replicasOrDefault(n int) []int {
+	if n == 0 {
+		return nil
+	}
+	return []int{n}
+}
@@ -0,0 +1,95 @@
+package consistent
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGovernor(t *testing.T) {
+	x := New(newConfig())
+	x.Set([]string{"a", "b", "c", "d"})
+	g := NewGovernor(x, 0.3, 50*time.Millisecond)
+	defer g.Close()
+
+	// each member added takes about a fifth of the hash space
+	g.Set([]string{"a", "b", "c", "d", "e", "f", "g"})
+	add, remove := g.Pending()
+	if len(add) == 0 || len(add) == 3 || len(remove) != 0 {
+		t.Fatalf("got pending %q, %q, expected some additions queued", add, remove)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if add, _ := g.Pending(); len(add) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("queued changes never applied")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	checkNum(len(x.Members()), 7, t)
+
+	// the first change of a window always goes through
+	g = NewGovernor(x, 0.1, time.Minute)
+	defer g.Close()
+	g.Remove("a")
+	g.Remove("b")
+	if _, remove := g.Pending(); len(remove) != 1 || remove[0] != "b" {
+		t.Errorf("got pending removals %q, expected [b]", remove)
+	}
+}
+
+func TestGovernorGuard(t *testing.T) {
+	x := New(Config{DefaultNumberOfReplicas: 20, Guard: &Guard{MinMembers: 2}})
+	x.Set([]string{"a", "b"})
+	g := NewGovernor(x, 1, time.Minute)
+	defer g.Close()
+	g.Remove("a")
+	if _, remove := g.Pending(); len(remove) != 1 {
+		t.Errorf("got pending removals %q, expected [a] held by the guard", remove)
+	}
+}
+
+func TestGovernorRefusedAdd(t *testing.T) {
+	x := New(Config{DefaultNumberOfReplicas: 20, MaxMembers: 2})
+	x.Set([]string{"a", "b"})
+	// no budget to speak of: moves leave the window at once
+	g := NewGovernor(x, 1, time.Nanosecond)
+	defer g.Close()
+	// c is refused while the ring is full, which must not hold back removing b
+	g.Set([]string{"a", "c"})
+	if m := x.Members(); len(m) != 2 || !x.IsMember("c") {
+		t.Errorf("got members %q, expected b replaced by c", m)
+	}
+	if add, remove := g.Pending(); len(add) != 0 || len(remove) != 0 {
+		t.Errorf("got pending %q, %q, expected none", add, remove)
+	}
+	g.Add("d")
+	if add, _ := g.Pending(); len(add) != 1 || add[0] != "d" {
+		t.Errorf("got pending additions %q, expected [d] refused", add)
+	}
+}
+
+func TestGovernorListener(t *testing.T) {
+	x := New(newConfig())
+	x.Set([]string{"a", "b"})
+	g := NewGovernor(x, 1, time.Minute)
+	defer g.Close()
+	x.OnChange(func(ev ChangeEvent) {
+		g.Pending()
+		g.Remove("a")
+	})
+	done := make(chan struct{})
+	go func() {
+		g.Add("c")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("a listener calling the governor deadlocked")
+	}
+	if m := x.Members(); len(m) != 2 || x.IsMember("a") {
+		t.Errorf("got members %q, expected [b c]", m)
+	}
+}
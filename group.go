@@ -0,0 +1,124 @@
+package consistent
+
+import "sort"
+
+// groupPrefix starts the key a group is routed on, so it cannot clash with the group IDs
+// used as plain keys.
+const groupPrefix = "\x00group:"
+
+// Group makes keys members of the affinity group groupID, so they all share an owner: Get,
+// GetTwo and GetN route them on the group instead of on themselves. A key belongs to at
+// most one group; grouping it again moves it.
+func (c *Consistent) Group(groupID string, keys ...string) {
+	c.Lock()
+	defer c.unlockAndPublish()
+	if c.groups == nil {
+		c.groups = make(map[string]string)
+		c.groupKeys = make(map[string][]string)
+		c.groupPins = make(map[string]string)
+	}
+	for _, k := range keys {
+		if old, ok := c.groups[k]; ok {
+			if old == groupID {
+				continue
+			}
+			c.ungroupKey(old, k)
+		}
+		c.groups[k] = groupID
+		c.groupKeys[groupID] = append(c.groupKeys[groupID], k)
+	}
+}
+
+// Ungroup dissolves the group groupID: its keys are routed on themselves again.
+func (c *Consistent) Ungroup(groupID string) {
+	c.Lock()
+	defer c.unlockAndPublish()
+	for _, k := range c.groupKeys[groupID] {
+		delete(c.groups, k)
+	}
+	delete(c.groupKeys, groupID)
+	delete(c.groupPins, groupID)
+}
+
+// GroupOf returns the group key belongs to, if any.
+func (c *Consistent) GroupOf(key string) (string, bool) {
+	c.RLock()
+	defer c.RUnlock()
+	g, ok := c.groups[key]
+	return g, ok
+}
+
+// GroupKeys returns the keys of the group groupID, sorted.
+func (c *Consistent) GroupKeys(groupID string) []string {
+	c.RLock()
+	defer c.RUnlock()
+	res := append([]string(nil), c.groupKeys[groupID]...)
+	sort.Strings(res)
+	return res
+}
+
+// GroupOwner returns the member owning the keys of the group groupID.
+func (c *Consistent) GroupOwner(groupID string) (string, error) {
+	c.RLock()
+	defer c.RUnlock()
+	if len(c.circle) == 0 {
+		return "", ErrEmptyCircle
+	}
+	if pin := c.groupPins[groupID]; c.members[pin] {
+		return pin, nil
+	}
+	return c.owner(groupPrefix + groupID)
+}
+
+// MoveGroup moves every key of the group groupID to member at once, until member leaves
+// the ring or the group is moved again. An empty member lets the ring place the group again.
+func (c *Consistent) MoveGroup(groupID, member string) error {
+	c.Lock()
+	defer c.Unlock()
+	if member == "" {
+		delete(c.groupPins, groupID)
+		return nil
+	}
+	if !c.members[member] {
+		return ErrNotMember
+	}
+	if _, ok := c.groupKeys[groupID]; !ok {
+		return nil
+	}
+	c.groupPins[groupID] = member
+	return nil
+}
+
+// need c.Lock() before calling
+func (c *Consistent) ungroupKey(groupID, key string) {
+	keys := c.groupKeys[groupID]
+	for i, k := range keys {
+		if k == key {
+			keys = append(keys[:i:i], keys[i+1:]...)
+			break
+		}
+	}
+	if len(keys) == 0 {
+		delete(c.groupKeys, groupID)
+		delete(c.groupPins, groupID)
+		return
+	}
+	c.groupKeys[groupID] = keys
+}
+
+// need c.RLock() before calling
+// grouped returns the name to route name on and the member its group was moved to, if any.
+func (c *Consistent) grouped(name string) (string, string) {
+	if len(c.groups) == 0 {
+		return name, ""
+	}
+	g, ok := c.groups[name]
+	if !ok {
+		return name, ""
+	}
+	pin := c.groupPins[g]
+	if !c.members[pin] {
+		pin = ""
+	}
+	return groupPrefix + g, pin
+}
@@ -0,0 +1,58 @@
+package consistent
+
+import "testing"
+
+func TestGroup(t *testing.T) {
+	x := New(newConfig())
+	x.Set([]string{"a", "b", "c", "d"})
+	keys := []string{"cart:1", "orders:1", "profile:1", "session:1"}
+	x.Group("user1", keys...)
+	owner, err := x.GroupOwner("user1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range keys {
+		if m, _ := x.Get(k); m != owner {
+			t.Errorf("%s: got %s, expected group owner %s", k, m, owner)
+		}
+		if g, ok := x.GroupOf(k); !ok || g != "user1" {
+			t.Errorf("%s: got group %q", k, g)
+		}
+	}
+
+	var other string
+	for _, m := range x.Members() {
+		if m != owner {
+			other = m
+		}
+	}
+	if err := x.MoveGroup("user1", "missing"); err != ErrNotMember {
+		t.Errorf("got %v, expected ErrNotMember", err)
+	}
+	if err := x.MoveGroup("user1", other); err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range keys {
+		res, _ := x.GetN(k, 3)
+		a, b, _ := x.GetTwo(k)
+		if len(res) != 3 || res[0] != other || res[1] == other || a != other || b == other || b == "" {
+			t.Errorf("%s: got %v and %s, %s after moving to %s", k, res, a, b, other)
+		}
+	}
+	x.Remove(other)
+	if m, _ := x.GroupOwner("user1"); m != owner {
+		t.Errorf("expected the group to go back to %s when %s left, got %s", owner, other, m)
+	}
+
+	x.Group("user2", "cart:1")
+	if got := x.GroupKeys("user1"); len(got) != 3 || got[0] != "orders:1" {
+		t.Errorf("unexpected keys %v", got)
+	}
+	x.Ungroup("user1")
+	if _, ok := x.GroupOf("orders:1"); ok {
+		t.Errorf("expected orders:1 to be ungrouped")
+	}
+	if len(x.GroupKeys("user1")) != 0 {
+		t.Errorf("expected user1 to be gone")
+	}
+}
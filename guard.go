@@ -0,0 +1,171 @@
+package consistent
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/jiangz222/consistent/core"
+)
+
+var (
+	// ErrTooFewMembers is reported when a change would leave the ring with fewer members
+	// than Guard.MinMembers.
+	ErrTooFewMembers = errors.New("consistent: change would leave too few members")
+	// ErrTooMuchMoved is reported when a change would move more than Guard.MaxMovedShare of
+	// the hash space to other members.
+	ErrTooMuchMoved = errors.New("consistent: change would move too much of the hash space")
+)
+
+// Guard protects a ring from bad discovery responses by checking the changes made by Set,
+// SetWithReplicas and Remove before applying them. A violating change is rejected, leaving
+// the ring untouched, unless WarnOnly is set; either way it is reported to OnViolation.
+type Guard struct {
+	// MinMembers is the number of members a change may not shrink the ring below. 0 disables
+	// the check.
+	MinMembers int
+	// MaxMovedShare is the largest share of the hash space, between 0 and 1, a change may
+	// give to other members. 0 disables the check.
+	MaxMovedShare float64
+	// WarnOnly applies violating changes anyway, only reporting them.
+	WarnOnly bool
+	// OnViolation, which may be nil, is called with an error wrapping ErrTooFewMembers or
+	// ErrTooMuchMoved. It runs with the ring locked and must not use it.
+	OnViolation func(err error)
+}
+
+// need c.Lock() before calling
+// allowSet reports whether the guard lets the membership become elts.
+func (c *Consistent) allowSet(elts []SetElt) bool {
+	removed, added := c.setDiff(elts)
+	return c.allow(removed, joiners(added))
+}
+
+// need c.RLock() before calling
+// setDiff returns the members that setting the membership to elts removes, sorted, and the
+// elements it adds.
+func (c *Consistent) setDiff(elts []SetElt) (removed []string, added []SetElt) {
+	want := make(map[string]bool, len(elts))
+	for _, v := range elts {
+		if !c.members[v.Elt] && !want[v.Elt] {
+			added = append(added, v)
+		}
+		want[v.Elt] = true
+	}
+	for k := range c.members {
+		if !want[k] {
+			removed = append(removed, k)
+		}
+	}
+	sort.Strings(removed)
+	return removed, added
+}
+
+// need c.Lock() before calling
+// allow reports whether the guard lets the members in removed go and those in added join.
+func (c *Consistent) allow(removed []string, added []joiner) bool {
+	g := c.guard
+	if len(removed) == 0 && len(added) == 0 {
+		return true
+	}
+	var err error
+	after := len(c.members) - len(removed) + len(added)
+	if g.MinMembers > 0 && after < g.MinMembers && after < len(c.members) {
+		err = fmt.Errorf("%w: %d left, minimum %d", ErrTooFewMembers, after, g.MinMembers)
+	} else if g.MaxMovedShare > 0 {
+		circle, hashes := c.simulate(removed, added)
+		if moved := movedShare(c.sortedHashes, c.circle, hashes, circle); moved > g.MaxMovedShare {
+			err = fmt.Errorf("%w: %.1f%%, maximum %.1f%%", ErrTooMuchMoved, moved*100, g.MaxMovedShare*100)
+		}
+	}
+	if err == nil {
+		return true
+	}
+	if g.OnViolation != nil {
+		g.OnViolation(err)
+	}
+	return g.WarnOnly
+}
+
+// joiner is a member about to be added, with the salt and the hasher, nil for that of the
+// ring, its vnodes will be placed with.
+type joiner struct {
+	SetElt
+	salt   string
+	hasher Hasher
+}
+
+func joiners(elts []SetElt) []joiner {
+	res := make([]joiner, len(elts))
+	for i, v := range elts {
+		res[i].SetElt = v
+	}
+	return res
+}
+
+// need c.RLock() before calling
+// simulate returns the circle and sorted hashes the ring would have after removing and
+// adding members, following the rules of remove and add: points claimed by several
+// members go to the winner under Config.TieBreak, quarantined members are left out of the
+// sorted hashes and Config.MaxShare is applied.
+func (c *Consistent) simulate(removed []string, added []joiner) (map[uint32]string, uints) {
+	circle := make(map[uint32]string, len(c.circle))
+	for k, v := range c.circle {
+		circle[k] = v
+	}
+	contested := make(map[uint32][]string, len(c.contested))
+	for k, v := range c.contested {
+		contested[k] = v
+	}
+	joining := make(map[string]core.Claimant, len(added))
+	claimant := func(elt string) core.Claimant {
+		if m, ok := joining[elt]; ok {
+			return m
+		}
+		return c.claimant(elt)
+	}
+	for _, elt := range removed {
+		for i := 0; i < c.membersReplicas[elt]; i++ {
+			c.tieBreak.Unclaim(circle, contested, c.vnodeHash(elt, i), elt, claimant)
+		}
+	}
+	for i, v := range added {
+		n := v.NumberOfReplicas
+		if n == 0 {
+			n = c.defaultNumberOfReplicas
+		}
+		joining[v.Elt] = core.Claimant{Name: v.Elt, Replicas: n, Joined: c.joinSeq + uint64(i) + 1}
+		for j := 0; j < n; j++ {
+			c.tieBreak.Claim(circle, contested, c.placeVnode(v.Elt, j, v.salt, v.hasher), v.Elt, claimant)
+		}
+	}
+	hashes := make(uints, 0, len(circle))
+	for k, elt := range circle {
+		if !c.isQuarantined(elt) {
+			hashes = append(hashes, k)
+		}
+	}
+	if len(hashes) == 0 {
+		for k := range circle {
+			hashes = append(hashes, k)
+		}
+	}
+	sort.Sort(hashes)
+	return circle, c.capShares(circle, hashes)
+}
+
+// movedShare returns the share of the hash space whose owner differs between two circles.
+// Nothing moves out of an empty circle, and everything moves into one.
+func movedShare(oldHashes uints, oldCircle map[uint32]string, newHashes uints, newCircle map[uint32]string) float64 {
+	if len(oldHashes) == 0 {
+		return 0
+	}
+	if len(newHashes) == 0 {
+		return 1
+	}
+	var moved uint64
+	for _, r := range movedRanges(oldHashes, owners(oldHashes, oldCircle), newHashes, owners(newHashes, newCircle)) {
+		moved += r.size()
+	}
+	return float64(moved) / (1 << 32)
+}
@@ -0,0 +1,76 @@
+package consistent
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestGuardMinMembers(t *testing.T) {
+	var violations []error
+	x := New(Config{DefaultNumberOfReplicas: 20, Guard: &Guard{
+		MinMembers:  2,
+		OnViolation: func(err error) { violations = append(violations, err) },
+	}})
+	x.Set([]string{"a"})
+	x.Set([]string{"a", "b", "c"})
+	if x.Remove("c") != true {
+		t.Errorf("expected removing c to be allowed")
+	}
+	if x.Remove("b") != false {
+		t.Errorf("expected removing b to be rejected")
+	}
+	x.Set(nil)
+	checkNum(len(x.Members()), 2, t)
+	if len(violations) != 2 || !errors.Is(violations[0], ErrTooFewMembers) {
+		t.Errorf("unexpected violations %v", violations)
+	}
+
+	x.guard.WarnOnly = true
+	x.Set(nil)
+	checkNum(len(x.Members()), 0, t)
+	checkNum(len(violations), 3, t)
+}
+
+func TestGuardMaxMovedShare(t *testing.T) {
+	var violations []error
+	x := New(Config{DefaultNumberOfReplicas: 100, Guard: &Guard{
+		MaxMovedShare: 0.35,
+		OnViolation:   func(err error) { violations = append(violations, err) },
+	}})
+	x.Set([]string{"a", "b", "c", "d"})
+	x.SetWithReplicas([]SetElt{{"a", 0}, {"b", 0}})
+	checkNum(len(x.Members()), 4, t)
+	if len(violations) != 1 || !errors.Is(violations[0], ErrTooMuchMoved) {
+		t.Errorf("unexpected violations %v", violations)
+	}
+	x.Set([]string{"a", "b", "c"})
+	checkNum(len(x.Members()), 3, t)
+}
+
+func TestMovedShare(t *testing.T) {
+	x := New(newConfig())
+	x.Set([]string{"a", "b", "c", "d"})
+	before := make(map[string]string)
+	for i := 0; i < 20000; i++ {
+		k := "user" + strconv.Itoa(i)
+		before[k], _ = x.Get(k)
+	}
+	circle, hashes := x.simulate([]string{"d"}, joiners([]SetElt{{"e", 40}}))
+	share := movedShare(x.sortedHashes, x.circle, hashes, circle)
+	x.Remove("d")
+	x.Add("e", 40)
+	moved := 0
+	for k, m := range before {
+		if now, _ := x.Get(k); now != m {
+			moved++
+		}
+	}
+	if sampled := float64(moved) / float64(len(before)); math.Abs(share-sampled) > 0.02 {
+		t.Errorf("got moved share %.3f, sampled %.3f", share, sampled)
+	}
+	if movedShare(nil, nil, x.sortedHashes, x.circle) != 0 || movedShare(x.sortedHashes, x.circle, nil, nil) != 1 {
+		t.Errorf("unexpected moved share for empty circles")
+	}
+}
@@ -0,0 +1,174 @@
+package consistent
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+// ErrFingerprintMismatch is returned by ApplyHandshake when the ring does not end up with
+// the fingerprint of the server, e.g. because it hashes differently.
+var ErrFingerprintMismatch = errors.New("consistent: ring fingerprint differs from the server's")
+
+// Delta is a change of membership: the members to remove, then the members to add. A
+// member whose replicas or salt change is removed and added back. From and To are the
+// versions of the ring it goes between, when made by EncodeDelta.
+type Delta struct {
+	From    uint64           `json:"from,omitempty"`
+	To      uint64           `json:"to,omitempty"`
+	Removed []string         `json:"removed,omitempty"`
+	Added   []SnapshotMember `json:"added,omitempty"`
+}
+
+// SnapshotDelta returns the delta turning the membership from into to.
+func SnapshotDelta(from, to Snapshot) Delta {
+	old := make(map[string]SnapshotMember, len(from.Members))
+	for _, m := range from.Members {
+		old[m.Name] = m
+	}
+	var d Delta
+	kept := make(map[string]bool, len(to.Members))
+	for _, m := range to.Members {
+		if o, ok := old[m.Name]; ok && o == m {
+			kept[m.Name] = true
+			continue
+		}
+		d.Added = append(d.Added, m)
+	}
+	for _, m := range from.Members {
+		if !kept[m.Name] {
+			d.Removed = append(d.Removed, m.Name)
+		}
+	}
+	return d
+}
+
+// apply returns the membership of s changed by d.
+func (s Snapshot) apply(d Delta) Snapshot {
+	members := make(map[string]SnapshotMember, len(s.Members)+len(d.Added))
+	for _, m := range s.Members {
+		members[m.Name] = m
+	}
+	for _, name := range d.Removed {
+		delete(members, name)
+	}
+	for _, m := range d.Added {
+		members[m.Name] = m
+	}
+	res := Snapshot{Members: make([]SnapshotMember, 0, len(members))}
+	for _, m := range members {
+		res.Members = append(res.Members, m)
+	}
+	sort.Slice(res.Members, func(i, j int) bool { return res.Members[i].Name < res.Members[j].Name })
+	return res
+}
+
+// ApplyDelta changes the membership of the ring by d, like Restore with the membership
+// changed by d. It does nothing if the change is rejected by Config.Guard.
+func (c *Consistent) ApplyDelta(d Delta) {
+	c.warmup(func() ([]string, []joiner) {
+		return c.restorePlan(c.snapshot().apply(d))
+	})
+	c.Lock()
+	defer c.unlockAndNotify()
+	c.restore(c.snapshot().apply(d))
+}
+
+// HandshakeReply is the answer of a Handshake to a client's ring fingerprint. A stale
+// client gets a Delta from its membership if the server still knows it, else the whole
+// Snapshot.
+type HandshakeReply struct {
+	Stale       bool      `json:"stale"`
+	Fingerprint uint64    `json:"fingerprint"`
+	Delta       *Delta    `json:"delta,omitempty"`
+	Snapshot    *Snapshot `json:"snapshot,omitempty"`
+}
+
+// Handshake lets a server check the ring fingerprint that clients routing keys themselves
+// send with their requests, and tell stale clients how to catch up. It remembers the
+// membership of the last versions of the ring to answer with deltas.
+type Handshake struct {
+	ring   *Consistent
+	keep   int
+	cancel func()
+
+	mu      sync.Mutex
+	current uint64
+	known   map[uint64]Snapshot
+	order   []uint64 // fingerprints in known, oldest first
+}
+
+// NewHandshake creates a Handshake for c remembering its last keep memberships, at least 1.
+func NewHandshake(c *Consistent, keep int) *Handshake {
+	if keep < 1 {
+		keep = 1
+	}
+	h := &Handshake{ring: c, keep: keep, known: make(map[uint64]Snapshot)}
+	h.cancel = c.OnChange(func(ChangeEvent) { h.record() })
+	h.record()
+	return h
+}
+
+// Close stops following the ring.
+func (h *Handshake) Close() {
+	h.cancel()
+}
+
+// Check returns the reply to a client whose ring has the given fingerprint.
+func (h *Handshake) Check(fingerprint uint64) HandshakeReply {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if fingerprint == h.current {
+		return HandshakeReply{Fingerprint: h.current}
+	}
+	r := HandshakeReply{Stale: true, Fingerprint: h.current}
+	if old, ok := h.known[fingerprint]; ok {
+		d := SnapshotDelta(old, h.known[h.current])
+		r.Delta = &d
+	} else {
+		s := h.known[h.current]
+		r.Snapshot = &s
+	}
+	return r
+}
+
+// record remembers the current membership of the ring. It reads the ring with h.mu held,
+// so the last of concurrent records reads the latest membership.
+func (h *Handshake) record() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.ring.RLock()
+	s, fp := h.ring.snapshot(), h.ring.fingerprint()
+	h.ring.RUnlock()
+	h.current = fp
+	for i, v := range h.order {
+		if v == fp {
+			h.order = append(h.order[:i:i], h.order[i+1:]...)
+			break
+		}
+	}
+	h.known[fp] = s
+	h.order = append(h.order, fp)
+	if len(h.order) > h.keep {
+		delete(h.known, h.order[0])
+		h.order = h.order[1:]
+	}
+}
+
+// ApplyHandshake brings the ring of a client up to date with a reply of the server's
+// Handshake, and checks it ends up with the server's fingerprint.
+func (c *Consistent) ApplyHandshake(r HandshakeReply) error {
+	if !r.Stale {
+		return nil
+	}
+	switch {
+	case r.Delta != nil:
+		c.ApplyDelta(*r.Delta)
+	case r.Snapshot != nil:
+		c.Restore(*r.Snapshot)
+	}
+	if c.Fingerprint() != r.Fingerprint {
+		return ErrFingerprintMismatch
+	}
+	return nil
+}
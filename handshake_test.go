@@ -0,0 +1,83 @@
+package consistent
+
+import (
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestSnapshotDelta(t *testing.T) {
+	from := Snapshot{Members: []SnapshotMember{{"a", 20, ""}, {"b", 20, ""}, {"c", 20, ""}}}
+	to := Snapshot{Members: []SnapshotMember{{"a", 20, ""}, {"b", 30, ""}, {"d", 20, "v2"}}}
+	d := SnapshotDelta(from, to)
+	want := Delta{Removed: []string{"b", "c"}, Added: []SnapshotMember{{"b", 30, ""}, {"d", 20, "v2"}}}
+	if !reflect.DeepEqual(d, want) {
+		t.Errorf("got %+v, expected %+v", d, want)
+	}
+	if got := from.apply(d); !reflect.DeepEqual(got, to) {
+		t.Errorf("got %+v, expected %+v", got, to)
+	}
+}
+
+func TestHandshake(t *testing.T) {
+	server := New(newConfig())
+	server.Set([]string{"a", "b", "c"})
+	h := NewHandshake(server, 4)
+	defer h.Close()
+
+	client := New(newConfig())
+	client.Restore(server.Snapshot())
+	if r := h.Check(client.Fingerprint()); r.Stale {
+		t.Errorf("expected an up to date client, got %+v", r)
+	}
+
+	server.Add("d")
+	server.Remove("a")
+	r := h.Check(client.Fingerprint())
+	if !r.Stale || r.Delta == nil || r.Snapshot != nil {
+		t.Fatalf("expected a delta, got %+v", r)
+	}
+	if !reflect.DeepEqual(*r.Delta, Delta{Removed: []string{"a"}, Added: []SnapshotMember{{"d", 20, ""}}}) {
+		t.Errorf("unexpected delta %+v", *r.Delta)
+	}
+	if err := client.ApplyHandshake(r); err != nil {
+		t.Fatal(err)
+	}
+	if client.Fingerprint() != server.Fingerprint() {
+		t.Errorf("expected the client to catch up")
+	}
+
+	stranger := New(newConfig())
+	stranger.Add("zzz")
+	r = h.Check(stranger.Fingerprint())
+	if r.Snapshot == nil {
+		t.Fatalf("expected a snapshot for an unknown fingerprint, got %+v", r)
+	}
+	if err := stranger.ApplyHandshake(r); err != nil {
+		t.Fatal(err)
+	}
+
+	fnvClient := New(Config{DefaultNumberOfReplicas: 20, UseFnv: true})
+	if err := fnvClient.ApplyHandshake(h.Check(0)); err != ErrFingerprintMismatch {
+		t.Errorf("got %v, expected ErrFingerprintMismatch", err)
+	}
+}
+
+func TestHandshakeConcurrentChanges(t *testing.T) {
+	server := New(newConfig())
+	h := NewHandshake(server, 4)
+	defer h.Close()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			server.Add("m" + strconv.Itoa(i))
+		}(i)
+	}
+	wg.Wait()
+	if r := h.Check(server.Fingerprint()); r.Stale {
+		t.Errorf("expected the latest fingerprint to be current, got %+v", r)
+	}
+}
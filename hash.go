@@ -0,0 +1,58 @@
+package consistent
+
+import "github.com/jiangz222/consistent/core"
+
+// HashAlgorithm selects one of the builtin hash functions with Config.Hash.
+type HashAlgorithm int
+
+const (
+	// HashCRC32 is CRC-32 IEEE, the default.
+	HashCRC32 HashAlgorithm = iota
+	// HashFnv is 32-bit FNV-1a, the same as Config.UseFnv.
+	HashFnv
+	// HashXXH32 is xxHash32 with seed 0.
+	HashXXH32
+	// HashXXH64 is the low 32 bits of xxHash64 with seed 0.
+	HashXXH64
+	// HashMurmur3 is MurmurHash3 x86 32-bit with seed 0.
+	HashMurmur3
+)
+
+var hashAlgorithmNames = [...]string{"crc32", "fnv", "xxh32", "xxh64", "murmur3"}
+
+func (a HashAlgorithm) String() string {
+	if a < 0 || int(a) >= len(hashAlgorithmNames) {
+		return "unknown"
+	}
+	return hashAlgorithmNames[a]
+}
+
+// hash hashes key with a, CRC-32 for unknown algorithms.
+func (a HashAlgorithm) hash(key string) uint32 {
+	switch a {
+	case HashFnv:
+		return hashKeyFnv(key)
+	case HashXXH32:
+		return core.HashXXH32(key)
+	case HashXXH64:
+		return core.HashXXH64Low(key)
+	case HashMurmur3:
+		return core.HashMurmur3(key)
+	}
+	return hashKeyCRC32(key)
+}
+
+// hashBytes is hash of key, without converting it to a string.
+func (a HashAlgorithm) hashBytes(key []byte) uint32 {
+	switch a {
+	case HashFnv:
+		return core.HashFnvBytes(key)
+	case HashXXH32:
+		return core.HashXXH32Bytes(key)
+	case HashXXH64:
+		return uint32(core.HashXXH64Bytes(key))
+	case HashMurmur3:
+		return core.HashMurmur3Bytes(key)
+	}
+	return core.HashCRC32Bytes(key)
+}
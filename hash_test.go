@@ -0,0 +1,64 @@
+package consistent
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jiangz222/consistent/core"
+)
+
+func TestHashAlgorithm(t *testing.T) {
+	for _, c := range []struct {
+		alg  HashAlgorithm
+		hash func(string) uint32
+	}{
+		{HashCRC32, core.HashCRC32},
+		{HashFnv, core.HashFnv},
+		{HashXXH32, core.HashXXH32},
+		{HashXXH64, core.HashXXH64Low},
+		{HashMurmur3, core.HashMurmur3},
+	} {
+		x := New(Config{DefaultNumberOfReplicas: 20, Hash: c.alg})
+		x.Add("abcdefg")
+		x.Add("hijklmn")
+		for _, m := range x.Members() {
+			for i := 0; i < 20; i++ {
+				if x.circle[c.hash(x.eltKey(m, i))] != m {
+					t.Errorf("%s: vnode %d of %s is not at its hash", c.alg, i, m)
+				}
+			}
+		}
+		if got := x.hashKey("key"); got != c.hash("key") {
+			t.Errorf("%s: key hashed to %#x, expected %#x", c.alg, got, c.hash("key"))
+		}
+	}
+}
+
+func TestHashAlgorithmEncoding(t *testing.T) {
+	x := New(Config{DefaultNumberOfReplicas: 20, Hash: HashMurmur3})
+	x.Add("abcdefg")
+	data, err := x.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	y := New(Config{DefaultNumberOfReplicas: 20, Hash: HashMurmur3})
+	if err := y.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	checkNum(len(y.Members()), 1, t)
+	z := New(Config{DefaultNumberOfReplicas: 20, Hash: HashXXH32})
+	if err := z.UnmarshalBinary(data); !errors.Is(err, ErrConfigMismatch) {
+		t.Errorf("got %v, expected ErrConfigMismatch", err)
+	}
+}
+
+func TestHashAlgorithmStrict(t *testing.T) {
+	conf := Config{Strict: true, DefaultNumberOfReplicas: 20, Hash: HashXXH64, UseFnv: true}
+	if err := conf.Validate(); !errors.Is(err, ErrNotStrict) {
+		t.Errorf("got %v, expected ErrNotStrict", err)
+	}
+	conf.UseFnv = false
+	if err := conf.Validate(); err != nil {
+		t.Error(err)
+	}
+}
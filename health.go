@@ -0,0 +1,113 @@
+package consistent
+
+import (
+	"errors"
+	"sort"
+)
+
+// ErrDegraded is returned by lookups while the ring is degraded with the DegradedFailFast
+// policy, see Config.DegradedShare.
+var ErrDegraded = errors.New("consistent: too many members down")
+
+// DegradedPolicy is how lookups route keys while the ring is degraded.
+type DegradedPolicy int
+
+const (
+	// DegradedAnyHealthy spreads the keys evenly over the members that are up, at the cost
+	// of moving most of them.
+	DegradedAnyHealthy DegradedPolicy = iota
+	// DegradedFailFast fails lookups with ErrDegraded.
+	DegradedFailFast
+)
+
+// MarkDown marks elt down, typically from a health check: it stays a member, but lookups
+// skip it, falling back to the next members, until MarkUp. If elt is not a member it does
+// nothing.
+func (c *Consistent) MarkDown(elt string) {
+	c.Lock()
+	defer c.unlockAndNotify()
+	if !c.members[elt] || c.down[elt] {
+		return
+	}
+	if c.down == nil {
+		c.down = make(map[string]bool)
+	}
+	c.down[elt] = true
+	c.changes.Down = append(c.changes.Down, elt)
+}
+
+// MarkUp undoes MarkDown.
+func (c *Consistent) MarkUp(elt string) {
+	c.Lock()
+	defer c.unlockAndNotify()
+	if !c.down[elt] {
+		return
+	}
+	delete(c.down, elt)
+	c.changes.Up = append(c.changes.Up, elt)
+}
+
+// SetHealth marks elt down if healthy is false and up otherwise, for health checkers
+// reporting a state rather than transitions. Only the keys of elt move while it is down,
+// and they return to it when it is up again.
+func (c *Consistent) SetHealth(elt string, healthy bool) {
+	if healthy {
+		c.MarkUp(elt)
+	} else {
+		c.MarkDown(elt)
+	}
+}
+
+// Healthy reports whether elt is a member not marked down.
+func (c *Consistent) Healthy(elt string) bool {
+	c.RLock()
+	defer c.RUnlock()
+	return c.members[elt] && !c.down[elt]
+}
+
+// Down returns the members marked down, sorted.
+func (c *Consistent) Down() []string {
+	c.RLock()
+	defer c.RUnlock()
+	return sortedSet(c.down)
+}
+
+// Degraded reports whether more than Config.DegradedShare of the members are marked down.
+func (c *Consistent) Degraded() bool {
+	c.RLock()
+	defer c.RUnlock()
+	return c.degraded()
+}
+
+// need c.RLock() before calling
+func (c *Consistent) degraded() bool {
+	return c.degradedShare > 0 && len(c.members) > 0 && float64(len(c.down))/float64(len(c.members)) > c.degradedShare
+}
+
+// need c.RLock() before calling
+// getDegraded returns n members that are up for the key hash key, skipping those for
+// which skip returns true, or ErrDegraded.
+func (c *Consistent) getDegraded(key uint32, n int, skip func(string) bool) ([]string, error) {
+	if c.degradedPolicy == DegradedFailFast {
+		return nil, ErrDegraded
+	}
+	up := make([]string, 0, len(c.members)-len(c.down))
+	for m := range c.members {
+		if !c.down[m] && (skip == nil || !skip(m)) {
+			up = append(up, m)
+		}
+	}
+	if len(up) == 0 {
+		return nil, ErrDegraded
+	}
+	sort.Strings(up)
+	if n > len(up) {
+		n = len(up)
+	}
+	start := int(uint64(key) * uint64(len(up)) >> 32)
+	res := make([]string, n)
+	for i := range res {
+		res[i] = up[(start+i)%len(up)]
+	}
+	return res, nil
+}
@@ -0,0 +1,189 @@
+package consistent
+
+import (
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+func TestMarkDown(t *testing.T) {
+	x := New(newConfig())
+	x.Set([]string{"a", "b", "c", "d"})
+	before := make(map[string]string)
+	for i := 0; i < 1000; i++ {
+		k := "key" + strconv.Itoa(i)
+		before[k] = mustGet(t, x, k)
+	}
+	x.MarkDown("b")
+	if down := x.Down(); len(down) != 1 || down[0] != "b" {
+		t.Errorf("got down %q", down)
+	}
+	for k, old := range before {
+		m := mustGet(t, x, k)
+		if m == "b" || (old != "b" && m != old) {
+			t.Errorf("%s: got %s, was %s", k, m, old)
+		}
+		if b, _ := x.GetBytes([]byte(k)); b != m {
+			t.Errorf("%s: GetBytes gives %s, Get %s", k, b, m)
+		}
+		if h, _ := x.GetByHash(x.HashKey(k)); h != m {
+			t.Errorf("%s: GetByHash gives %s, Get %s", k, h, m)
+		}
+		if batch, _ := x.GetBatch([]string{k}); batch[0] != m {
+			t.Errorf("%s: GetBatch gives %s, Get %s", k, batch[0], m)
+		}
+		res, _ := x.GetN(k, 4)
+		if len(res) != 3 || res[0] != m {
+			t.Errorf("%s: GetN gives %q", k, res)
+		}
+	}
+	x.MarkUp("b")
+	for k, old := range before {
+		if m := mustGet(t, x, k); m != old {
+			t.Errorf("%s: got %s after MarkUp, was %s", k, m, old)
+		}
+	}
+}
+
+func TestSetHealth(t *testing.T) {
+	x := New(newConfig())
+	x.Set([]string{"a", "b", "c"})
+	x.SetHealth("a", false)
+	if x.Healthy("a") || !x.Healthy("b") || x.Healthy("z") {
+		t.Error("unexpected health")
+	}
+	for i := 0; i < 100; i++ {
+		if m := mustGet(t, x, "key"+strconv.Itoa(i)); m == "a" {
+			t.Fatal("got an unhealthy member")
+		}
+	}
+	x.SetHealth("a", true)
+	if !x.Healthy("a") || len(x.Down()) != 0 {
+		t.Errorf("a still down: %v", x.Down())
+	}
+}
+
+func TestDegraded(t *testing.T) {
+	x := New(Config{DefaultNumberOfReplicas: 20, DegradedShare: 0.5})
+	x.Set([]string{"a", "b", "c", "d"})
+	x.MarkDown("a")
+	x.MarkDown("b")
+	if x.Degraded() {
+		t.Errorf("degraded with half the members down")
+	}
+	x.MarkDown("c")
+	if !x.Degraded() {
+		t.Errorf("not degraded with 3 of 4 members down")
+	}
+	for i := 0; i < 100; i++ {
+		if m := mustGet(t, x, "key"+strconv.Itoa(i)); m != "d" {
+			t.Errorf("got %s, expected the member still up", m)
+		}
+	}
+
+	y := New(Config{DefaultNumberOfReplicas: 20, DegradedShare: 0.5, DegradedPolicy: DegradedFailFast})
+	y.Set([]string{"a", "b"})
+	y.MarkDown("a")
+	y.MarkDown("b")
+	if _, err := y.Get("key"); err != ErrDegraded {
+		t.Errorf("got %v, expected ErrDegraded", err)
+	}
+	if _, err := y.GetN("key", 2); err != ErrDegraded {
+		t.Errorf("got %v, expected ErrDegraded", err)
+	}
+	y.Remove("b")
+	y.MarkUp("a")
+	if _, err := y.Get("key"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMarkDownLookups(t *testing.T) {
+	x := New(newConfig())
+	x.Set([]string{"a", "b", "c", "d"})
+	router := NewTopicRouter(x, RebalanceListener{})
+	defer router.Close()
+	partitions := Partitions("t", 50)
+	router.AddPartitions(partitions...)
+	sem := NewSemaphore(x, "b")
+	defer sem.Close()
+	var keys []string
+	for i := 0; i < 200; i++ {
+		keys = append(keys, "key"+strconv.Itoa(i))
+	}
+	var owned []string
+	for _, k := range keys {
+		if mustGet(t, x, k) == "b" {
+			owned = append(owned, k)
+		}
+	}
+	if len(owned) == 0 {
+		t.Fatal("expected b to own keys")
+	}
+	lease, err := sem.TryAcquire(owned[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.Track(keys...)
+	var evicted []string
+	cancel := EvictUnowned(x, "b", 1, func() []string { return owned }, func(k string) { evicted = append(evicted, k) })
+	defer cancel()
+
+	x.MarkDown("b")
+	checkNum(len(evicted), len(owned), t)
+	select {
+	case <-lease.Lost():
+	default:
+		t.Error("expected the lease of b to be lost")
+	}
+	if _, err := sem.TryAcquire(owned[0]); err != ErrNotOwner {
+		t.Errorf("expected ErrNotOwner, got %v", err)
+	}
+	if k := x.KeysOwnedBy("b"); len(k) != 0 {
+		t.Errorf("b still owns %v", k)
+	}
+	if a := router.Assignment("b"); len(a) != 0 {
+		t.Errorf("b still assigned %v", a)
+	}
+	if m := x.VerifyOwnership(map[string]string{owned[0]: "b"}); len(m) != 1 {
+		t.Errorf("expected a mismatch, got %v", m)
+	}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		if m, _ := x.RandomOwnerWeighted(rng); m == "b" {
+			t.Fatal("RandomOwnerWeighted returned b")
+		}
+	}
+	for _, k := range keys {
+		want, _ := x.GetN(k, 3)
+		check := func(name string, res []string) {
+			t.Helper()
+			if sliceContainsMember(res, "b") {
+				t.Errorf("%s of %s returned b: %v", name, k, res)
+			}
+		}
+		check("GetN", want)
+		res, _ := x.GetNFiltered(k, 3, func(string) bool { return false })
+		check("GetNFiltered", res)
+		res, _ = x.GetNDistinctZones(k, 3)
+		check("GetNDistinctZones", res)
+		res, _ = x.GetNDraining(k, 3)
+		check("GetNDraining", res)
+		res, _ = x.GetNBounded(k, 3, 0)
+		check("GetNBounded", res)
+		m, _ := x.GetLeast(k)
+		check("GetLeast", []string{m})
+		a, b, _ := x.GetTwo(k)
+		if a != want[0] || b != want[1] {
+			t.Errorf("GetTwo of %s gives %s, %s, GetN %v", k, a, b, want)
+		}
+	}
+
+	x.MarkUp("b")
+	if _, err := sem.TryAcquire(owned[0]); err != nil {
+		t.Errorf("expected b to own %s again, got %v", owned[0], err)
+	}
+	if a := router.Assignment("b"); len(a) == 0 {
+		t.Error("expected b to be assigned partitions again")
+	}
+}
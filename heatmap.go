@@ -0,0 +1,135 @@
+package consistent
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// KeyIterator calls yield with every key until it returns false, the shape of a
+// range-over-func iterator.
+type KeyIterator func(yield func(key string) bool)
+
+// HeatmapBucket is one bucket of a Heatmap: the hashes from Start to End included.
+type HeatmapBucket struct {
+	Start uint32 `json:"start"`
+	End   uint32 `json:"end"`
+	Keys  int    `json:"keys"`
+	// Density is Keys over the number of keys the bucket would get if the sample hashed
+	// uniformly: 1 is average, 2 twice as many keys.
+	Density float64 `json:"density"`
+	// Owners are the members owning part of the bucket, in the order of the circle.
+	Owners []string `json:"owners"`
+}
+
+// Boundary is a point of the circle where ownership changes: Member owns the hashes from
+// the previous boundary included up to Hash excluded.
+type Boundary struct {
+	Hash   uint32 `json:"hash"`
+	Member string `json:"member"`
+}
+
+// Heatmap is the density of a sample of keys over equal buckets of the hash space, with
+// the ownership boundaries of the circle, showing whether real keys cluster in particular
+// regions of the ring.
+type Heatmap struct {
+	Keys       int             `json:"keys"`
+	Buckets    []HeatmapBucket `json:"buckets"`
+	Boundaries []Boundary      `json:"boundaries"`
+}
+
+// Heatmap hashes the keys produced by sample into buckets equal buckets of the hash space,
+// bucket i holding the hashes h with h * buckets >> 32 == i, like a RoutingTable. Only the
+// circle is considered: groups, overrides and weighted mode are ignored. It returns an
+// empty heatmap if buckets < 1.
+func (c *Consistent) Heatmap(buckets int, sample KeyIterator) Heatmap {
+	c.RLock()
+	defer c.RUnlock()
+	var hm Heatmap
+	if buckets < 1 {
+		return hm
+	}
+	hm.Buckets = make([]HeatmapBucket, buckets)
+	for i := range hm.Buckets {
+		b := &hm.Buckets[i]
+		b.Start = uint32((uint64(i)<<32 + uint64(buckets) - 1) / uint64(buckets))
+		b.End = uint32((uint64(i+1)<<32+uint64(buckets)-1)/uint64(buckets) - 1)
+		b.Owners = c.ownersBetween(b.Start, b.End)
+	}
+	sample(func(key string) bool {
+		hm.Buckets[uint64(c.hashKey(key))*uint64(buckets)>>32].Keys++
+		hm.Keys++
+		return true
+	})
+	if hm.Keys > 0 {
+		avg := float64(hm.Keys) / float64(buckets)
+		for i := range hm.Buckets {
+			hm.Buckets[i].Density = float64(hm.Buckets[i].Keys) / avg
+		}
+	}
+	for i, h := range c.sortedHashes {
+		m := c.circle[h]
+		if i+1 < len(c.sortedHashes) && c.circle[c.sortedHashes[i+1]] == m {
+			continue
+		}
+		hm.Boundaries = append(hm.Boundaries, Boundary{Hash: h, Member: m})
+	}
+	return hm
+}
+
+// need c.RLock() before calling
+// ownersBetween returns the members owning the hashes from start to end included.
+func (c *Consistent) ownersBetween(start, end uint32) []string {
+	n := len(c.sortedHashes)
+	if n == 0 {
+		return nil
+	}
+	var res []string
+	add := func(m string) {
+		for _, v := range res {
+			if v == m {
+				return
+			}
+		}
+		res = append(res, m)
+	}
+	i := searchHashes(c.sortedHashes, &c.index, start)
+	if c.sortedHashes[i] <= start {
+		// past the last vnode, the whole bucket wraps around to the first one
+		return []string{c.circle[c.sortedHashes[i]]}
+	}
+	for ; i < n; i++ {
+		add(c.circle[c.sortedHashes[i]])
+		if c.sortedHashes[i] > end {
+			return res
+		}
+	}
+	// the end of the bucket wraps around to the first vnode
+	add(c.circle[c.sortedHashes[0]])
+	return res
+}
+
+// WriteJSON writes hm to w as JSON.
+func (hm Heatmap) WriteJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(hm)
+}
+
+// WriteCSV writes the buckets of hm to w as CSV, one row per bucket under the header
+// start,end,keys,density,owners, the owners separated by spaces.
+func (hm Heatmap) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"start", "end", "keys", "density", "owners"})
+	for _, b := range hm.Buckets {
+		cw.Write([]string{
+			strconv.FormatUint(uint64(b.Start), 10),
+			strconv.FormatUint(uint64(b.End), 10),
+			strconv.Itoa(b.Keys),
+			strconv.FormatFloat(b.Density, 'f', 4, 64),
+			strings.Join(b.Owners, " "),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,72 @@
+package consistent
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestHeatmap(t *testing.T) {
+	x := New(newConfig())
+	x.Add("abcdefg")
+	x.Add("hijklmn")
+	x.Add("opqrstu")
+	keys := func(yield func(string) bool) {
+		for i := 0; i < 1000; i++ {
+			if !yield("key" + strconv.Itoa(i)) {
+				return
+			}
+		}
+	}
+	hm := x.Heatmap(16, keys)
+	checkNum(hm.Keys, 1000, t)
+	checkNum(len(hm.Buckets), 16, t)
+	total := 0
+	for i, b := range hm.Buckets {
+		total += b.Keys
+		if i > 0 && b.Start != hm.Buckets[i-1].End+1 {
+			t.Errorf("bucket %d starts at %d, after %d", i, b.Start, hm.Buckets[i-1].End)
+		}
+	}
+	checkNum(total, 1000, t)
+	if hm.Buckets[0].Start != 0 || hm.Buckets[15].End != 1<<32-1 {
+		t.Errorf("buckets cover %d to %d", hm.Buckets[0].Start, hm.Buckets[15].End)
+	}
+	keys(func(k string) bool {
+		b := hm.Buckets[uint64(x.hashKey(k))*16>>32]
+		owner := mustGet(t, x, k)
+		for _, m := range b.Owners {
+			if m == owner {
+				return true
+			}
+		}
+		t.Errorf("%s: owner %s not in %q", k, owner, b.Owners)
+		return true
+	})
+	for i := 1; i < len(hm.Boundaries); i++ {
+		if hm.Boundaries[i].Member == hm.Boundaries[i-1].Member {
+			t.Errorf("boundary %d does not change owner", i)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := hm.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	checkNum(len(lines), 17, t)
+	if lines[0] != "start,end,keys,density,owners" {
+		t.Errorf("got header %q", lines[0])
+	}
+	buf.Reset()
+	if err := hm.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var back Heatmap
+	if err := json.Unmarshal(buf.Bytes(), &back); err != nil {
+		t.Fatal(err)
+	}
+	checkNum(back.Keys, 1000, t)
+}
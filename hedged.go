@@ -0,0 +1,86 @@
+package consistent
+
+import (
+	"context"
+	"time"
+)
+
+// DoHedged calls fn with the primary owner of key and, every delay without a success, with
+// the next owner, up to Config.HedgeOwners owners, as Hedged.Do. The owners are tried in
+// the order of Config.ReadPolicy, those it leaves out, such as all but the primary with
+// PrimaryOnly, coming after in ring order.
+func (c *Consistent) DoHedged(ctx context.Context, key string, fn func(ctx context.Context, member string) error, delay time.Duration) (string, error) {
+	owners, err := c.GetN(key, c.hedgeOwners)
+	if err != nil {
+		return "", err
+	}
+	ordered := c.readPolicy.Order(key, append([]string(nil), owners...))
+	for _, m := range owners {
+		if !sliceContainsMember(ordered, m) {
+			ordered = append(ordered, m)
+		}
+	}
+	return Hedged{Delay: delay}.Do(ctx, ordered, fn)
+}
+
+// Do calls fn with the first of owners and, every Delay without a success, with the next
+// one. A failed attempt starts the next one right away. It returns the owner of the first
+// successful call, after cancelling the context of the others, or the last error if every
+// attempt failed.
+func (p Hedged) Do(ctx context.Context, owners []string, fn func(ctx context.Context, member string) error) (string, error) {
+	if len(owners) == 0 {
+		return "", ErrEmptyCircle
+	}
+	delay := p.Delay
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		member string
+		err    error
+	}
+	results := make(chan result, len(owners))
+	launched, pending := 0, 0
+	launch := func() {
+		m := owners[launched]
+		launched++
+		pending++
+		go func() {
+			results <- result{m, fn(ctx, m)}
+		}()
+	}
+
+	launch()
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	var lastErr error
+	for {
+		select {
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				return r.member, nil
+			}
+			lastErr = r.err
+			if launched < len(owners) {
+				launch()
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				timer.Reset(delay)
+			} else if pending == 0 {
+				return "", lastErr
+			}
+		case <-timer.C:
+			if launched < len(owners) {
+				launch()
+				timer.Reset(delay)
+			}
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package consistent
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDoHedgedPrimary(t *testing.T) {
+	x := newPolicyRing(nil)
+	member, err := x.DoHedged(context.Background(), "9999999", func(ctx context.Context, m string) error {
+		return nil
+	}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if member != "opqrstu" {
+		t.Errorf("got %q, expected the primary owner", member)
+	}
+}
+
+func TestDoHedgedSlowPrimary(t *testing.T) {
+	x := newPolicyRing(nil)
+	var mu sync.Mutex
+	var cancelled bool
+	member, err := x.DoHedged(context.Background(), "9999999", func(ctx context.Context, m string) error {
+		if m == "opqrstu" {
+			<-ctx.Done()
+			mu.Lock()
+			cancelled = true
+			mu.Unlock()
+			return ctx.Err()
+		}
+		return nil
+	}, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if member != "abcdefg" {
+		t.Errorf("got %q, expected the hedged owner", member)
+	}
+	time.Sleep(10 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if !cancelled {
+		t.Errorf("expected the slow attempt to be cancelled")
+	}
+}
+
+func TestDoHedgedAllFail(t *testing.T) {
+	x := newPolicyRing(nil)
+	boom := errors.New("boom")
+	var mu sync.Mutex
+	var tried []string
+	_, err := x.DoHedged(context.Background(), "9999999", func(ctx context.Context, m string) error {
+		mu.Lock()
+		tried = append(tried, m)
+		mu.Unlock()
+		return boom
+	}, time.Hour)
+	if err != boom {
+		t.Errorf("expected the last error, got %v", err)
+	}
+	checkNum(len(tried), 2, t)
+}
+
+func TestDoHedgedContext(t *testing.T) {
+	x := newPolicyRing(nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := x.DoHedged(ctx, "9999999", func(ctx context.Context, m string) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, time.Millisecond)
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
+
+type reversePolicy struct{}
+
+func (reversePolicy) Order(key string, owners []string) []string {
+	for i, j := 0, len(owners)-1; i < j; i, j = i+1, j-1 {
+		owners[i], owners[j] = owners[j], owners[i]
+	}
+	return owners
+}
+
+func TestDoHedgedReadPolicy(t *testing.T) {
+	x := newPolicyRing(reversePolicy{})
+	member, err := x.DoHedged(context.Background(), "9999999", func(ctx context.Context, m string) error {
+		return nil
+	}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if member != "abcdefg" {
+		t.Errorf("got %q, expected the first owner of the read policy", member)
+	}
+}
+
+func TestHedgedDo(t *testing.T) {
+	var mu sync.Mutex
+	var tried []string
+	member, err := Hedged{Delay: time.Millisecond}.Do(context.Background(), []string{"a", "b", "c"}, func(ctx context.Context, m string) error {
+		mu.Lock()
+		tried = append(tried, m)
+		mu.Unlock()
+		if m != "c" {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if member != "c" {
+		t.Errorf("got %q, expected the last owner", member)
+	}
+	if _, err := (Hedged{}).Do(context.Background(), nil, nil); err != ErrEmptyCircle {
+		t.Errorf("expected ErrEmptyCircle, got %v", err)
+	}
+}
@@ -0,0 +1,152 @@
+package consistent
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// ErrUnknownVersion is returned when a ring version is not retained, see Config.KeepVersions.
+var ErrUnknownVersion = errors.New("consistent: ring version not retained")
+
+// VersionSummary is a compacted ring version: the ranges of the hash space that moved
+// when the ring went from Version to the next retained version. See Config.CompactVersions.
+type VersionSummary struct {
+	Version uint64
+	Time    time.Time // when Version became current
+	Moved   []MovedRange
+}
+
+// ringVersion is the placement of the circle at one version of the ring.
+type ringVersion struct {
+	version uint64
+	time    time.Time
+	hashes  uints
+	owners  []string
+}
+
+func (v *ringVersion) owner(key uint32) (string, error) {
+	if len(v.hashes) == 0 {
+		return "", ErrEmptyCircle
+	}
+	i := sort.Search(len(v.hashes), func(x int) bool { return v.hashes[x] > key })
+	if i >= len(v.hashes) {
+		i = 0
+	}
+	return v.owners[i], nil
+}
+
+// versionHistory retains the last versions of a ring in full and the versions before them
+// as summaries of their moved ranges.
+type versionHistory struct {
+	keep    int
+	maxAge  time.Duration
+	compact int
+	now     func() time.Time
+
+	full      []ringVersion    // oldest first
+	summaries []VersionSummary // oldest first, the last one leading to full[0]
+}
+
+func (h *versionHistory) record(version uint64, hashes uints, circle map[uint32]string) {
+	if h.keep <= 0 {
+		return
+	}
+	hashes = append(uints(nil), hashes...)
+	now := h.now()
+	h.full = append(h.full, ringVersion{version, now, hashes, owners(hashes, circle)})
+	drop := len(h.full) - h.keep
+	if drop < 0 {
+		drop = 0
+	}
+	if h.maxAge > 0 {
+		// a version is kept for maxAge after it stopped being current
+		for drop < len(h.full)-1 && now.Sub(h.full[drop+1].time) > h.maxAge {
+			drop++
+		}
+	}
+	if drop == 0 {
+		return
+	}
+	if h.compact > 0 {
+		for i := 0; i < drop; i++ {
+			old, next := h.full[i], h.full[i+1]
+			h.summaries = append(h.summaries, VersionSummary{
+				Version: old.version,
+				Time:    old.time,
+				Moved:   movedRanges(old.hashes, old.owners, next.hashes, next.owners),
+			})
+		}
+		if n := len(h.summaries) - h.compact; n > 0 {
+			h.summaries = append(h.summaries[:0], h.summaries[n:]...)
+		}
+	}
+	h.full = append(h.full[:0], h.full[drop:]...)
+}
+
+func (h *versionHistory) ownerAt(key uint32, version uint64) (string, error) {
+	for i := len(h.full) - 1; i >= 0; i-- {
+		if h.full[i].version == version {
+			return h.full[i].owner(key)
+		}
+	}
+	i := sort.Search(len(h.summaries), func(x int) bool { return h.summaries[x].Version >= version })
+	if i == len(h.summaries) || h.summaries[i].Version != version || len(h.full) == 0 {
+		return "", ErrUnknownVersion
+	}
+	// walk back from the oldest full version, undoing the moves of each summary
+	owner, err := h.full[0].owner(key)
+	if err != nil && err != ErrEmptyCircle {
+		return "", err
+	}
+	for j := len(h.summaries) - 1; j >= i; j-- {
+		for _, r := range h.summaries[j].Moved {
+			if uint64(key-r.Start) < r.size() {
+				owner = r.From
+				break
+			}
+		}
+	}
+	if owner == "" {
+		return "", ErrEmptyCircle
+	}
+	return owner, nil
+}
+
+// Version returns the version of the ring, incremented by every membership change. It is
+// the Version reported by StatsSnapshot.
+func (c *Consistent) Version() uint64 {
+	c.RLock()
+	defer c.RUnlock()
+	return c.stats.version
+}
+
+// OwnerAt returns the member that owned key on the circle at version of the ring. Only the
+// versions retained according to Config.KeepVersions, KeepVersionsFor and CompactVersions
+// are known; others give ErrUnknownVersion. Groups, overrides and weighted mode are not
+// taken into account.
+func (c *Consistent) OwnerAt(key string, version uint64) (string, error) {
+	c.RLock()
+	defer c.RUnlock()
+	return c.history.ownerAt(c.hashKey(key), version)
+}
+
+// PreviousOwner returns the member that owned key on the circle before the last
+// membership change, e.g. for a cache-miss handler to fetch data from it right after a
+// topology change. It needs Config.KeepVersions of at least 2.
+func (c *Consistent) PreviousOwner(key string) (string, error) {
+	c.RLock()
+	defer c.RUnlock()
+	full := c.history.full
+	if len(full) < 2 || full[len(full)-1].version != c.stats.version {
+		return "", ErrUnknownVersion
+	}
+	return full[len(full)-2].owner(c.hashKey(key))
+}
+
+// CompactedVersions returns the summaries of the compacted ring versions, oldest first.
+func (c *Consistent) CompactedVersions() []VersionSummary {
+	c.RLock()
+	defer c.RUnlock()
+	return append([]VersionSummary(nil), c.history.summaries...)
+}
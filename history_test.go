@@ -0,0 +1,96 @@
+package consistent
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestOwnerHistory(t *testing.T) {
+	x := New(Config{DefaultNumberOfReplicas: 20, KeepVersions: 2})
+	x.Set([]string{"a", "b", "c"})
+	v1 := x.Version()
+	before := make(map[string]string)
+	for i := 0; i < 100; i++ {
+		k := "key" + strconv.Itoa(i)
+		before[k] = mustGet(t, x, k)
+	}
+	x.Remove("b")
+	if x.Version() != v1+1 {
+		t.Fatalf("got version %d, expected %d", x.Version(), v1+1)
+	}
+	for k, owner := range before {
+		if m, err := x.PreviousOwner(k); err != nil || m != owner {
+			t.Errorf("%s: got previous owner %s, %v, expected %s", k, m, err, owner)
+		}
+		if m, _ := x.OwnerAt(k, v1); m != owner {
+			t.Errorf("%s: got owner %s at version %d, expected %s", k, m, v1, owner)
+		}
+		if m, _ := x.OwnerAt(k, v1+1); m != mustGet(t, x, k) {
+			t.Errorf("%s: got owner %s at the current version, expected %s", k, m, mustGet(t, x, k))
+		}
+	}
+	x.Add("d")
+	if _, err := x.OwnerAt("key1", v1); err != ErrUnknownVersion {
+		t.Errorf("got %v, expected ErrUnknownVersion beyond KeepVersions", err)
+	}
+}
+
+func TestOwnerHistoryDisabled(t *testing.T) {
+	x := New(newConfig())
+	x.Add("a")
+	x.Add("b")
+	if _, err := x.PreviousOwner("ggg"); err != ErrUnknownVersion {
+		t.Errorf("got %v, expected ErrUnknownVersion", err)
+	}
+}
+
+func TestOwnerHistoryCompaction(t *testing.T) {
+	x := New(Config{DefaultNumberOfReplicas: 20, KeepVersions: 1, CompactVersions: 3})
+	var versions []uint64
+	var owners []map[string]string
+	for _, m := range []string{"a", "b", "c", "d", "e"} {
+		x.Add(m)
+		versions = append(versions, x.Version())
+		o := make(map[string]string)
+		for i := 0; i < 100; i++ {
+			k := "key" + strconv.Itoa(i)
+			o[k] = mustGet(t, x, k)
+		}
+		owners = append(owners, o)
+	}
+	if n := len(x.CompactedVersions()); n != 3 {
+		t.Fatalf("got %d compacted versions, expected 3", n)
+	}
+	for i := 1; i < len(versions); i++ {
+		for k, owner := range owners[i] {
+			if m, err := x.OwnerAt(k, versions[i]); err != nil || m != owner {
+				t.Errorf("%s: got %s, %v at version %d, expected %s", k, m, err, versions[i], owner)
+			}
+		}
+	}
+	if _, err := x.OwnerAt("key1", versions[0]); err != ErrUnknownVersion {
+		t.Errorf("got %v, expected ErrUnknownVersion beyond CompactVersions", err)
+	}
+	if _, err := x.PreviousOwner("key1"); err != ErrUnknownVersion {
+		t.Errorf("got %v, expected ErrUnknownVersion with a single full version", err)
+	}
+}
+
+func TestOwnerHistoryMaxAge(t *testing.T) {
+	x := New(Config{DefaultNumberOfReplicas: 20, KeepVersions: 10, KeepVersionsFor: time.Minute})
+	now := time.Unix(1000000, 0)
+	x.history.now = func() time.Time { return now }
+	x.Add("a")
+	x.Add("b")
+	now = now.Add(30 * time.Second)
+	x.Add("c")
+	if _, err := x.PreviousOwner("ggg"); err != nil {
+		t.Errorf("got %v, expected the previous version to be retained", err)
+	}
+	now = now.Add(2 * time.Minute)
+	x.Add("d")
+	if n := len(x.history.full); n != 2 {
+		t.Errorf("got %d full versions, expected the current one and the one it replaced", n)
+	}
+}
@@ -0,0 +1,97 @@
+package consistent
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Op describes one call to Get, Add or Remove, as passed to the After function of a Hook.
+type Op struct {
+	Name     string // "Get", "Add" or "Remove"
+	Key      string // the key looked up, or the member added or removed
+	Result   string // the owner returned by Get
+	Err      error  // the error returned by Get
+	Changed  bool   // whether Add or Remove changed the membership
+	Duration time.Duration
+}
+
+// Hook is a pair of functions run around every call to Get, Add and Remove, for plugins such
+// as metrics, tracing or hot key detection. Before, which may be nil, gets the name of the
+// operation and its key before it runs; After, which may be nil, gets its outcome. Both run
+// on the calling goroutine, outside the ring lock, so they may use the ring.
+type Hook struct {
+	Before func(name, key string)
+	After  func(op Op)
+}
+
+// AddHook registers h. Rings without hooks do not pay for them. The returned function
+// unregisters h.
+func (c *Consistent) AddHook(h Hook) (remove func()) {
+	return c.hooks.add(h)
+}
+
+type hookEntry struct {
+	id   int
+	hook Hook
+}
+
+// hooks keeps the registered hooks in an atomic.Value so lookups read them without locking.
+type hooks struct {
+	mu   sync.Mutex
+	next int
+	list atomic.Value // []hookEntry
+}
+
+func (h *hooks) load() []hookEntry {
+	list, _ := h.list.Load().([]hookEntry)
+	return list
+}
+
+func (h *hooks) add(hook Hook) func() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	id := h.next
+	h.next++
+	old := h.load()
+	h.list.Store(append(old[:len(old):len(old)], hookEntry{id, hook}))
+	return func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		old := h.load()
+		for i, v := range old {
+			if v.id == id {
+				h.list.Store(append(old[:i:i], old[i+1:]...))
+				return
+			}
+		}
+	}
+}
+
+// around runs the Before functions of list and returns a function running their After
+// functions, to be deferred with pointers to the outcome of the operation.
+func around(list []hookEntry, name, key string) func(result *string, err *error, changed *bool) {
+	for _, v := range list {
+		if v.hook.Before != nil {
+			v.hook.Before(name, key)
+		}
+	}
+	start := time.Now()
+	return func(result *string, err *error, changed *bool) {
+		op := Op{Name: name, Key: key, Duration: time.Since(start)}
+		if result != nil {
+			op.Result = *result
+		}
+		if err != nil {
+			op.Err = *err
+		}
+		if changed != nil {
+			op.Changed = *changed
+		}
+		for _, v := range list {
+			if v.hook.After != nil {
+				v.hook.After(op)
+			}
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package consistent
+
+import "testing"
+
+func TestHooks(t *testing.T) {
+	x := New(newConfig())
+	var before []string
+	var ops []Op
+	remove := x.AddHook(Hook{
+		Before: func(name, key string) { before = append(before, name+" "+key) },
+		After: func(op Op) {
+			if op.Duration < 0 {
+				t.Errorf("negative duration in %+v", op)
+			}
+			ops = append(ops, op)
+		},
+	})
+	counted := 0
+	removeCounter := x.AddHook(Hook{After: func(Op) { counted++ }})
+	x.Get("ggg")
+	x.Add("abcdefg")
+	x.Add("abcdefg")
+	x.Get("ggg")
+	x.Remove("abcdefg")
+	remove()
+	x.Get("ggg")
+	removeCounter()
+	x.Get("ggg")
+
+	if len(before) != 5 || before[1] != "Add abcdefg" {
+		t.Errorf("unexpected Before calls %v", before)
+	}
+	if len(ops) != 5 {
+		t.Fatalf("expected 5 operations, got %+v", ops)
+	}
+	if ops[0].Err != ErrEmptyCircle || !ops[1].Changed || ops[2].Changed || ops[3].Result != "abcdefg" || !ops[4].Changed {
+		t.Errorf("unexpected operations %+v", ops)
+	}
+	checkNum(counted, 6, t)
+}
@@ -0,0 +1,59 @@
+package consistent
+
+import "sort"
+
+const (
+	// indexThreshold is the number of vnodes above which lookups go through a bucketIndex.
+	indexThreshold = 1 << 10
+	// largeIndexThreshold switches the index from 256 to 4096 buckets.
+	largeIndexThreshold = 1 << 16
+)
+
+// bucketIndex splits the uint32 space into 2^bits buckets by the top bits of a hash. For every
+// bucket it records the position of the first sorted hash that falls into it, so a lookup only
+// has to binary search the hashes of a single bucket.
+type bucketIndex struct {
+	shift   uint
+	buckets []uint32 // len(buckets) == 2^bits+1, the last entry is len(sortedHashes)
+}
+
+// build (re)creates the index for hashes, or drops it when the ring is too small to benefit.
+func (b *bucketIndex) build(hashes uints) {
+	if len(hashes) < indexThreshold {
+		b.buckets = nil
+		return
+	}
+	bits := uint(8)
+	if len(hashes) >= largeIndexThreshold {
+		bits = 12
+	}
+	n := 1 << bits
+	if cap(b.buckets) >= n+1 {
+		b.buckets = b.buckets[:n+1]
+	} else {
+		b.buckets = make([]uint32, n+1)
+	}
+	b.shift = 32 - bits
+	pos := 0
+	for i := 0; i < n; i++ {
+		for pos < len(hashes) && int(hashes[pos]>>b.shift) < i {
+			pos++
+		}
+		b.buckets[i] = uint32(pos)
+	}
+	b.buckets[n] = uint32(len(hashes))
+}
+
+// search returns the same position as a plain binary search over hashes for the first
+// hash greater than key, wrapping around to 0.
+func (b *bucketIndex) search(hashes uints, key uint32) int {
+	bucket := key >> b.shift
+	lo, hi := int(b.buckets[bucket]), int(b.buckets[bucket+1])
+	i := lo + sort.Search(hi-lo, func(x int) bool {
+		return hashes[lo+x] > key
+	})
+	if i >= len(hashes) {
+		i = 0
+	}
+	return i
+}
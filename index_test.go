@@ -0,0 +1,69 @@
+package consistent
+
+import (
+	"math/rand"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+func TestBucketIndexSearch(t *testing.T) {
+	for _, size := range []int{indexThreshold, largeIndexThreshold} {
+		hashes := make(uints, size)
+		for i := range hashes {
+			hashes[i] = rand.Uint32()
+		}
+		hashes[0] = 0
+		hashes[1] = ^uint32(0)
+		sort.Sort(hashes)
+		var b bucketIndex
+		b.build(hashes)
+		if b.buckets == nil {
+			t.Fatalf("expected index for %d hashes", size)
+		}
+		keys := []uint32{0, 1, ^uint32(0), ^uint32(0) - 1, hashes[size/2], hashes[size/2] + 1}
+		for i := 0; i < 10000; i++ {
+			keys = append(keys, rand.Uint32())
+		}
+		for _, key := range keys {
+			expected := sort.Search(len(hashes), func(x int) bool { return hashes[x] > key })
+			if expected >= len(hashes) {
+				expected = 0
+			}
+			if got := b.search(hashes, key); got != expected {
+				t.Fatalf("key %d: got %d, expected %d", key, got, expected)
+			}
+		}
+	}
+}
+
+func TestIndexedGet(t *testing.T) {
+	x := New(Config{DefaultNumberOfReplicas: 100})
+	for i := 0; i < 20; i++ {
+		x.Add("member" + strconv.Itoa(i))
+	}
+	if x.index.buckets == nil {
+		t.Fatalf("expected an index above %d vnodes", indexThreshold)
+	}
+	for i := 0; i < 1000; i++ {
+		key := "key" + strconv.Itoa(i)
+		h := x.hashKey(key)
+		expected := sort.Search(len(x.sortedHashes), func(j int) bool { return x.sortedHashes[j] > h })
+		if expected >= len(x.sortedHashes) {
+			expected = 0
+		}
+		result, err := x.Get(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result != x.circle[x.sortedHashes[expected]] {
+			t.Errorf("%s: got %q, expected %q", key, result, x.circle[x.sortedHashes[expected]])
+		}
+	}
+	for i := 0; i < 20; i++ {
+		x.Remove("member" + strconv.Itoa(i))
+	}
+	if x.index.buckets != nil {
+		t.Errorf("expected index to be dropped on an empty ring")
+	}
+}
@@ -0,0 +1,33 @@
+//go:build go1.23
+// +build go1.23
+
+package consistent
+
+import "iter"
+
+// AllMembers returns an iterator over the members, sorted, as of the call. It reads an
+// immutable snapshot published with every change, so it takes no lock, copies nothing and
+// may be consumed while the ring changes. It needs Go 1.23.
+func (c *Consistent) AllMembers() iter.Seq[string] {
+	v := c.view.Load().(*readView)
+	return func(yield func(string) bool) {
+		for _, m := range v.members {
+			if !yield(m) {
+				return
+			}
+		}
+	}
+}
+
+// AllMemberReplicas returns an iterator over the members, sorted, and their replicas as of
+// the call, with the snapshot semantics of AllMembers. It needs Go 1.23.
+func (c *Consistent) AllMemberReplicas() iter.Seq2[string, int] {
+	v := c.view.Load().(*readView)
+	return func(yield func(string, int) bool) {
+		for i, m := range v.members {
+			if !yield(m, v.replicas[i]) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,59 @@
+//go:build go1.23
+// +build go1.23
+
+package consistent
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestAllMembers(t *testing.T) {
+	x := New(newConfig())
+	x.Add("b")
+	x.Add("a", 40)
+	x.Add("c")
+	var got []string
+	for m := range x.AllMembers() {
+		got = append(got, m)
+	}
+	if len(got) != 3 || got[0] != "a" || got[1] != "b" || got[2] != "c" {
+		t.Errorf("got %v, expected [a b c]", got)
+	}
+	for m, n := range x.AllMemberReplicas() {
+		if n != x.MemberReplicas()[m] {
+			t.Errorf("%s: got %d replicas, expected %d", m, n, x.MemberReplicas()[m])
+		}
+	}
+
+	// the iterator keeps the membership of the call while the ring changes
+	seq := x.AllMembers()
+	n := 0
+	for m := range seq {
+		x.Remove(m)
+		x.Add("new" + strconv.Itoa(n))
+		n++
+	}
+	if n != 3 {
+		t.Errorf("iterated over %d members, expected 3", n)
+	}
+	x.MarkDown("new0")
+	if len(x.Members()) != 3 {
+		t.Errorf("unexpected members %v", x.Members())
+	}
+	for m := range x.AllMembers() {
+		if m[:3] != "new" {
+			t.Errorf("stale member %s", m)
+		}
+	}
+
+	seq2 := x.AllMemberReplicas()
+	total := 0
+	if allocs := testing.AllocsPerRun(100, func() {
+		for _, n := range seq2 {
+			total += n
+		}
+	}); allocs != 0 && !raceEnabled {
+		t.Errorf("consuming the iterator allocates %v times", allocs)
+	}
+}
@@ -0,0 +1,92 @@
+// Package jump implements Jump Consistent Hash, from "A Fast, Minimal Memory, Consistent
+// Hash Algorithm" by John Lamping and Eric Veach. It maps keys to numbered buckets without
+// any memory and far faster than a ring, and moves only 1/n of the keys when a bucket is
+// appended. Buckets can only be added or removed at the end, so it suits shards numbered
+// densely, such as storage partitions, rather than members coming and going at random.
+package jump
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/jiangz222/consistent/core"
+)
+
+// ErrNoBuckets is returned by lookups on a Ring without members.
+var ErrNoBuckets = errors.New("jump: no buckets")
+
+// JumpHash returns the bucket of key, between 0 and numBuckets-1, or -1 if numBuckets < 1.
+func JumpHash(key uint64, numBuckets int) int {
+	if numBuckets < 1 {
+		return -1
+	}
+	var b, j int64 = -1, 0
+	for j < int64(numBuckets) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return int(b)
+}
+
+// Ring maps string keys to named members with JumpHash, member i being bucket i. Keys are
+// hashed with xxHash64. It is safe for concurrent use.
+type Ring struct {
+	mu      sync.RWMutex
+	members []string
+}
+
+// New creates a Ring with members, in bucket order.
+func New(members ...string) *Ring {
+	return &Ring{members: append([]string(nil), members...)}
+}
+
+// Append adds member as the last bucket. Only the keys it takes over move, about 1/n of
+// them.
+func (r *Ring) Append(member string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.members = append(r.members, member)
+}
+
+// RemoveLast removes the last bucket and returns its member, "" if there is none. Only its
+// keys move.
+func (r *Ring) RemoveLast() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.members) == 0 {
+		return ""
+	}
+	m := r.members[len(r.members)-1]
+	r.members = r.members[:len(r.members)-1]
+	return m
+}
+
+// Replace gives bucket i to member, e.g. to swap a failed machine for a new one without
+// moving any key. It reports whether i is a bucket.
+func (r *Ring) Replace(i int, member string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if i < 0 || i >= len(r.members) {
+		return false
+	}
+	r.members[i] = member
+	return true
+}
+
+// Members returns the members in bucket order.
+func (r *Ring) Members() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]string(nil), r.members...)
+}
+
+// Get returns the member of the bucket of key.
+func (r *Ring) Get(key string) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.members) == 0 {
+		return "", ErrNoBuckets
+	}
+	return r.members[JumpHash(core.HashXXH64(key), len(r.members))], nil
+}
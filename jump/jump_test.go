@@ -0,0 +1,74 @@
+package jump
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestJumpHash(t *testing.T) {
+	for _, c := range []struct {
+		key     uint64
+		buckets int
+		want    int
+	}{
+		{1, 1, 0},
+		{42, 57, 43},
+		{0xDEAD10CC, 1, 0},
+		{0xDEAD10CC, 666, 361},
+		{256, 1024, 520},
+		{0, 0, -1},
+	} {
+		if got := JumpHash(c.key, c.buckets); got != c.want {
+			t.Errorf("JumpHash(%d, %d) = %d, expected %d", c.key, c.buckets, got, c.want)
+		}
+	}
+}
+
+func TestRing(t *testing.T) {
+	r := New()
+	if _, err := r.Get("key"); err != ErrNoBuckets {
+		t.Errorf("got %v, expected ErrNoBuckets", err)
+	}
+	for i := 0; i < 10; i++ {
+		r.Append("m" + strconv.Itoa(i))
+	}
+	const keys = 10000
+	before := make(map[string]string)
+	for i := 0; i < keys; i++ {
+		k := "key" + strconv.Itoa(i)
+		before[k], _ = r.Get(k)
+	}
+	r.Append("m10")
+	moved := 0
+	for k, old := range before {
+		m, _ := r.Get(k)
+		if m != old {
+			if m != "m10" {
+				t.Errorf("%s moved from %s to %s", k, old, m)
+			}
+			moved++
+		}
+	}
+	if moved < keys/11-300 || moved > keys/11+300 {
+		t.Errorf("%d keys moved, expected about %d", moved, keys/11)
+	}
+	if m := r.RemoveLast(); m != "m10" {
+		t.Errorf("removed %s", m)
+	}
+	r.Replace(3, "spare")
+	for k, old := range before {
+		m, _ := r.Get(k)
+		if old == "m3" {
+			old = "spare"
+		}
+		if m != old {
+			t.Errorf("%s: got %s, expected %s", k, m, old)
+		}
+	}
+}
+
+func BenchmarkJumpHash(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		JumpHash(uint64(i), 1000)
+	}
+}
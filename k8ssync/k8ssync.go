@@ -0,0 +1,369 @@
+// Package k8ssync keeps the membership of a ring equal to the ready endpoints of a
+// Kubernetes Service, reconciled from its EndpointSlices, for sharded caches and other
+// services running in Kubernetes.
+//
+// It talks to the API server directly, listing then watching the EndpointSlices labelled
+// kubernetes.io/service-name=<service>, and does not depend on client-go. Inside a pod,
+// InCluster configures it from the service account; its role needs the list and watch
+// verbs on endpointslices.discovery.k8s.io.
+package k8ssync
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jiangz222/consistent"
+)
+
+const (
+	// DefaultDebounce is the time a Watcher waits without further changes before applying
+	// them when Debounce is not set, so a rolling update causes few remaps.
+	DefaultDebounce = time.Second
+	// DefaultRetryDelay is the time to wait after a failed request when RetryDelay is not set.
+	DefaultRetryDelay = time.Second
+)
+
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount/"
+
+// ErrWatchClosed is reported when the API server ends a watch with an error, after which
+// the EndpointSlices are listed again.
+var ErrWatchClosed = errors.New("k8ssync: watch closed")
+
+// Endpoint is a ready address of the Service.
+type Endpoint struct {
+	Address string
+	Port    int
+	Pod     string // the pod the endpoint targets, if any
+}
+
+// Watcher applies the ready endpoints of Service in Namespace to Ring with Set.
+type Watcher struct {
+	// Server is the base URL of the API server.
+	Server    string
+	Namespace string
+	Service   string
+	Ring      *consistent.Consistent
+	// Client makes the requests. Defaults to http.DefaultClient.
+	Client *http.Client
+	// Token is the bearer token of the requests. TokenFile, if set, is read before each
+	// list or watch instead, to follow rotated service account tokens.
+	Token     string
+	TokenFile string
+	// Port is the name of the port of the endpoints to use. Defaults to the first port.
+	Port string
+	// Name returns the member name of an endpoint. Defaults to "address:port".
+	Name func(Endpoint) string
+	// Debounce defaults to DefaultDebounce; set it negative to apply every change at once.
+	Debounce time.Duration
+	// OnError, if set, is called with the errors of the requests.
+	OnError func(error)
+	// RetryDelay defaults to DefaultRetryDelay.
+	RetryDelay time.Duration
+}
+
+// New creates a Watcher applying the ready endpoints of service in namespace, as seen by
+// the API server at server, to ring.
+func New(server, namespace, service string, ring *consistent.Consistent) *Watcher {
+	return &Watcher{Server: server, Namespace: namespace, Service: service, Ring: ring}
+}
+
+// InCluster creates a Watcher for a process running in a pod, authenticated as its
+// service account. An empty namespace is that of the pod.
+func InCluster(namespace, service string, ring *consistent.Consistent) (*Watcher, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, errors.New("k8ssync: not running in a pod")
+	}
+	ca, err := ioutil.ReadFile(serviceAccountDir + "ca.crt")
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, errors.New("k8ssync: no certificate in " + serviceAccountDir + "ca.crt")
+	}
+	if namespace == "" {
+		ns, err := ioutil.ReadFile(serviceAccountDir + "namespace")
+		if err != nil {
+			return nil, err
+		}
+		namespace = strings.TrimSpace(string(ns))
+	}
+	w := New("https://"+net.JoinHostPort(host, port), namespace, service, ring)
+	w.TokenFile = serviceAccountDir + "token"
+	w.Client = &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	return w, nil
+}
+
+// Run lists the EndpointSlices of the Service, applies their ready endpoints and follows
+// their changes until ctx is done, listing them again whenever a request fails. It
+// returns ctx.Err().
+func (w *Watcher) Run(ctx context.Context) error {
+	for {
+		err := w.follow(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if w.OnError != nil {
+			w.OnError(err)
+		}
+		delay := w.RetryDelay
+		if delay <= 0 {
+			delay = DefaultRetryDelay
+		}
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// endpointSlice is the part of a discovery.k8s.io/v1 EndpointSlice the Watcher reads.
+type endpointSlice struct {
+	Metadata struct {
+		Name            string `json:"name"`
+		ResourceVersion string `json:"resourceVersion"`
+	} `json:"metadata"`
+	Endpoints []struct {
+		Addresses  []string `json:"addresses"`
+		Conditions struct {
+			Ready *bool `json:"ready"`
+		} `json:"conditions"`
+		TargetRef *struct {
+			Kind string `json:"kind"`
+			Name string `json:"name"`
+		} `json:"targetRef"`
+	} `json:"endpoints"`
+	Ports []struct {
+		Name *string `json:"name"`
+		Port *int    `json:"port"`
+	} `json:"ports"`
+}
+
+type sliceList struct {
+	Metadata struct {
+		ResourceVersion string `json:"resourceVersion"`
+	} `json:"metadata"`
+	Items []endpointSlice `json:"items"`
+}
+
+type watchEvent struct {
+	Type   string          `json:"type"`
+	Object json.RawMessage `json:"object"`
+}
+
+// follow lists the slices, then watches them until the watch ends, returning the error
+// that ended it.
+func (w *Watcher) follow(ctx context.Context) error {
+	var list sliceList
+	resp, err := w.get(ctx, url.Values{})
+	if err != nil {
+		return err
+	}
+	err = json.NewDecoder(resp.Body).Decode(&list)
+	resp.Body.Close()
+	if err != nil {
+		return fmt.Errorf("k8ssync: decoding EndpointSlices: %v", err)
+	}
+	slices := make(map[string][]string, len(list.Items))
+	for _, s := range list.Items {
+		slices[s.Metadata.Name] = w.members(s)
+	}
+	w.apply(slices)
+
+	wctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	resp, err = w.get(wctx, url.Values{
+		"watch":               {"true"},
+		"resourceVersion":     {list.Metadata.ResourceVersion},
+		"allowWatchBookmarks": {"true"},
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	events := make(chan watchEvent)
+	errc := make(chan error, 1)
+	go func() {
+		dec := json.NewDecoder(resp.Body)
+		for {
+			var ev watchEvent
+			if err := dec.Decode(&ev); err != nil {
+				errc <- err
+				return
+			}
+			select {
+			case events <- ev:
+			case <-wctx.Done():
+				return
+			}
+		}
+	}()
+
+	debounce := w.Debounce
+	if debounce == 0 {
+		debounce = DefaultDebounce
+	}
+	var (
+		timer *time.Timer
+		fire  <-chan time.Time
+	)
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+	for {
+		select {
+		case ev := <-events:
+			switch ev.Type {
+			case "ADDED", "MODIFIED", "DELETED":
+			case "ERROR":
+				// typically 410 Gone: the resource version is too old to watch from
+				if fire != nil {
+					w.apply(slices)
+				}
+				return fmt.Errorf("%w: %s", ErrWatchClosed, ev.Object)
+			default:
+				continue
+			}
+			var s endpointSlice
+			if err := json.Unmarshal(ev.Object, &s); err != nil {
+				return fmt.Errorf("k8ssync: decoding EndpointSlice: %v", err)
+			}
+			if ev.Type == "DELETED" {
+				delete(slices, s.Metadata.Name)
+			} else {
+				slices[s.Metadata.Name] = w.members(s)
+			}
+			if debounce < 0 {
+				w.apply(slices)
+				continue
+			}
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = time.NewTimer(debounce)
+			fire = timer.C
+		case <-fire:
+			fire = nil
+			w.apply(slices)
+		case err := <-errc:
+			if fire != nil {
+				w.apply(slices)
+			}
+			return fmt.Errorf("%w: %v", ErrWatchClosed, err)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// get requests the EndpointSlices of the Service with the parameters q.
+func (w *Watcher) get(ctx context.Context, q url.Values) (*http.Response, error) {
+	q.Set("labelSelector", "kubernetes.io/service-name="+w.Service)
+	u := strings.TrimSuffix(w.Server, "/") + "/apis/discovery.k8s.io/v1/namespaces/" +
+		url.PathEscape(w.Namespace) + "/endpointslices?" + q.Encode()
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	token := w.Token
+	if w.TokenFile != "" {
+		b, err := ioutil.ReadFile(w.TokenFile)
+		if err != nil {
+			return nil, err
+		}
+		token = strings.TrimSpace(string(b))
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("k8ssync: %s: %s", u, resp.Status)
+	}
+	return resp, nil
+}
+
+// members returns the member names of the ready endpoints of s.
+func (w *Watcher) members(s endpointSlice) []string {
+	port := -1
+	for _, p := range s.Ports {
+		name := ""
+		if p.Name != nil {
+			name = *p.Name
+		}
+		if p.Port != nil && (w.Port == "" || name == w.Port) {
+			port = *p.Port
+			break
+		}
+	}
+	if port < 0 {
+		return nil
+	}
+	var res []string
+	for _, e := range s.Endpoints {
+		// a nil ready condition means ready
+		if e.Conditions.Ready != nil && !*e.Conditions.Ready {
+			continue
+		}
+		pod := ""
+		if e.TargetRef != nil && e.TargetRef.Kind == "Pod" {
+			pod = e.TargetRef.Name
+		}
+		for _, a := range e.Addresses {
+			res = append(res, w.name(Endpoint{Address: a, Port: port, Pod: pod}))
+		}
+	}
+	return res
+}
+
+func (w *Watcher) name(e Endpoint) string {
+	if w.Name != nil {
+		return w.Name(e)
+	}
+	return net.JoinHostPort(e.Address, strconv.Itoa(e.Port))
+}
+
+// apply makes the endpoints of slices the membership of the ring. An endpoint may be in
+// several slices for a while as it moves between them.
+func (w *Watcher) apply(slices map[string][]string) {
+	seen := make(map[string]bool)
+	var members []string
+	for _, s := range slices {
+		for _, m := range s {
+			if !seen[m] {
+				seen[m] = true
+				members = append(members, m)
+			}
+		}
+	}
+	sort.Strings(members)
+	w.Ring.Set(members)
+}
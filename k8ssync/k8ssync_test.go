@@ -0,0 +1,135 @@
+package k8ssync
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jiangz222/consistent"
+)
+
+func slice(name string, ready map[string]bool) string {
+	var eps []string
+	for addr, r := range ready {
+		eps = append(eps, fmt.Sprintf(`{"addresses": [%q], "conditions": {"ready": %v}, "targetRef": {"kind": "Pod", "name": "pod-%s"}}`, addr, r, addr))
+	}
+	sort.Strings(eps)
+	return fmt.Sprintf(`{"metadata": {"name": %q, "resourceVersion": "1"}, "endpoints": [%s],
+		"ports": [{"name": "metrics", "port": 9090}, {"name": "cache", "port": 11211}]}`, name, strings.Join(eps, ","))
+}
+
+// apiServer serves the EndpointSlices of the service cache in namespace default.
+type apiServer struct {
+	mu     sync.Mutex
+	items  map[string]string
+	lists  int
+	events chan string
+}
+
+func (a *apiServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/apis/discovery.k8s.io/v1/namespaces/default/endpointslices" ||
+		r.URL.Query().Get("labelSelector") != "kubernetes.io/service-name=cache" ||
+		r.Header.Get("Authorization") != "Bearer secret" {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	if r.URL.Query().Get("watch") != "true" {
+		a.mu.Lock()
+		a.lists++
+		var items []string
+		for _, s := range a.items {
+			items = append(items, s)
+		}
+		a.mu.Unlock()
+		fmt.Fprintf(w, `{"metadata": {"resourceVersion": "1"}, "items": [%s]}`, strings.Join(items, ","))
+		return
+	}
+	w.(http.Flusher).Flush()
+	for {
+		select {
+		case ev := <-a.events:
+			fmt.Fprintln(w, ev)
+			w.(http.Flusher).Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// event changes the slices like the watch event it sends.
+func (a *apiServer) event(typ, name, obj string) {
+	a.mu.Lock()
+	if typ == "DELETED" {
+		delete(a.items, name)
+	} else {
+		a.items[name] = obj
+	}
+	a.mu.Unlock()
+	a.events <- fmt.Sprintf(`{"type": %q, "object": %s}`, typ, obj)
+}
+
+func waitMembers(t *testing.T, c *consistent.Consistent, want ...string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		got := c.Members()
+		sort.Strings(got)
+		if strings.Join(got, ",") == strings.Join(want, ",") {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("members %v, expected %v", got, want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	a := &apiServer{items: map[string]string{
+		"cache-1": slice("cache-1", map[string]bool{"10.0.0.1": true, "10.0.0.2": false}),
+	}, events: make(chan string)}
+	srv := httptest.NewServer(a)
+	defer srv.Close()
+	c := consistent.New(consistent.Config{DefaultNumberOfReplicas: 20})
+	w := New(srv.URL, "default", "cache", c)
+	w.Token = "secret"
+	w.Port = "cache"
+	w.Debounce = 20 * time.Millisecond
+	w.RetryDelay = 10 * time.Millisecond
+	errs := make(chan error, 10)
+	w.OnError = func(err error) { errs <- err }
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- w.Run(ctx) }()
+
+	waitMembers(t, c, "10.0.0.1:11211")
+	a.event("MODIFIED", "cache-1", slice("cache-1", map[string]bool{"10.0.0.1": true, "10.0.0.2": true}))
+	a.event("ADDED", "cache-2", slice("cache-2", map[string]bool{"10.0.0.3": true}))
+	waitMembers(t, c, "10.0.0.1:11211", "10.0.0.2:11211", "10.0.0.3:11211")
+	a.event("DELETED", "cache-1", slice("cache-1", nil))
+	waitMembers(t, c, "10.0.0.3:11211")
+
+	// an expired resource version makes it list again
+	a.events <- `{"type": "ERROR", "object": {"kind": "Status", "code": 410}}`
+	if err := <-errs; !strings.Contains(err.Error(), "410") {
+		t.Errorf("unexpected error %v", err)
+	}
+	a.event("ADDED", "cache-3", slice("cache-3", map[string]bool{"10.0.0.4": true}))
+	waitMembers(t, c, "10.0.0.3:11211", "10.0.0.4:11211")
+	a.mu.Lock()
+	if a.lists != 2 {
+		t.Errorf("listed %d times, expected 2", a.lists)
+	}
+	a.mu.Unlock()
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("got %v, expected context.Canceled", err)
+	}
+}
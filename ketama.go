@@ -0,0 +1,31 @@
+package consistent
+
+import (
+	"crypto/md5"
+	"strconv"
+)
+
+// DefaultKetamaPoints is the number of points per member in KetamaCompatible mode when
+// Config.DefaultNumberOfReplicas is not set, that of libketama and spymemcached.
+const DefaultKetamaPoints = 160
+
+// ketamaPoint returns point idx of elt as libketama places it: the MD5 digest of
+// "elt-n" gives the 4 points 4n to 4n+3, each from 4 bytes read little endian. Members
+// are expected to be named "host:port" like the servers of memcached clients.
+func ketamaPoint(elt string, idx int) uint32 {
+	d := md5.Sum([]byte(elt + "-" + strconv.Itoa(idx/4)))
+	b := d[(idx%4)*4:]
+	return uint32(b[3])<<24 | uint32(b[2])<<16 | uint32(b[1])<<8 | uint32(b[0])
+}
+
+// ketamaKeyHash returns the hash of key libketama looks up, minus one: libketama routes a
+// key to the first point at or above its hash, and the circle to the first point above.
+func ketamaKeyHash(key string) uint32 {
+	return ketamaKeyHashBytes([]byte(key))
+}
+
+func ketamaKeyHashBytes(key []byte) uint32 {
+	d := md5.Sum(key)
+	h := uint32(d[3])<<24 | uint32(d[2])<<16 | uint32(d[1])<<8 | uint32(d[0])
+	return h - 1
+}
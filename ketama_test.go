@@ -0,0 +1,51 @@
+package consistent
+
+import (
+	"crypto/md5"
+	"fmt"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+// libketama builds the continuum the way libketama does, for comparison.
+func libketama(servers []string, points int) func(key string) string {
+	type point struct {
+		value  uint32
+		server string
+	}
+	var continuum []point
+	for _, s := range servers {
+		for k := 0; k < points/4; k++ {
+			d := md5.Sum([]byte(fmt.Sprintf("%s-%d", s, k)))
+			for h := 0; h < 4; h++ {
+				v := uint32(d[3+h*4])<<24 | uint32(d[2+h*4])<<16 | uint32(d[1+h*4])<<8 | uint32(d[h*4])
+				continuum = append(continuum, point{v, s})
+			}
+		}
+	}
+	sort.Slice(continuum, func(i, j int) bool { return continuum[i].value < continuum[j].value })
+	return func(key string) string {
+		d := md5.Sum([]byte(key))
+		h := uint32(d[3])<<24 | uint32(d[2])<<16 | uint32(d[1])<<8 | uint32(d[0])
+		i := sort.Search(len(continuum), func(x int) bool { return continuum[x].value >= h })
+		if i == len(continuum) {
+			i = 0
+		}
+		return continuum[i].server
+	}
+}
+
+func TestKetamaCompatible(t *testing.T) {
+	servers := []string{"10.0.0.1:11211", "10.0.0.2:11211", "10.0.0.3:11211"}
+	x := New(Config{KetamaCompatible: true})
+	x.Set(servers)
+	checkNum(len(x.sortedHashes), 3*DefaultKetamaPoints, t)
+	ref := libketama(servers, DefaultKetamaPoints)
+	for i := 0; i < 1000; i++ {
+		k := "key" + strconv.Itoa(i)
+		if got, want := mustGet(t, x, k), ref(k); got != want {
+			t.Errorf("%s: got %s, libketama gives %s", k, got, want)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+// Package keyextract provides composable extractors that pull a routing key out of an HTTP
+// request or a gRPC call, for use with a consistent.Locator.
+//
+// The gRPC extractors do not depend on grpc-go. Metadata takes a function reading the
+// incoming metadata, typically:
+//
+//	func(ctx context.Context) (map[string][]string, bool) {
+//		md, ok := metadata.FromIncomingContext(ctx)
+//		return md, ok
+//	}
+package keyextract
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/jiangz222/consistent"
+)
+
+// ErrNoKey is returned by the lookup helpers when the extractor found no key.
+var ErrNoKey = errors.New("keyextract: no routing key")
+
+// HTTP extracts a routing key from r, reporting false if there is none.
+type HTTP func(r *http.Request) (string, bool)
+
+// GRPC extracts a routing key from a gRPC call context and request message.
+type GRPC func(ctx context.Context, req interface{}) (string, bool)
+
+// MetadataFunc returns the incoming gRPC metadata of ctx.
+type MetadataFunc func(ctx context.Context) (map[string][]string, bool)
+
+// Header extracts the first value of header name.
+func Header(name string) HTTP {
+	return func(r *http.Request) (string, bool) {
+		v := r.Header.Get(name)
+		return v, v != ""
+	}
+}
+
+// Cookie extracts the value of the cookie called name.
+func Cookie(name string) HTTP {
+	return func(r *http.Request) (string, bool) {
+		c, err := r.Cookie(name)
+		if err != nil || c.Value == "" {
+			return "", false
+		}
+		return c.Value, true
+	}
+}
+
+// Query extracts the first value of URL query parameter name.
+func Query(name string) HTTP {
+	return func(r *http.Request) (string, bool) {
+		v := r.URL.Query().Get(name)
+		return v, v != ""
+	}
+}
+
+// PathSegment extracts the i-th segment of the URL path, counting from 0 and ignoring the
+// leading slash: segment 1 of "/users/42/posts" is "42".
+func PathSegment(i int) HTTP {
+	return func(r *http.Request) (string, bool) {
+		segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		if i < 0 || i >= len(segments) || segments[i] == "" {
+			return "", false
+		}
+		return segments[i], true
+	}
+}
+
+// First returns the key of the first extractor that finds one.
+func First(extractors ...HTTP) HTTP {
+	return func(r *http.Request) (string, bool) {
+		for _, e := range extractors {
+			if k, ok := e(r); ok {
+				return k, true
+			}
+		}
+		return "", false
+	}
+}
+
+// Join concatenates the keys of all extractors with sep. It finds no key unless every
+// extractor does.
+func Join(sep string, extractors ...HTTP) HTTP {
+	return func(r *http.Request) (string, bool) {
+		parts := make([]string, 0, len(extractors))
+		for _, e := range extractors {
+			k, ok := e(r)
+			if !ok {
+				return "", false
+			}
+			parts = append(parts, k)
+		}
+		return strings.Join(parts, sep), len(parts) > 0
+	}
+}
+
+// Metadata extracts the first value of the gRPC metadata key. Keys are matched lower-cased,
+// like grpc-go stores them.
+func Metadata(md MetadataFunc, key string) GRPC {
+	key = strings.ToLower(key)
+	return func(ctx context.Context, req interface{}) (string, bool) {
+		m, ok := md(ctx)
+		if !ok {
+			return "", false
+		}
+		if v := m[key]; len(v) > 0 && v[0] != "" {
+			return v[0], true
+		}
+		return "", false
+	}
+}
+
+// FirstGRPC returns the key of the first extractor that finds one.
+func FirstGRPC(extractors ...GRPC) GRPC {
+	return func(ctx context.Context, req interface{}) (string, bool) {
+		for _, e := range extractors {
+			if k, ok := e(ctx, req); ok {
+				return k, true
+			}
+		}
+		return "", false
+	}
+}
+
+// LookupHTTP extracts the key of r with e and returns its owner in l.
+func LookupHTTP(l consistent.Locator, e HTTP, r *http.Request) (string, error) {
+	k, ok := e(r)
+	if !ok {
+		return "", ErrNoKey
+	}
+	return l.Get(k)
+}
+
+// LookupGRPC extracts the key of a call with e and returns its owner in l.
+func LookupGRPC(ctx context.Context, l consistent.Locator, e GRPC, req interface{}) (string, error) {
+	k, ok := e(ctx, req)
+	if !ok {
+		return "", ErrNoKey
+	}
+	return l.Get(k)
+}
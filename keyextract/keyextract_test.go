@@ -0,0 +1,112 @@
+package keyextract
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jiangz222/consistent"
+)
+
+func TestHTTPExtractors(t *testing.T) {
+	r := httptest.NewRequest("GET", "/users/42/posts?tenant=acme", nil)
+	r.Header.Set("X-User", "u1")
+	r.AddCookie(&http.Cookie{Name: "session", Value: "s1"})
+
+	tests := []struct {
+		e   HTTP
+		key string
+		ok  bool
+	}{
+		{Header("X-User"), "u1", true},
+		{Header("X-Missing"), "", false},
+		{Cookie("session"), "s1", true},
+		{Cookie("missing"), "", false},
+		{Query("tenant"), "acme", true},
+		{PathSegment(1), "42", true},
+		{PathSegment(5), "", false},
+		{First(Header("X-Missing"), Cookie("session")), "s1", true},
+		{Join("/", Query("tenant"), PathSegment(1)), "acme/42", true},
+		{Join("/", Query("tenant"), Header("X-Missing")), "", false},
+	}
+	for i, tt := range tests {
+		key, ok := tt.e(r)
+		if key != tt.key || ok != tt.ok {
+			t.Errorf("%d. got %q, %v, expected %q, %v", i, key, ok, tt.key, tt.ok)
+		}
+	}
+}
+
+func TestLookupHTTP(t *testing.T) {
+	x := consistent.New(consistent.Config{DefaultNumberOfReplicas: 20})
+	x.Add("abcdefg")
+	r := httptest.NewRequest("GET", "/", nil)
+	if _, err := LookupHTTP(x, Header("X-User"), r); err != ErrNoKey {
+		t.Errorf("expected no key error, got %v", err)
+	}
+	r.Header.Set("X-User", "u1")
+	if m, err := LookupHTTP(x, Header("X-User"), r); err != nil || m != "abcdefg" {
+		t.Errorf("got %q, %v", m, err)
+	}
+}
+
+type incomingKey struct{}
+
+func incoming(ctx context.Context) (map[string][]string, bool) {
+	md, ok := ctx.Value(incomingKey{}).(map[string][]string)
+	return md, ok
+}
+
+type user struct {
+	Id int64 `protobuf:"varint,1,opt,name=id,proto3"`
+}
+
+type Location struct {
+	Region string `protobuf:"bytes,3,opt,name=region,proto3"`
+}
+
+type getRequest struct {
+	sizeCache  int32
+	TenantName string `protobuf:"bytes,1,opt,name=tenant_name,json=tenantName,proto3"`
+	User       *user  `protobuf:"bytes,2,opt,name=user,proto3"`
+	*Location
+}
+
+func TestGRPCExtractors(t *testing.T) {
+	ctx := context.WithValue(context.Background(), incomingKey{}, map[string][]string{"x-user": {"u1"}})
+	req := &getRequest{TenantName: "acme", User: &user{Id: 42}}
+
+	tests := []struct {
+		e   GRPC
+		key string
+		ok  bool
+	}{
+		{Metadata(incoming, "X-User"), "u1", true},
+		{Metadata(incoming, "x-missing"), "", false},
+		{ProtoField("tenant_name"), "acme", true},
+		{ProtoField("tenantName"), "acme", true},
+		{ProtoField("TenantName"), "acme", true},
+		{ProtoField("user.id"), "42", true},
+		{ProtoField("user.missing"), "", false},
+		{ProtoField("sizeCache"), "", false},
+		{ProtoField("Region"), "", false},
+		{ProtoField("location"), "", false},
+		{FirstGRPC(Metadata(incoming, "x-missing"), ProtoField("user.id")), "42", true},
+	}
+	for i, tt := range tests {
+		key, ok := tt.e(ctx, req)
+		if key != tt.key || ok != tt.ok {
+			t.Errorf("%d. got %q, %v, expected %q, %v", i, key, ok, tt.key, tt.ok)
+		}
+	}
+	if key, _ := ProtoField("Region")(ctx, &getRequest{Location: &Location{Region: "eu"}}); key != "eu" {
+		t.Errorf("got %q, expected the promoted field", key)
+	}
+	if _, ok := ProtoField("user.id")(ctx, &getRequest{}); ok {
+		t.Errorf("expected no key for a nil nested message")
+	}
+	if _, ok := Metadata(incoming, "x-user")(context.Background(), nil); ok {
+		t.Errorf("expected no key without metadata")
+	}
+}
@@ -0,0 +1,86 @@
+package keyextract
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ProtoField extracts a field of the request message by its dotted protobuf field path,
+// e.g. "user.id". Fields are resolved through the `protobuf:"...,name=..."` tags of the
+// generated Go structs, falling back to the Go field name, so no protobuf runtime is needed.
+// Strings, integers, floats, bools and []byte are converted to their text form.
+func ProtoField(path string) GRPC {
+	names := strings.Split(path, ".")
+	return func(ctx context.Context, req interface{}) (string, bool) {
+		v := reflect.ValueOf(req)
+		for _, name := range names {
+			for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+				if v.IsNil() {
+					return "", false
+				}
+				v = v.Elem()
+			}
+			if v.Kind() != reflect.Struct {
+				return "", false
+			}
+			f, ok := protoFieldByName(v.Type(), name)
+			if !ok {
+				return "", false
+			}
+			for i, x := range f.Index {
+				if i > 0 && v.Kind() == reflect.Ptr {
+					// promoted through an embedded pointer
+					if v.IsNil() {
+						return "", false
+					}
+					v = v.Elem()
+				}
+				v = v.Field(x)
+			}
+		}
+		return scalarString(v)
+	}
+}
+
+func protoFieldByName(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		for _, part := range strings.Split(f.Tag.Get("protobuf"), ",") {
+			if part == "name="+name || part == "json="+name {
+				return f, true
+			}
+		}
+	}
+	// unexported fields, such as the internal state of generated messages, cannot be read
+	if f, ok := t.FieldByName(name); ok && f.PkgPath == "" {
+		return f, true
+	}
+	return reflect.StructField{}, false
+}
+
+func scalarString(v reflect.Value) (string, bool) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "", false
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), v.Len() > 0
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return string(v.Bytes()), v.Len() > 0
+		}
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return fmt.Sprint(v.Interface()), true
+	}
+	return "", false
+}
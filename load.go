@@ -0,0 +1,174 @@
+package consistent
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// DefaultLoadFactor is the load factor of GetLeast when Config.LoadFactor is not set.
+const DefaultLoadFactor = 1.25
+
+// loadTracker counts the requests in flight per member for consistent hashing with bounded
+// loads (Mirrokni, Thorup and Zadimoghaddam), and with Config.LoadHalfLife the recent load
+// observed per member, decaying exponentially.
+type loadTracker struct {
+	mu     sync.Mutex
+	factor float64
+	loads  map[string]int64
+	total  int64
+
+	halfLife     time.Duration
+	now          func() time.Time
+	decayed      map[string]decayedLoad
+	decayedTotal decayedLoad
+}
+
+// decayedLoad is a load of v at time at, halving every half-life since.
+type decayedLoad struct {
+	v  float64
+	at time.Time
+}
+
+func (d decayedLoad) value(now time.Time, halfLife time.Duration) float64 {
+	if d.v == 0 {
+		return 0
+	}
+	return d.v * math.Exp2(-float64(now.Sub(d.at))/float64(halfLife))
+}
+
+// GetLeast returns the first of the owners GetN returns for name whose load, as counted by
+// Inc and Done, is below the bound ceil(LoadFactor * (total load + 1) / members), so no member gets
+// more than LoadFactor times its fair share of the requests in flight. Callers call Inc on
+// the member before using it and Done once finished. With Config.LoadHalfLife, the load of
+// a member also includes the decayed costs recorded by Observe.
+func (c *Consistent) GetLeast(name string) (string, error) {
+	c.RLock()
+	defer c.RUnlock()
+	if len(c.sortedHashes) == 0 {
+		return "", ErrEmptyCircle
+	}
+	l := &c.load
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	factor := l.factor
+	if factor <= 0 {
+		factor = DefaultLoadFactor
+	}
+	now := l.clock()
+	total := float64(l.total) + l.decayedTotal.value(now, l.halfLife)
+	bound := math.Ceil(factor * (total + 1) / float64(len(c.members)))
+	res, _, err := c.lookup(name, 1, 0, func(m string) bool { return l.score(m, now) >= bound })
+	if err != nil {
+		return "", err
+	}
+	if len(res) == 0 {
+		// every member is loaded: fall back to the owner
+		return c.owner(name)
+	}
+	return res[0], nil
+}
+
+// Inc counts one more request in flight on member.
+func (c *Consistent) Inc(member string) {
+	l := &c.load
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.loads == nil {
+		l.loads = make(map[string]int64)
+	}
+	l.loads[member]++
+	l.total++
+}
+
+// Done counts one less request in flight on member.
+func (c *Consistent) Done(member string) {
+	l := &c.load
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.loads[member] <= 0 {
+		return
+	}
+	l.loads[member]--
+	l.total--
+	if l.loads[member] == 0 {
+		delete(l.loads, member)
+	}
+}
+
+// Observe records a load of cost on member, such as 1 for a short request or its duration
+// in seconds, counting towards the load of member for GetLeast and decaying by half every
+// Config.LoadHalfLife, so short requests share the load model of the long-lived
+// connections counted by Inc and Done. It does nothing unless Config.LoadHalfLife is set.
+func (c *Consistent) Observe(member string, cost float64) {
+	l := &c.load
+	if l.halfLife <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.clock()
+	if l.decayed == nil {
+		l.decayed = make(map[string]decayedLoad)
+	}
+	l.decayed[member] = decayedLoad{l.decayed[member].value(now, l.halfLife) + cost, now}
+	l.decayedTotal = decayedLoad{l.decayedTotal.value(now, l.halfLife) + cost, now}
+}
+
+// need l.mu locked before calling
+func (l *loadTracker) clock() time.Time {
+	if l.halfLife <= 0 {
+		return time.Time{}
+	}
+	if l.now != nil {
+		return l.now()
+	}
+	return time.Now()
+}
+
+// need l.mu locked before calling
+// score returns the load of member at now: its requests in flight plus its decayed load.
+func (l *loadTracker) score(member string, now time.Time) float64 {
+	return float64(l.loads[member]) + l.decayed[member].value(now, l.halfLife)
+}
+
+func (l *loadTracker) forget(member string) {
+	l.mu.Lock()
+	l.total -= l.loads[member]
+	delete(l.loads, member)
+	if d, ok := l.decayed[member]; ok {
+		now := l.clock()
+		l.decayedTotal = decayedLoad{math.Max(l.decayedTotal.value(now, l.halfLife)-d.value(now, l.halfLife), 0), now}
+		delete(l.decayed, member)
+	}
+	l.mu.Unlock()
+}
+
+// Loads returns the number of requests in flight per member, as counted by Inc and Done.
+func (c *Consistent) Loads() map[string]int64 {
+	l := &c.load
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	res := make(map[string]int64, len(l.loads))
+	for m, n := range l.loads {
+		res[m] = n
+	}
+	return res
+}
+
+// LoadScores returns the load per member GetLeast compares: the requests in flight counted
+// by Inc and Done plus, with Config.LoadHalfLife, the decayed costs recorded by Observe.
+func (c *Consistent) LoadScores() map[string]float64 {
+	l := &c.load
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.clock()
+	res := make(map[string]float64, len(l.loads)+len(l.decayed))
+	for m := range l.loads {
+		res[m] = l.score(m, now)
+	}
+	for m := range l.decayed {
+		res[m] = l.score(m, now)
+	}
+	return res
+}
@@ -0,0 +1,117 @@
+package consistent
+
+import (
+	"math"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestGetLeast(t *testing.T) {
+	x := New(Config{DefaultNumberOfReplicas: 20, LoadFactor: 1.25})
+	x.Set([]string{"a", "b", "c", "d"})
+	if m, _ := x.GetLeast("hot"); m != mustGet(t, x, "hot") {
+		t.Errorf("expected the owner without load, got %s", m)
+	}
+	// every request goes to the same hot key and stays in flight
+	for i := 1; i <= 100; i++ {
+		m, err := x.GetLeast("hot")
+		if err != nil {
+			t.Fatal(err)
+		}
+		x.Inc(m)
+		bound := int64(math.Ceil(1.25 * float64(i) / 4))
+		for member, n := range x.Loads() {
+			if n > bound {
+				t.Fatalf("after %d requests %s has %d, bound %d", i, member, n, bound)
+			}
+		}
+	}
+	loads := x.Loads()
+	if len(loads) != 4 {
+		t.Errorf("expected the hot key to spread over every member, got %v", loads)
+	}
+	for m, n := range loads {
+		for i := int64(0); i < n; i++ {
+			x.Done(m)
+		}
+	}
+	x.Done("a")
+	if len(x.Loads()) != 0 {
+		t.Errorf("unexpected loads %v", x.Loads())
+	}
+	for i := 0; i < 50; i++ {
+		k := "user" + strconv.Itoa(i)
+		if m, _ := x.GetLeast(k); m != mustGet(t, x, k) {
+			t.Errorf("%s: expected the owner without load, got %s", k, m)
+		}
+	}
+	if _, err := New(newConfig()).GetLeast("ggg"); err != ErrEmptyCircle {
+		t.Errorf("got %v, expected ErrEmptyCircle", err)
+	}
+}
+
+func mustGet(t *testing.T, x *Consistent, k string) string {
+	m, err := x.Get(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestLoadForgottenOnRemove(t *testing.T) {
+	x := New(newConfig())
+	x.Set([]string{"a", "b"})
+	x.Inc("a")
+	x.Inc("b")
+	x.Remove("a")
+	if l := x.Loads(); len(l) != 1 || l["b"] != 1 {
+		t.Errorf("unexpected loads %v", l)
+	}
+}
+
+func TestObserveDecays(t *testing.T) {
+	x := New(Config{DefaultNumberOfReplicas: 20, LoadHalfLife: time.Second})
+	now := time.Unix(1000, 0)
+	x.load.now = func() time.Time { return now }
+	x.Set([]string{"a", "b", "c", "d"})
+	owner := mustGet(t, x, "hot")
+
+	// short requests on the owner push the hot key to other members
+	for i := 0; i < 10; i++ {
+		x.Observe(owner, 1)
+	}
+	if s := x.LoadScores()[owner]; s != 10 {
+		t.Errorf("got score %v, expected 10", s)
+	}
+	if m, _ := x.GetLeast("hot"); m == owner {
+		t.Errorf("expected a member other than the loaded owner %s", owner)
+	}
+	// a long-lived connection counts along with the decayed load
+	x.Inc(owner)
+	now = now.Add(time.Second)
+	if s := x.LoadScores()[owner]; math.Abs(s-6) > 1e-9 {
+		t.Errorf("got score %v after a half-life, expected 6", s)
+	}
+	x.Done(owner)
+	now = now.Add(time.Minute)
+	if m, _ := x.GetLeast("hot"); m != owner {
+		t.Errorf("expected the owner once its load decayed, got %s", m)
+	}
+
+	x.Observe(owner, 5)
+	x.Remove(owner)
+	if s, ok := x.LoadScores()[owner]; ok {
+		t.Errorf("removed member still has score %v", s)
+	}
+	if total := x.load.decayedTotal.value(now, time.Second); total > 1e-9 {
+		t.Errorf("decayed total is %v after removing the only loaded member", total)
+	}
+
+	y := New(newConfig())
+	y.Add("a")
+	y.Observe("a", 1)
+	if len(y.LoadScores()) != 0 {
+		t.Errorf("Observe without LoadHalfLife recorded %v", y.LoadScores())
+	}
+}
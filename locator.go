@@ -0,0 +1,12 @@
+package consistent
+
+// Locator is the read side of a ring: it maps keys to members. Code that only routes keys
+// can depend on a Locator instead of a *Consistent, and use consistenttest.Fake in tests.
+type Locator interface {
+	Get(name string) (string, error)
+	GetTwo(name string) (string, string, error)
+	GetN(name string, n int) ([]string, error)
+	Members() []string
+}
+
+var _ Locator = (*Consistent)(nil)
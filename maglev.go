@@ -0,0 +1,187 @@
+package consistent
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/jiangz222/consistent/core"
+)
+
+// DefaultMaglevTableSize is the size of the lookup table of a Maglev when none is given,
+// a prime giving about a hundred entries per member to several hundred members.
+const DefaultMaglevTableSize = 65537
+
+// ErrMaglevTableSize is returned by NewMaglev for a table size that is not a prime.
+var ErrMaglevTableSize = errors.New("consistent: Maglev table size must be a prime")
+
+// Maglev maps keys to members with Maglev hashing: a lookup table in which every member
+// fills an equal share of the entries, each following its own permutation. A lookup is a
+// single table index rather than a binary search, for load balancers doing millions of
+// lookups per second. A change of membership rebuilds the table, and moves slightly more
+// keys than a ring would.
+type Maglev struct {
+	mu      sync.RWMutex
+	size    uint64
+	members []string // sorted
+	table   []int32  // member index per entry
+}
+
+var _ Locator = (*Maglev)(nil)
+
+// NewMaglev creates an empty Maglev with a lookup table of tableSize entries, a prime
+// well above the number of members for an even balance, or DefaultMaglevTableSize if 0.
+func NewMaglev(tableSize int) (*Maglev, error) {
+	if tableSize == 0 {
+		tableSize = DefaultMaglevTableSize
+	}
+	if !isPrime(tableSize) {
+		return nil, ErrMaglevTableSize
+	}
+	return &Maglev{size: uint64(tableSize)}, nil
+}
+
+func isPrime(n int) bool {
+	if n < 2 {
+		return false
+	}
+	for d := 2; d*d <= n; d++ {
+		if n%d == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Add inserts elt and rebuilds the table. If elt is already a member it does nothing.
+func (m *Maglev) Add(elt string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := sort.SearchStrings(m.members, elt)
+	if i < len(m.members) && m.members[i] == elt {
+		return
+	}
+	m.members = append(m.members, "")
+	copy(m.members[i+1:], m.members[i:])
+	m.members[i] = elt
+	m.populate()
+}
+
+// Remove removes elt and rebuilds the table.
+func (m *Maglev) Remove(elt string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := sort.SearchStrings(m.members, elt)
+	if i == len(m.members) || m.members[i] != elt {
+		return
+	}
+	m.members = append(m.members[:i], m.members[i+1:]...)
+	m.populate()
+}
+
+// Set makes the membership elts with a single rebuild of the table.
+func (m *Maglev) Set(elts []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	seen := make(map[string]bool, len(elts))
+	m.members = m.members[:0]
+	for _, e := range elts {
+		if !seen[e] {
+			seen[e] = true
+			m.members = append(m.members, e)
+		}
+	}
+	sort.Strings(m.members)
+	m.populate()
+}
+
+// Members returns the members, sorted.
+func (m *Maglev) Members() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]string(nil), m.members...)
+}
+
+// Get returns the member of the table entry of name.
+func (m *Maglev) Get(name string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if len(m.members) == 0 {
+		return "", ErrEmptyCircle
+	}
+	return m.members[m.table[core.HashXXH64(name)%m.size]], nil
+}
+
+// GetTwo returns the first two distinct members from the table entry of name on, the
+// second "" if there is a single member.
+func (m *Maglev) GetTwo(name string) (string, string, error) {
+	res, err := m.GetN(name, 2)
+	if err != nil {
+		return "", "", err
+	}
+	if len(res) == 1 {
+		return res[0], "", nil
+	}
+	return res[0], res[1], nil
+}
+
+// GetN returns the first n distinct members from the table entry of name on, the first
+// being the one Get returns.
+func (m *Maglev) GetN(name string, n int) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if len(m.members) == 0 {
+		return nil, ErrEmptyCircle
+	}
+	if n > len(m.members) {
+		n = len(m.members)
+	}
+	res := make([]string, 0, n)
+	seen := make(map[int32]bool, n)
+	start := core.HashXXH64(name) % m.size
+	for j := uint64(0); j < m.size && len(res) < n; j++ {
+		i := m.table[(start+j)%m.size]
+		if !seen[i] {
+			seen[i] = true
+			res = append(res, m.members[i])
+		}
+	}
+	return res, nil
+}
+
+// need m.mu locked before calling
+// populate fills the table, each member in turn taking the next free entry of its
+// permutation, offset + j*skip modulo the table size.
+func (m *Maglev) populate() {
+	if len(m.members) == 0 {
+		m.table = nil
+		return
+	}
+	offsets := make([]uint64, len(m.members))
+	skips := make([]uint64, len(m.members))
+	next := make([]uint64, len(m.members))
+	for i, e := range m.members {
+		offsets[i] = core.HashXXH64(e) % m.size
+		skips[i] = uint64(core.HashMurmur3(e))%(m.size-1) + 1
+	}
+	table := make([]int32, m.size)
+	for i := range table {
+		table[i] = -1
+	}
+	for filled := uint64(0); ; {
+		for i := range m.members {
+			c := (offsets[i] + next[i]*skips[i]) % m.size
+			for table[c] >= 0 {
+				next[i]++
+				c = (offsets[i] + next[i]*skips[i]) % m.size
+			}
+			table[c] = int32(i)
+			next[i]++
+			filled++
+			if filled == m.size {
+				m.table = table
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package consistent
+
+import (
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestMaglev(t *testing.T) {
+	if _, err := NewMaglev(100); err != ErrMaglevTableSize {
+		t.Errorf("got %v, expected ErrMaglevTableSize", err)
+	}
+	m, err := NewMaglev(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Get("key"); err != ErrEmptyCircle {
+		t.Errorf("got %v, expected ErrEmptyCircle", err)
+	}
+	for i := 0; i < 10; i++ {
+		m.Add("m" + strconv.Itoa(i))
+	}
+	counts := make(map[int32]int)
+	for _, i := range m.table {
+		counts[i]++
+	}
+	for i, n := range counts {
+		if math.Abs(float64(n)-DefaultMaglevTableSize/10) > DefaultMaglevTableSize/100 {
+			t.Errorf("member %d has %d entries, expected about %d", i, n, DefaultMaglevTableSize/10)
+		}
+	}
+
+	const keys = 10000
+	before := make(map[string]string)
+	for i := 0; i < keys; i++ {
+		k := "key" + strconv.Itoa(i)
+		before[k], _ = m.Get(k)
+		res, _ := m.GetN(k, 3)
+		if len(res) != 3 || res[0] != before[k] || res[1] == res[0] || res[2] == res[1] {
+			t.Errorf("%s: GetN gives %q", k, res)
+		}
+	}
+	m.Remove("m3")
+	moved := 0
+	for k, old := range before {
+		if n, _ := m.Get(k); n != old {
+			moved++
+			if n == "m3" {
+				t.Errorf("%s still on a removed member", k)
+			}
+		}
+	}
+	// the keys of m3 move, and few others
+	if moved > keys/10+keys/50 {
+		t.Errorf("%d keys moved, expected about %d", moved, keys/10)
+	}
+
+	n, _ := NewMaglev(0)
+	n.Set([]string{"m9", "m0", "m1", "m2", "m4", "m5", "m6", "m7", "m8"})
+	for k := range before {
+		a, _ := m.Get(k)
+		b, _ := n.Get(k)
+		if a != b {
+			t.Fatalf("%s: tables built by Set and Add/Remove differ", k)
+		}
+	}
+}
+
+func BenchmarkMaglevGet(b *testing.B) {
+	m, _ := NewMaglev(0)
+	for i := 0; i < 100; i++ {
+		m.Add("m" + strconv.Itoa(i))
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Get("key")
+	}
+}
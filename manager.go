@@ -0,0 +1,174 @@
+package consistent
+
+import (
+	"sort"
+	"sync"
+)
+
+// Manager owns named rings, typically one per backend pool of a service, all created from
+// the same Config so they share its hasher, rate tracking, guard and other settings.
+type Manager struct {
+	conf Config
+
+	mu        sync.RWMutex
+	rings     map[string]*Consistent
+	cancels   map[string]func()
+	next      int
+	listeners []ringListener
+}
+
+type ringListener struct {
+	id int
+	fn func(ring string, ev ChangeEvent)
+}
+
+// NewManager creates a Manager creating its rings with conf.
+func NewManager(conf Config) *Manager {
+	return &Manager{
+		conf:    conf,
+		rings:   make(map[string]*Consistent),
+		cancels: make(map[string]func()),
+	}
+}
+
+// Ring returns the ring called name, creating an empty one if needed.
+func (m *Manager) Ring(name string) *Consistent {
+	m.mu.RLock()
+	c, ok := m.rings[name]
+	m.mu.RUnlock()
+	if ok {
+		return c
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if c, ok := m.rings[name]; ok {
+		return c
+	}
+	conf := m.conf
+	if conf.ProfileName != "" {
+		conf.ProfileName += "/" + name
+	}
+	c = New(conf)
+	m.rings[name] = c
+	m.cancels[name] = c.OnChange(func(ev ChangeEvent) { m.notify(name, ev) })
+	return c
+}
+
+// Lookup returns the ring called name, if there is one.
+func (m *Manager) Lookup(name string) (*Consistent, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	c, ok := m.rings[name]
+	return c, ok
+}
+
+// Names returns the names of the rings, sorted.
+func (m *Manager) Names() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	names := make([]string, 0, len(m.rings))
+	for name := range m.rings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Delete forgets the ring called name, reporting whether there was one. Its changes are
+// no longer reported to the listeners of m.
+func (m *Manager) Delete(name string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.rings[name]; !ok {
+		return false
+	}
+	m.cancels[name]()
+	delete(m.rings, name)
+	delete(m.cancels, name)
+	return true
+}
+
+// Snapshot returns the snapshot of every ring, by name.
+func (m *Manager) Snapshot() map[string]Snapshot {
+	m.mu.RLock()
+	rings := make(map[string]*Consistent, len(m.rings))
+	for name, c := range m.rings {
+		rings[name] = c
+	}
+	m.mu.RUnlock()
+	res := make(map[string]Snapshot, len(rings))
+	for name, c := range rings {
+		res[name] = c.Snapshot()
+	}
+	return res
+}
+
+// Restore restores every ring named in snaps, creating the missing ones. Rings not named in
+// snaps are left as they are.
+func (m *Manager) Restore(snaps map[string]Snapshot) {
+	for name, s := range snaps {
+		m.Ring(name).Restore(s)
+	}
+}
+
+// OnChange registers fn to be called after every membership change of any ring of m, with
+// the name of the ring. It runs like the functions registered with Consistent.OnChange.
+// The returned function unregisters fn.
+func (m *Manager) OnChange(fn func(ring string, ev ChangeEvent)) (cancel func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id := m.next
+	m.next++
+	m.listeners = append(m.listeners, ringListener{id, fn})
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		for i, v := range m.listeners {
+			if v.id == id {
+				m.listeners = append(m.listeners[:i:i], m.listeners[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func (m *Manager) notify(ring string, ev ChangeEvent) {
+	m.mu.RLock()
+	list := m.listeners
+	m.mu.RUnlock()
+	for _, v := range list {
+		v.fn(ring, ev)
+	}
+}
+
+// StatsSnapshot returns the statistics of every ring, by name, and their total: counters,
+// members, vnodes and rebuilds are summed, LastChange is the latest and Imbalance the
+// largest. The fields describing the last rebuild are left zero.
+func (m *Manager) StatsSnapshot() (total Stats, rings map[string]Stats) {
+	m.mu.RLock()
+	list := make(map[string]*Consistent, len(m.rings))
+	for name, c := range m.rings {
+		list[name] = c
+	}
+	m.mu.RUnlock()
+	rings = make(map[string]Stats, len(list))
+	for name, c := range list {
+		s := c.StatsSnapshot()
+		rings[name] = s
+		total.Lookups += s.Lookups
+		total.Errors += s.Errors
+		total.Writes += s.Writes
+		total.Version += s.Version
+		total.Members += s.Members
+		total.Vnodes += s.Vnodes
+		total.Rebuilds += s.Rebuilds
+		total.RebuildTime += s.RebuildTime
+		if s.LastChange.After(total.LastChange) {
+			total.LastChange = s.LastChange
+		}
+		if s.Imbalance > total.Imbalance {
+			total.Imbalance = s.Imbalance
+		}
+	}
+	return total, rings
+}
@@ -0,0 +1,42 @@
+package consistent
+
+import "testing"
+
+func TestManager(t *testing.T) {
+	m := NewManager(Config{DefaultNumberOfReplicas: 10, UseFnv: true})
+	var rings []string
+	cancel := m.OnChange(func(ring string, ev ChangeEvent) { rings = append(rings, ring) })
+	m.Ring("cache").Set([]string{"a", "b"})
+	m.Ring("db").Add("c")
+	if m.Ring("cache") != m.Ring("cache") || !m.Ring("db").useFnv {
+		t.Errorf("expected rings to be reused and share the config")
+	}
+	checkNum(len(m.Ring("db").circle), 10, t)
+	if len(rings) != 2 || rings[0] != "cache" || rings[1] != "db" {
+		t.Errorf("unexpected events for %v", rings)
+	}
+
+	snaps := m.Snapshot()
+	n := NewManager(Config{DefaultNumberOfReplicas: 10, UseFnv: true})
+	n.Restore(snaps)
+	if names := n.Names(); len(names) != 2 || names[0] != "cache" || names[1] != "db" {
+		t.Fatalf("unexpected rings %v", names)
+	}
+	a, _ := m.Ring("cache").Get("ggg")
+	b, _ := n.Ring("cache").Get("ggg")
+	if a != b {
+		t.Errorf("got %s, expected %s", b, a)
+	}
+
+	db, _ := m.Lookup("db")
+	if !m.Delete("db") || m.Delete("db") {
+		t.Errorf("unexpected Delete results")
+	}
+	if _, ok := m.Lookup("db"); ok {
+		t.Errorf("expected db to be gone")
+	}
+	db.Add("d")
+	cancel()
+	m.Ring("cache").Add("e")
+	checkNum(len(rings), 2, t)
+}
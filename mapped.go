@@ -0,0 +1,251 @@
+package consistent
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sort"
+)
+
+// File layout written by WriteMapped, all integers little endian:
+//
+//	magic    [4]byte "CHRM"
+//	version  uint32
+//	hasher   uint32  (mappedCRC32 ... mappedMurmur3)
+//	points   uint32
+//	members  uint32
+//	hashes   [points]uint32, sorted
+//	owners   [points]uint32, index into the member table
+//	table    [members]{offset, length uint32}, into the names blob
+//	names    []byte
+const (
+	mappedMagic      = "CHRM"
+	mappedVersion    = 1
+	mappedHeaderSize = 20
+
+	mappedCRC32   = 0
+	mappedFnv     = 1
+	mappedCustom  = 2
+	mappedKetama  = 3
+	mappedXXH32   = 4
+	mappedXXH64   = 5
+	mappedMurmur3 = 6
+)
+
+var (
+	// ErrBadMappedRing is returned when a file is not a ring written by WriteMapped.
+	ErrBadMappedRing = errors.New("consistent: invalid mapped ring")
+	// ErrMappedHasher is returned when a ring written with a custom hasher is opened without one.
+	ErrMappedHasher = errors.New("consistent: mapped ring needs its custom hasher")
+)
+
+// hasherKind returns the mapped hasher constant of the hasher of c.
+func (c *Consistent) hasherKind() uint32 {
+	switch {
+	case c.ketama:
+		return mappedKetama
+	case c.customHasher != nil:
+		return mappedCustom
+	case c.useFnv:
+		return mappedFnv
+	case c.algorithm == HashXXH32:
+		return mappedXXH32
+	case c.algorithm == HashXXH64:
+		return mappedXXH64
+	case c.algorithm == HashMurmur3:
+		return mappedMurmur3
+	}
+	return mappedCRC32
+}
+
+// WriteMapped writes the current circle to w in a read-only format that OpenMapped can
+// memory-map, so several processes on a host can share one copy of a large ring.
+func (c *Consistent) WriteMapped(w io.Writer) error {
+	c.RLock()
+	defer c.RUnlock()
+
+	hasher := c.hasherKind()
+
+	names := make([]string, 0, len(c.members))
+	ids := make(map[string]uint32, len(c.members))
+	for m := range c.members {
+		names = append(names, m)
+	}
+	sort.Strings(names)
+	for i, m := range names {
+		ids[m] = uint32(i)
+	}
+
+	bw := bufio.NewWriter(w)
+	var buf [4]byte
+	put := func(v uint32) {
+		binary.LittleEndian.PutUint32(buf[:], v)
+		bw.Write(buf[:])
+	}
+	bw.WriteString(mappedMagic)
+	put(mappedVersion)
+	put(hasher)
+	put(uint32(len(c.sortedHashes)))
+	put(uint32(len(names)))
+	for _, h := range c.sortedHashes {
+		put(h)
+	}
+	for _, h := range c.sortedHashes {
+		put(ids[c.circle[h]])
+	}
+	offset := uint32(0)
+	for _, m := range names {
+		put(offset)
+		put(uint32(len(m)))
+		offset += uint32(len(m))
+	}
+	for _, m := range names {
+		bw.WriteString(m)
+	}
+	return bw.Flush()
+}
+
+// MappedRing is a read-only ring backed by a file written with WriteMapped.
+type MappedRing struct {
+	data    []byte
+	hasher  uint32
+	custom  Hasher
+	points  int
+	members []string
+	unmap   func() error
+}
+
+// OpenMapped maps the ring file at path. h must be the custom hasher the ring was written
+// with, or nil for rings using the builtin hashers.
+func OpenMapped(path string, h Hasher) (*MappedRing, error) {
+	data, unmap, err := mapFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m, err := newMappedRing(data, h)
+	if err != nil {
+		unmap()
+		return nil, err
+	}
+	m.unmap = unmap
+	return m, nil
+}
+
+func newMappedRing(data []byte, h Hasher) (*MappedRing, error) {
+	if len(data) < mappedHeaderSize || string(data[:4]) != mappedMagic {
+		return nil, ErrBadMappedRing
+	}
+	if binary.LittleEndian.Uint32(data[4:]) != mappedVersion {
+		return nil, ErrBadMappedRing
+	}
+	m := &MappedRing{
+		data:   data,
+		hasher: binary.LittleEndian.Uint32(data[8:]),
+		custom: h,
+		points: int(binary.LittleEndian.Uint32(data[12:])),
+	}
+	if m.hasher == mappedCustom && h == nil {
+		return nil, ErrMappedHasher
+	}
+	n := int(binary.LittleEndian.Uint32(data[16:]))
+	table := mappedHeaderSize + 8*m.points
+	blob := table + 8*n
+	if blob > len(data) {
+		return nil, ErrBadMappedRing
+	}
+	m.members = make([]string, n)
+	for i := range m.members {
+		off := blob + int(binary.LittleEndian.Uint32(data[table+8*i:]))
+		l := int(binary.LittleEndian.Uint32(data[table+8*i+4:]))
+		if off+l > len(data) {
+			return nil, ErrBadMappedRing
+		}
+		m.members[i] = string(data[off : off+l])
+	}
+	for i := 0; i < m.points; i++ {
+		if int(m.owner(i)) >= n {
+			return nil, ErrBadMappedRing
+		}
+	}
+	return m, nil
+}
+
+// Close unmaps the file. The ring must not be used afterwards.
+func (m *MappedRing) Close() error {
+	if m.unmap == nil {
+		return nil
+	}
+	err := m.unmap()
+	m.unmap = nil
+	m.data = nil
+	return err
+}
+
+// Members returns the members of the mapped ring.
+func (m *MappedRing) Members() []string {
+	return append([]string(nil), m.members...)
+}
+
+// Get returns an element close to where name hashes to in the circle.
+func (m *MappedRing) Get(name string) (string, error) {
+	if m.points == 0 {
+		return "", ErrEmptyCircle
+	}
+	return m.members[m.owner(m.search(m.hashKey(name)))], nil
+}
+
+// GetN returns the N closest distinct elements to the name input in the circle.
+func (m *MappedRing) GetN(name string, n int) ([]string, error) {
+	if m.points == 0 {
+		return nil, ErrEmptyCircle
+	}
+	if n > len(m.members) {
+		n = len(m.members)
+	}
+	start := m.search(m.hashKey(name))
+	res := make([]string, 0, n)
+	for j := 0; j < m.points && len(res) < n; j++ {
+		elem := m.members[m.owner((start+j)%m.points)]
+		if !sliceContainsMember(res, elem) {
+			res = append(res, elem)
+		}
+	}
+	return res, nil
+}
+
+func (m *MappedRing) hash(i int) uint32 {
+	return binary.LittleEndian.Uint32(m.data[mappedHeaderSize+4*i:])
+}
+
+func (m *MappedRing) owner(i int) uint32 {
+	return binary.LittleEndian.Uint32(m.data[mappedHeaderSize+4*(m.points+i):])
+}
+
+func (m *MappedRing) search(key uint32) int {
+	i := sort.Search(m.points, func(x int) bool {
+		return m.hash(x) > key
+	})
+	if i >= m.points {
+		i = 0
+	}
+	return i
+}
+
+func (m *MappedRing) hashKey(key string) uint32 {
+	switch m.hasher {
+	case mappedCustom:
+		return m.custom.HashFunc(key)
+	case mappedFnv:
+		return hashKeyFnv(key)
+	case mappedKetama:
+		return ketamaKeyHash(key)
+	case mappedXXH32:
+		return HashXXH32.hash(key)
+	case mappedXXH64:
+		return HashXXH64.hash(key)
+	case mappedMurmur3:
+		return HashMurmur3.hash(key)
+	}
+	return hashKeyCRC32(key)
+}
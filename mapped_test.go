@@ -0,0 +1,112 @@
+package consistent
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func writeMappedFile(t *testing.T, x *Consistent) string {
+	dir, err := ioutil.TempDir("", "consistent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "ring")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := x.WriteMapped(f); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestMappedRing(t *testing.T) {
+	for _, h := range []HashAlgorithm{HashCRC32, HashFnv, HashXXH32, HashXXH64, HashMurmur3} {
+		x := New(Config{DefaultNumberOfReplicas: 20, Hash: h})
+		x.Add("abcdefg")
+		x.Add("hijklmn")
+		x.Add("opqrstu")
+		m, err := OpenMapped(writeMappedFile(t, x), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkNum(len(m.Members()), 3, t)
+		for i := 0; i < 1000; i++ {
+			key := "key" + strconv.Itoa(i)
+			expected, _ := x.Get(key)
+			result, err := m.Get(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result != expected {
+				t.Errorf("%s: got %q, expected %q", key, result, expected)
+			}
+			expectedN, _ := x.GetN(key, 2)
+			resultN, _ := m.GetN(key, 2)
+			if len(resultN) != 2 || resultN[0] != expectedN[0] || resultN[1] != expectedN[1] {
+				t.Errorf("%s: got %q, expected %q", key, resultN, expectedN)
+			}
+		}
+		if err := m.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+type constHasher uint32
+
+func (h constHasher) HashFunc(key string) uint32 { return uint32(h) + uint32(len(key)) }
+
+func TestMappedRingCustomHasher(t *testing.T) {
+	x := New(Config{DefaultNumberOfReplicas: 20, CustomHasher: constHasher(7)})
+	x.Add("abcdefg")
+	path := writeMappedFile(t, x)
+	if _, err := OpenMapped(path, nil); err != ErrMappedHasher {
+		t.Fatalf("expected mapped hasher error, got %v", err)
+	}
+	m, err := OpenMapped(path, constHasher(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	result, err := m.Get("anything")
+	if err != nil || result != "abcdefg" {
+		t.Errorf("got %q, %v", result, err)
+	}
+}
+
+func TestMappedRingInvalid(t *testing.T) {
+	if _, err := newMappedRing([]byte("not a ring at all!!!!"), nil); err != ErrBadMappedRing {
+		t.Errorf("expected bad mapped ring error, got %v", err)
+	}
+	x := New(newConfig())
+	x.Add("abcdefg")
+	data := mappedBytes(t, x)
+	if _, err := newMappedRing(data[:len(data)-3], nil); err != ErrBadMappedRing {
+		t.Errorf("expected bad mapped ring error for truncated data, got %v", err)
+	}
+	m, err := newMappedRing(mappedBytes(t, New(newConfig())), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Get("x"); err != ErrEmptyCircle {
+		t.Errorf("expected empty circle error")
+	}
+}
+
+func mappedBytes(t *testing.T, c *Consistent) []byte {
+	var buf bytes.Buffer
+	if err := c.WriteMapped(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
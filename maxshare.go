@@ -0,0 +1,83 @@
+package consistent
+
+import "sort"
+
+// need c.RLock() before calling
+// capShares drops from hashes, sorted points of circle, those of the members owning more than
+// Config.MaxShare of the circle, so the ranges they owned spill to the next point. A point
+// is only dropped if the member after it stays within the cap, and a member keeps its last
+// point, so a cap no membership can meet is met as closely as possible. The members over
+// the cap are brought back within it one at a time, in name order, each round dropping the
+// point closest to doing so. The shares are computed once and adjusted as points are
+// dropped, so capShares costs O(V) plus, for every member over the cap, its points times
+// the points it drops.
+func (c *Consistent) capShares(circle map[uint32]string, hashes uints) uints {
+	if c.maxShare <= 0 || c.maxShare >= 1 || len(hashes) < 2 {
+		return hashes
+	}
+	limit := uint64(c.maxShare * (1 << 32))
+	n := len(hashes)
+	owned := make(map[string]uint64)
+	points := make(map[string][]int)
+	for i, h := range hashes {
+		m := circle[h]
+		owned[m] += uint64(h - hashes[(i+n-1)%n])
+		points[m] = append(points[m], i)
+	}
+	var over []string
+	for m, o := range owned {
+		if o > limit {
+			over = append(over, m)
+		}
+	}
+	if len(over) == 0 {
+		return hashes
+	}
+	sort.Strings(over)
+
+	// the points left, as a circular doubly linked list
+	prev, next := make([]int, n), make([]int, n)
+	for i := range hashes {
+		prev[i], next[i] = (i+n-1)%n, (i+1)%n
+	}
+	dropped := make([]bool, n)
+	for _, m := range over {
+		mine := points[m]
+		for owned[m] > limit && len(mine) > 1 {
+			excess := owned[m] - limit
+			best, bestArc := -1, uint64(0)
+			for j, i := range mine {
+				after := circle[hashes[next[i]]]
+				arc := uint64(hashes[i] - hashes[prev[i]])
+				if after == m || owned[after]+arc > limit {
+					continue
+				}
+				// the smallest arc removing the excess, else the largest one
+				switch {
+				case best < 0:
+				case arc >= excess && (bestArc < excess || arc < bestArc):
+				case arc < excess && bestArc < excess && arc > bestArc:
+				default:
+					continue
+				}
+				best, bestArc = j, arc
+			}
+			if best < 0 {
+				break
+			}
+			i := mine[best]
+			owned[m] -= bestArc
+			owned[circle[hashes[next[i]]]] += bestArc
+			next[prev[i]], prev[next[i]] = next[i], prev[i]
+			dropped[i] = true
+			mine = append(mine[:best], mine[best+1:]...)
+		}
+	}
+	kept := hashes[:0]
+	for i, h := range hashes {
+		if !dropped[i] {
+			kept = append(kept, h)
+		}
+	}
+	return kept
+}
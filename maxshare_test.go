@@ -0,0 +1,70 @@
+package consistent
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMaxShare(t *testing.T) {
+	conf := newConfig()
+	conf.MaxShare = 0.3
+	x := New(conf)
+	x.Add("big", 200)
+	for _, m := range []string{"a", "b", "c", "d"} {
+		x.Add(m)
+	}
+	check := func() {
+		t.Helper()
+		if err := x.Verify(); err != nil {
+			t.Fatal(err)
+		}
+		for m, share := range ownershipShares(x.sortedHashes, x.circle) {
+			if share > conf.MaxShare {
+				t.Errorf("%s owns %.3f of the circle, above %.2f", m, share, conf.MaxShare)
+			}
+		}
+	}
+	check()
+	if len(x.sortedHashes) == len(x.circle) {
+		t.Errorf("expected points of big to be left out")
+	}
+	x.Remove("d")
+	check()
+	x.Add("e", 100)
+	check()
+
+	// a cap no membership can meet keeps every member routed
+	x.Set([]string{"a", "b"})
+	if err := x.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	shares := ownershipShares(x.sortedHashes, x.circle)
+	if shares["a"] == 0 || shares["b"] == 0 {
+		t.Errorf("expected both members to stay routed, got %v", shares)
+	}
+}
+
+func TestMaxShareQuarantined(t *testing.T) {
+	conf := newConfig()
+	conf.MaxShare = 0.3
+	conf.FlapThreshold = 1
+	conf.FlapCooldown = time.Hour
+	x := New(conf)
+	for i := 0; i < 2; i++ {
+		x.Add("big", 200)
+		x.Add("a")
+		x.Add("b")
+		if i == 0 {
+			x.Set(nil)
+		}
+	}
+	if q := x.Quarantined(); len(q) != 3 {
+		t.Fatalf("got quarantined %v, expected every member", q)
+	}
+	if len(x.sortedHashes) == len(x.circle) {
+		t.Errorf("expected points of big to be left out")
+	}
+	if err := x.Verify(); err != nil {
+		t.Error(err)
+	}
+}
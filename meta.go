@@ -0,0 +1,52 @@
+package consistent
+
+// AddWithMeta inserts elt like Add and attaches meta to it, e.g. its zone, for RemoveFunc
+// and Meta. If elt is already a member only its meta is replaced.
+func (c *Consistent) AddWithMeta(elt string, meta interface{}, numbersOfReplicas ...int) {
+	numberOfReplicas := c.defaultNumberOfReplicas
+	if len(numbersOfReplicas) > 0 {
+		numberOfReplicas = numbersOfReplicas[0]
+	}
+	c.warmupAdd(joiner{SetElt: SetElt{Elt: elt, NumberOfReplicas: numberOfReplicas}}, nil)
+	c.Lock()
+	defer c.unlockAndNotify()
+	if c.metas == nil {
+		c.metas = make(map[string]interface{})
+	}
+	c.metas[elt] = meta
+	if c.members[elt] {
+		return
+	}
+	c.add(elt, numberOfReplicas)
+}
+
+// Meta returns the meta elt was added with, nil if none.
+func (c *Consistent) Meta(elt string) interface{} {
+	c.RLock()
+	defer c.RUnlock()
+	return c.metas[elt]
+}
+
+// RemoveFunc removes every member for which match returns true, e.g. every member of a
+// zone during an incident, in a single change with a single update of the sorted hashes.
+// match is called with the ring locked and must not use it. RemoveFunc returns the number
+// of members removed, 0 if the change is rejected by Config.Guard.
+func (c *Consistent) RemoveFunc(match func(member string, meta interface{}) bool) int {
+	c.Lock()
+	defer c.unlockAndNotify()
+	var removed []string
+	for m := range c.members {
+		if match(m, c.metas[m]) {
+			removed = append(removed, m)
+		}
+	}
+	if len(removed) == 0 || (c.guard != nil && !c.allow(removed, nil)) {
+		return 0
+	}
+	var points uints
+	for _, m := range removed {
+		points = append(points, c.removePoints(m, c.membersReplicas[m])...)
+	}
+	c.deleteSortedHashes(points)
+	return len(removed)
+}
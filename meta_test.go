@@ -0,0 +1,46 @@
+package consistent
+
+import "testing"
+
+func TestRemoveFunc(t *testing.T) {
+	x := New(newConfig())
+	x.AddWithMeta("a", "us-east-1a")
+	x.AddWithMeta("b", "us-east-1c")
+	x.AddWithMeta("c", "us-east-1c")
+	x.Add("d")
+	if x.Meta("b") != "us-east-1c" || x.Meta("d") != nil {
+		t.Errorf("unexpected metas %v, %v", x.Meta("b"), x.Meta("d"))
+	}
+	var events []ChangeEvent
+	x.OnChange(func(ev ChangeEvent) { events = append(events, ev) })
+	rebuilds := x.StatsSnapshot().Rebuilds
+	n := x.RemoveFunc(func(member string, meta interface{}) bool { return meta == "us-east-1c" })
+	if n != 2 {
+		t.Errorf("got %d removed, expected 2", n)
+	}
+	checkNum(len(x.Members()), 2, t)
+	if got := x.StatsSnapshot().Rebuilds - rebuilds; got != 1 {
+		t.Errorf("got %d rebuilds, expected 1", got)
+	}
+	if len(events) != 1 || len(events[0].Removed) != 2 {
+		t.Errorf("expected a single event removing 2 members, got %v", events)
+	}
+	if err := x.Verify(); err != nil {
+		t.Error(err)
+	}
+	if x.Meta("b") != nil {
+		t.Errorf("expected the meta of a removed member to be forgotten")
+	}
+	if n := x.RemoveFunc(func(string, interface{}) bool { return false }); n != 0 {
+		t.Errorf("got %d removed, expected 0", n)
+	}
+}
+
+func TestRemoveFuncGuard(t *testing.T) {
+	x := New(Config{DefaultNumberOfReplicas: 20, Guard: &Guard{MinMembers: 2}})
+	x.Set([]string{"a", "b", "c"})
+	if n := x.RemoveFunc(func(m string, _ interface{}) bool { return m != "a" }); n != 0 {
+		t.Errorf("got %d removed, expected the guard to reject the change", n)
+	}
+	checkNum(len(x.Members()), 3, t)
+}
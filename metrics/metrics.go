@@ -0,0 +1,183 @@
+// Package metrics exposes the health of a ring as Prometheus metrics: its member and vnode
+// counts, the calls to Get per member, the lookups failing on an empty circle and the
+// topology changes.
+//
+// It does not depend on the Prometheus client library. A Collector serves the text
+// exposition format itself, and Collect returns the metrics for an adapter registering
+// them on a prometheus.Registerer, typically:
+//
+//	type promCollector struct{ c *metrics.Collector }
+//
+//	func (p promCollector) Describe(ch chan<- *prometheus.Desc) {
+//		prometheus.DescribeByCollect(p, ch)
+//	}
+//
+//	func (p promCollector) Collect(ch chan<- prometheus.Metric) {
+//		for _, m := range p.c.Collect() {
+//			t := prometheus.GaugeValue
+//			if m.Kind == metrics.Counter {
+//				t = prometheus.CounterValue
+//			}
+//			desc := prometheus.NewDesc(m.Name, m.Help, m.LabelNames, nil)
+//			ch <- prometheus.MustNewConstMetric(desc, t, m.Value, m.LabelValues...)
+//		}
+//	}
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/jiangz222/consistent"
+)
+
+// DefaultNamespace prefixes the metric names unless Collector.Namespace is set.
+const DefaultNamespace = "consistent"
+
+// Kind is the Prometheus type of a metric.
+type Kind int
+
+const (
+	Gauge Kind = iota
+	Counter
+)
+
+func (k Kind) String() string {
+	if k == Counter {
+		return "counter"
+	}
+	return "gauge"
+}
+
+// Metric is one sample of a metric.
+type Metric struct {
+	Name        string
+	Help        string
+	Kind        Kind
+	LabelNames  []string
+	LabelValues []string
+	Value       float64
+}
+
+// Collector gathers the metrics of a ring. It counts the calls to Get, including GetBytes,
+// with a hook and the topology changes with an OnChange listener, from its creation on.
+type Collector struct {
+	// Namespace prefixes the metric names. Defaults to DefaultNamespace.
+	Namespace string
+
+	ring    *consistent.Consistent
+	cancels []func()
+
+	mu      sync.Mutex
+	gets    map[string]uint64
+	empty   uint64
+	changes uint64
+}
+
+// New creates a Collector for ring. Close it to stop counting.
+func New(ring *consistent.Consistent) *Collector {
+	c := &Collector{ring: ring, gets: make(map[string]uint64)}
+	c.cancels = append(c.cancels,
+		ring.AddHook(consistent.Hook{After: c.after}),
+		ring.OnChange(c.changed),
+	)
+	return c
+}
+
+// Close stops counting calls and changes.
+func (c *Collector) Close() {
+	for _, cancel := range c.cancels {
+		cancel()
+	}
+}
+
+func (c *Collector) after(op consistent.Op) {
+	if op.Name != "Get" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch {
+	case op.Err == nil:
+		c.gets[op.Result]++
+	case op.Err == consistent.ErrEmptyCircle:
+		c.empty++
+	}
+}
+
+func (c *Collector) changed(ev consistent.ChangeEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.changes++
+	// removed members no longer get series of their own
+	for _, m := range ev.Removed {
+		delete(c.gets, m)
+	}
+}
+
+// Collect returns the current value of every metric, the calls to Get sorted by member.
+func (c *Collector) Collect() []Metric {
+	ns := c.Namespace
+	if ns == "" {
+		ns = DefaultNamespace
+	}
+	s := c.ring.StatsSnapshot()
+	res := []Metric{
+		{Name: ns + "_members", Help: "Number of members of the ring.", Kind: Gauge, Value: float64(s.Members)},
+		{Name: ns + "_vnodes", Help: "Number of vnodes on the circle.", Kind: Gauge, Value: float64(s.Vnodes)},
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	members := make([]string, 0, len(c.gets))
+	for m := range c.gets {
+		members = append(members, m)
+	}
+	sort.Strings(members)
+	for _, m := range members {
+		res = append(res, Metric{
+			Name:        ns + "_gets_total",
+			Help:        "Calls to Get, by member returned.",
+			Kind:        Counter,
+			LabelNames:  []string{"member"},
+			LabelValues: []string{m},
+			Value:       float64(c.gets[m]),
+		})
+	}
+	return append(res,
+		Metric{Name: ns + "_empty_circle_errors_total", Help: "Calls to Get failing on an empty circle.", Kind: Counter, Value: float64(c.empty)},
+		Metric{Name: ns + "_topology_changes_total", Help: "Changes of the membership of the ring.", Kind: Counter, Value: float64(c.changes)},
+	)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	var b strings.Builder
+	last := ""
+	for _, m := range c.Collect() {
+		if m.Name != last {
+			fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", m.Name, m.Help, m.Name, m.Kind)
+			last = m.Name
+		}
+		b.WriteString(m.Name)
+		if len(m.LabelNames) > 0 {
+			b.WriteByte('{')
+			for i, name := range m.LabelNames {
+				if i > 0 {
+					b.WriteByte(',')
+				}
+				fmt.Fprintf(&b, "%s=\"%s\"", name, labelEscaper.Replace(m.LabelValues[i]))
+			}
+			b.WriteByte('}')
+		}
+		fmt.Fprintf(&b, " %s\n", strconv.FormatFloat(m.Value, 'g', -1, 64))
+	}
+	w.Write([]byte(b.String()))
+}
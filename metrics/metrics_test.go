@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jiangz222/consistent"
+)
+
+func TestCollector(t *testing.T) {
+	ring := consistent.New(consistent.Config{DefaultNumberOfReplicas: 20})
+	c := New(ring)
+	defer c.Close()
+	ring.Get("key")
+	ring.Add("a")
+	ring.Add("b")
+	for _, k := range []string{"k1", "k2", "k3", "k4"} {
+		ring.Get(k)
+	}
+
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE consistent_members gauge\nconsistent_members 2\n",
+		"consistent_vnodes 40\n",
+		"# TYPE consistent_gets_total counter\n",
+		"consistent_empty_circle_errors_total 1\n",
+		"consistent_topology_changes_total 2\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("missing %q in:\n%s", want, body)
+		}
+	}
+	total := 0.0
+	for _, m := range c.Collect() {
+		if m.Name == "consistent_gets_total" {
+			total += m.Value
+		}
+	}
+	if total != 4 {
+		t.Errorf("got %v gets, expected 4", total)
+	}
+
+	ring.Remove("a")
+	for _, m := range c.Collect() {
+		if m.Name == "consistent_gets_total" && m.LabelValues[0] == "a" {
+			t.Errorf("removed member still has a series")
+		}
+	}
+}
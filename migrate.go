@@ -0,0 +1,133 @@
+package consistent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultMigrationConcurrency is the number of ranges a Migration transfers at once when
+// Concurrency is not set.
+const DefaultMigrationConcurrency = 4
+
+// ErrMigrationFailed is returned by Migration.Run when some ranges could not be transferred.
+var ErrMigrationFailed = errors.New("consistent: migration failed")
+
+// MigrationProgress is reported after each range of a migration is transferred or has
+// failed for good.
+type MigrationProgress struct {
+	Range MovedRange
+	Err   error // nil if Range was transferred
+	// Done and Failed count the ranges transferred and failed so far, out of Total.
+	Done, Failed, Total int
+}
+
+// Migration turns a plan of moved ranges, such as the Moved of an Effect or ChangeEvent,
+// into an actual migration: it calls Transfer for each range, Concurrency ranges at a
+// time, retrying failed ones, and reports its progress.
+type Migration struct {
+	// Transfer moves the keys of r from r.From to r.To. It must stop when ctx is done.
+	Transfer func(ctx context.Context, r MovedRange) error
+	// Concurrency is the number of ranges transferred at once. Defaults to
+	// DefaultMigrationConcurrency.
+	Concurrency int
+	// Retries is the number of times a failed range is retried, RetryDelay after the first
+	// failure, then twice as long after each.
+	Retries    int
+	RetryDelay time.Duration
+	// OnProgress, if set, is called after each range, never concurrently.
+	OnProgress func(MigrationProgress)
+}
+
+// Run transfers the ranges of plan and returns those that failed, in the order of plan.
+// The error is ctx.Err() if ctx was done before the end, the ranges not transferred by then
+// counting as failed, and wraps ErrMigrationFailed if ranges failed otherwise.
+func (m Migration) Run(ctx context.Context, plan []MovedRange) ([]MovedRange, error) {
+	workers := m.Concurrency
+	if workers <= 0 {
+		workers = DefaultMigrationConcurrency
+	}
+	var (
+		mu           sync.Mutex
+		wg           sync.WaitGroup
+		done, failed int
+		errs         = make([]error, len(plan))
+		sem          = make(chan struct{}, workers)
+	)
+	started := 0
+start:
+	for ; started < len(plan); started++ {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			break start
+		}
+		if ctx.Err() != nil {
+			<-sem
+			break
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := m.transfer(ctx, plan[i])
+			<-sem
+			mu.Lock()
+			defer mu.Unlock()
+			errs[i] = err
+			if err != nil {
+				failed++
+			} else {
+				done++
+			}
+			if m.OnProgress != nil {
+				m.OnProgress(MigrationProgress{Range: plan[i], Err: err, Done: done, Failed: failed, Total: len(plan)})
+			}
+		}(started)
+	}
+	wg.Wait()
+	for i := started; i < len(plan); i++ {
+		errs[i] = ctx.Err()
+	}
+
+	var (
+		res   []MovedRange
+		first error
+	)
+	for i, err := range errs {
+		if err != nil {
+			res = append(res, plan[i])
+			if first == nil {
+				first = err
+			}
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return res, err
+	}
+	if len(res) > 0 {
+		return res, fmt.Errorf("%w: %d of %d ranges, first: %v", ErrMigrationFailed, len(res), len(plan), first)
+	}
+	return nil, nil
+}
+
+// transfer calls Transfer for r until it succeeds, it failed Retries times more or ctx is
+// done.
+func (m Migration) transfer(ctx context.Context, r MovedRange) error {
+	delay := m.RetryDelay
+	for attempt := 0; ; attempt++ {
+		err := m.Transfer(ctx, r)
+		if err == nil || attempt >= m.Retries || ctx.Err() != nil {
+			return err
+		}
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+		delay *= 2
+	}
+}
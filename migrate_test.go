@@ -0,0 +1,83 @@
+package consistent
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMigration(t *testing.T) {
+	x := New(newConfig())
+	x.Set([]string{"a", "b", "c", "d"})
+	plan := x.DryRun().Set([]string{"a", "b", "c", "e"}).Moved
+	if len(plan) < 3 {
+		t.Fatalf("expected moved ranges, got %d", len(plan))
+	}
+	broken := plan[1]
+	var (
+		mu            sync.Mutex
+		running, most int
+		attempts      = make(map[MovedRange]int)
+		progress      []MigrationProgress
+	)
+	m := Migration{
+		Transfer: func(ctx context.Context, r MovedRange) error {
+			mu.Lock()
+			running++
+			if running > most {
+				most = running
+			}
+			attempts[r]++
+			n := attempts[r]
+			mu.Unlock()
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			running--
+			mu.Unlock()
+			if r == broken || (r == plan[0] && n == 1) {
+				return errors.New("unreachable")
+			}
+			return nil
+		},
+		Concurrency: 2,
+		Retries:     2,
+		RetryDelay:  time.Millisecond,
+		OnProgress:  func(p MigrationProgress) { progress = append(progress, p) },
+	}
+	failed, err := m.Run(context.Background(), plan)
+	if !errors.Is(err, ErrMigrationFailed) {
+		t.Errorf("got %v, expected ErrMigrationFailed", err)
+	}
+	if len(failed) != 1 || failed[0] != broken {
+		t.Errorf("got failed %v, expected %v", failed, broken)
+	}
+	checkNum(attempts[broken], 3, t)
+	checkNum(attempts[plan[0]], 2, t)
+	if most > 2 {
+		t.Errorf("%d transfers ran at once, expected at most 2", most)
+	}
+	checkNum(len(progress), len(plan), t)
+	last := progress[len(progress)-1]
+	checkNum(last.Done, len(plan)-1, t)
+	checkNum(last.Failed, 1, t)
+	checkNum(last.Total, len(plan), t)
+}
+
+func TestMigrationCancel(t *testing.T) {
+	plan := []MovedRange{{Start: 1, End: 2, From: "a", To: "b"}, {Start: 3, End: 4, From: "a", To: "b"}}
+	ctx, cancel := context.WithCancel(context.Background())
+	m := Migration{
+		Transfer: func(ctx context.Context, r MovedRange) error {
+			cancel()
+			return ctx.Err()
+		},
+		Concurrency: 1,
+	}
+	failed, err := m.Run(ctx, plan)
+	if err != context.Canceled {
+		t.Errorf("got %v, expected context.Canceled", err)
+	}
+	checkNum(len(failed), 2, t)
+}
@@ -0,0 +1,106 @@
+package consistent
+
+import "sort"
+
+// MovedRange is a range of the hash space that changed owner: the keys whose hash h is in
+// [Start, End), wrapping around zero when End <= Start, so Start == End is the whole circle.
+// From is "" for keys of a circle that was empty, To for keys of a circle that became empty.
+type MovedRange struct {
+	Start, End uint32
+	From, To   string
+}
+
+func (r MovedRange) size() uint64 {
+	if r.Start == r.End {
+		return 1 << 32
+	}
+	return uint64(r.End - r.Start)
+}
+
+// owners returns the owner of each of the sorted hashes of a circle.
+func owners(hashes uints, circle map[uint32]string) []string {
+	res := make([]string, len(hashes))
+	for i, h := range hashes {
+		res[i] = circle[h]
+	}
+	return res
+}
+
+// movedRanges returns the ranges of the hash space whose owner differs between two circles
+// given by their sorted hashes and the owners of those, merging adjacent ranges moving
+// between the same members.
+func movedRanges(oldHashes uints, oldOwners []string, newHashes uints, newOwners []string) []MovedRange {
+	points := make(uints, 0, len(oldHashes)+len(newHashes))
+	points = append(points, oldHashes...)
+	points = append(points, newHashes...)
+	if len(points) == 0 {
+		return nil
+	}
+	sort.Sort(points)
+	// owner returns the member owning the keys just below point p
+	owner := func(hashes uints, owners []string, p uint32) string {
+		if len(hashes) == 0 {
+			return ""
+		}
+		i := sort.Search(len(hashes), func(x int) bool { return hashes[x] >= p })
+		if i == len(hashes) {
+			i = 0
+		}
+		return owners[i]
+	}
+	if points[0] == points[len(points)-1] {
+		// a single point owns the whole circle
+		from, to := owner(oldHashes, oldOwners, points[0]), owner(newHashes, newOwners, points[0])
+		if from == to {
+			return nil
+		}
+		return []MovedRange{{points[0], points[0], from, to}}
+	}
+	var res []MovedRange
+	prev := points[len(points)-1]
+	for _, p := range points {
+		if p == prev {
+			continue
+		}
+		from, to := owner(oldHashes, oldOwners, p), owner(newHashes, newOwners, p)
+		if from != to {
+			if n := len(res); n > 0 && res[n-1].End == prev && res[n-1].From == from && res[n-1].To == to {
+				res[n-1].End = p
+			} else {
+				res = append(res, MovedRange{prev, p, from, to})
+			}
+		}
+		prev = p
+	}
+	return res
+}
+
+// movesBase is the routing state of a ring before the first change of a batch.
+type movesBase struct {
+	hashes uints
+	owners []string
+}
+
+// need c.Lock() before calling
+// captureMoves records the routing state before a change, unless an earlier change of the
+// same batch already did.
+func (c *Consistent) captureMoves() {
+	if !c.trackMoves || c.movesBase != nil {
+		return
+	}
+	c.movesBase = &movesBase{
+		hashes: append(uints(nil), c.sortedHashes...),
+		owners: owners(c.sortedHashes, c.circle),
+	}
+}
+
+// need c.Lock() before calling
+// takeMoves returns the ranges moved since captureMoves and ends the batch.
+func (c *Consistent) takeMoves() []MovedRange {
+	base := c.movesBase
+	if base == nil {
+		return nil
+	}
+	c.movesBase = nil
+	return movedRanges(base.hashes, base.owners, c.sortedHashes, owners(c.sortedHashes, c.circle))
+}
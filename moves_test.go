@@ -0,0 +1,52 @@
+package consistent
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestMovedRanges(t *testing.T) {
+	x := New(Config{DefaultNumberOfReplicas: 20, TrackMovedRanges: true})
+	var events []ChangeEvent
+	x.OnChange(func(ev ChangeEvent) { events = append(events, ev) })
+	x.Add("a")
+	if len(events) != 1 || len(events[0].Moved) != 1 || events[0].Moved[0].size() != 1<<32 || events[0].Moved[0].To != "a" {
+		t.Fatalf("unexpected first event %+v", events)
+	}
+	x.Set([]string{"a", "b"})
+	before := make(map[string]string)
+	for i := 0; i < 2000; i++ {
+		k := "user" + strconv.Itoa(i)
+		before[k], _ = x.Get(k)
+	}
+	x.Set([]string{"a", "c", "d"})
+	ev := events[len(events)-1]
+	if len(ev.Moved) == 0 {
+		t.Fatalf("expected moved ranges")
+	}
+	for k, m := range before {
+		now, _ := x.Get(k)
+		h := x.hashKey(k)
+		var r *MovedRange
+		for i := range ev.Moved {
+			mr := ev.Moved[i]
+			if (mr.Start < mr.End && h >= mr.Start && h < mr.End) || (mr.Start >= mr.End && (h >= mr.Start || h < mr.End)) {
+				r = &mr
+			}
+		}
+		if now == m && r != nil {
+			t.Errorf("%s stayed on %s but is in moved range %+v", k, m, *r)
+		}
+		if now != m && (r == nil || r.From != m || r.To != now) {
+			t.Errorf("%s moved from %s to %s, got range %+v", k, m, now, r)
+		}
+	}
+	for i := 1; i < len(ev.Moved); i++ {
+		if ev.Moved[i].End <= ev.Moved[i-1].End {
+			t.Errorf("ranges are not sorted")
+		}
+	}
+	if New(newConfig()).takeMoves() != nil {
+		t.Errorf("expected no moves without TrackMovedRanges")
+	}
+}
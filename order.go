@@ -0,0 +1,54 @@
+package consistent
+
+import "sort"
+
+// Order selects how GetNOrdered orders its result.
+type Order int
+
+const (
+	// OrderRing keeps the elements in ring order, as GetN does.
+	OrderRing Order = iota
+	// OrderWeight sorts the elements by number of replicas, highest first. Elements with
+	// the same number of replicas keep their ring order.
+	OrderWeight
+	// OrderShuffled shuffles the elements with a generator seeded by the hash of the name,
+	// so every name spreads its replicas' load differently but always the same way.
+	OrderShuffled
+)
+
+// GetNOrdered is like GetN but returns the elements in the given order. The set of
+// elements is the same whatever the order.
+func (c *Consistent) GetNOrdered(name string, n int, order Order) ([]string, error) {
+	res, err := c.GetN(name, n)
+	if err != nil {
+		return nil, err
+	}
+	switch order {
+	case OrderWeight:
+		c.RLock()
+		sort.SliceStable(res, func(i, j int) bool {
+			return c.membersReplicas[res[i]] > c.membersReplicas[res[j]]
+		})
+		c.RUnlock()
+	case OrderShuffled:
+		c.RLock()
+		seed := uint64(c.hashKey(name))
+		c.RUnlock()
+		for i := len(res) - 1; i > 0; i-- {
+			seed = splitmix64(seed)
+			j := int(seed % uint64(i+1))
+			res[i], res[j] = res[j], res[i]
+		}
+	}
+	return res, nil
+}
+
+// splitmix64 advances a SplitMix64 generator. It is used instead of math/rand so the
+// shuffles stay identical across Go releases and are easy to reproduce in other languages.
+func splitmix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	z := x
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
@@ -0,0 +1,72 @@
+package consistent
+
+import (
+	"sort"
+	"strconv"
+	"testing"
+)
+
+func TestGetNOrderedRing(t *testing.T) {
+	x := New(newConfig())
+	x.Add("abcdefg")
+	x.Add("hijklmn")
+	x.Add("opqrstu")
+	members, err := x.GetNOrdered("9999999", 3, OrderRing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"opqrstu", "abcdefg", "hijklmn"}
+	for i := range expected {
+		if members[i] != expected[i] {
+			t.Errorf("wrong members[%d]: %q", i, members[i])
+		}
+	}
+}
+
+func TestGetNOrderedWeight(t *testing.T) {
+	x := New(newConfig())
+	x.Add("abcdefg", 10)
+	x.Add("hijklmn", 30)
+	x.Add("opqrstu", 20)
+	members, err := x.GetNOrdered("9999999", 3, OrderWeight)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"hijklmn", "opqrstu", "abcdefg"}
+	for i := range expected {
+		if members[i] != expected[i] {
+			t.Errorf("wrong members[%d]: %q", i, members[i])
+		}
+	}
+}
+
+func TestGetNOrderedShuffled(t *testing.T) {
+	x := New(newConfig())
+	for i := 0; i < 10; i++ {
+		x.Add("member" + strconv.Itoa(i))
+	}
+	orders := make(map[string]bool)
+	for i := 0; i < 50; i++ {
+		name := "key" + strconv.Itoa(i)
+		a, _ := x.GetNOrdered(name, 5, OrderShuffled)
+		b, _ := x.GetNOrdered(name, 5, OrderShuffled)
+		ring, _ := x.GetN(name, 5)
+		for j := range a {
+			if a[j] != b[j] {
+				t.Fatalf("%s: shuffle is not deterministic: %q vs %q", name, a, b)
+			}
+		}
+		sort.Strings(ring)
+		sorted := append([]string(nil), a...)
+		sort.Strings(sorted)
+		for j := range ring {
+			if ring[j] != sorted[j] {
+				t.Fatalf("%s: shuffle changed the set of members", name)
+			}
+		}
+		orders[a[0]] = true
+	}
+	if len(orders) < 2 {
+		t.Errorf("expected shuffles to vary across names")
+	}
+}
@@ -0,0 +1,250 @@
+package consistent
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// maxAuditEntries bounds the audit trail kept by Overrides; the oldest entries go first.
+const maxAuditEntries = 1024
+
+// Override is a per-key routing decision: a pin sends Key to Member, an exclusion keeps
+// Key away from Member. A zero Expires means the override never expires.
+type Override struct {
+	Key     string    `json:"key"`
+	Member  string    `json:"member"`
+	Expires time.Time `json:"expires,omitempty"`
+	Reason  string    `json:"reason,omitempty"`
+}
+
+// AuditEntry records one change made to an Overrides table.
+type AuditEntry struct {
+	Time   time.Time `json:"time"`
+	Action string    `json:"action"` // "pin", "unpin", "exclude" or "include"
+	Key    string    `json:"key"`
+	Member string    `json:"member,omitempty"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// Overrides holds emergency per-key routing decisions that take precedence over the
+// circle once installed with SetOverrides. Pins to members that are no longer in the ring
+// are ignored, and a key whose every member is excluded falls back to the circle.
+type Overrides struct {
+	mu         sync.RWMutex
+	pins       map[string]Override
+	exclusions map[string][]Override
+	audit      []AuditEntry
+	now        func() time.Time
+}
+
+// NewOverrides creates an empty override table.
+func NewOverrides() *Overrides {
+	return &Overrides{
+		pins:       make(map[string]Override),
+		exclusions: make(map[string][]Override),
+		now:        time.Now,
+	}
+}
+
+// SetOverrides installs o on the ring, or removes the override table if o is nil.
+func (c *Consistent) SetOverrides(o *Overrides) {
+	c.Lock()
+	defer c.unlockAndPublish()
+	c.overrides = o
+}
+
+// Pin routes key to member. A ttl of 0 pins it until Unpin.
+func (o *Overrides) Pin(key, member string, ttl time.Duration, reason string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.pins[key] = Override{Key: key, Member: member, Expires: o.expiry(ttl), Reason: reason}
+	o.record("pin", key, member, reason)
+}
+
+// Unpin removes the pin of key.
+func (o *Overrides) Unpin(key, reason string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if _, ok := o.pins[key]; !ok {
+		return
+	}
+	delete(o.pins, key)
+	o.record("unpin", key, "", reason)
+}
+
+// Exclude keeps key away from member. A ttl of 0 excludes it until Include.
+func (o *Overrides) Exclude(key, member string, ttl time.Duration, reason string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	list := o.exclusions[key]
+	for i, e := range list {
+		if e.Member == member {
+			list = append(list[:i], list[i+1:]...)
+			break
+		}
+	}
+	o.exclusions[key] = append(list, Override{Key: key, Member: member, Expires: o.expiry(ttl), Reason: reason})
+	o.record("exclude", key, member, reason)
+}
+
+// Include lifts the exclusion of member for key.
+func (o *Overrides) Include(key, member, reason string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	list := o.exclusions[key]
+	for i, e := range list {
+		if e.Member == member {
+			list = append(list[:i], list[i+1:]...)
+			if len(list) == 0 {
+				delete(o.exclusions, key)
+			} else {
+				o.exclusions[key] = list
+			}
+			o.record("include", key, member, reason)
+			return
+		}
+	}
+}
+
+// Pins returns the pins that have not expired.
+func (o *Overrides) Pins() []Override {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	now := o.now()
+	var res []Override
+	for _, p := range o.pins {
+		if live(p, now) {
+			res = append(res, p)
+		}
+	}
+	return res
+}
+
+// Exclusions returns the exclusions that have not expired.
+func (o *Overrides) Exclusions() []Override {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	now := o.now()
+	var res []Override
+	for _, list := range o.exclusions {
+		for _, e := range list {
+			if live(e, now) {
+				res = append(res, e)
+			}
+		}
+	}
+	return res
+}
+
+// Audit returns the recorded changes, oldest first.
+func (o *Overrides) Audit() []AuditEntry {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return append([]AuditEntry(nil), o.audit...)
+}
+
+// Expire drops expired overrides and returns how many were dropped.
+func (o *Overrides) Expire() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	now := o.now()
+	n := 0
+	for k, p := range o.pins {
+		if !live(p, now) {
+			delete(o.pins, k)
+			n++
+		}
+	}
+	for k, list := range o.exclusions {
+		kept := list[:0]
+		for _, e := range list {
+			if live(e, now) {
+				kept = append(kept, e)
+			} else {
+				n++
+			}
+		}
+		if len(kept) == 0 {
+			delete(o.exclusions, k)
+		} else {
+			o.exclusions[k] = kept
+		}
+	}
+	return n
+}
+
+type overridesFile struct {
+	Pins       []Override   `json:"pins"`
+	Exclusions []Override   `json:"exclusions"`
+	Audit      []AuditEntry `json:"audit"`
+}
+
+// Save writes the live overrides and the audit trail to w as JSON.
+func (o *Overrides) Save(w io.Writer) error {
+	f := overridesFile{Pins: o.Pins(), Exclusions: o.Exclusions(), Audit: o.Audit()}
+	return json.NewEncoder(w).Encode(f)
+}
+
+// Load replaces the content of o with overrides written by Save. Overrides that expired in
+// the meantime are dropped.
+func (o *Overrides) Load(r io.Reader) error {
+	var f overridesFile
+	if err := json.NewDecoder(r).Decode(&f); err != nil {
+		return err
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.pins = make(map[string]Override, len(f.Pins))
+	o.exclusions = make(map[string][]Override)
+	for _, p := range f.Pins {
+		o.pins[p.Key] = p
+	}
+	for _, e := range f.Exclusions {
+		o.exclusions[e.Key] = append(o.exclusions[e.Key], e)
+	}
+	o.audit = f.Audit
+	return nil
+}
+
+// need o.mu.Lock() before calling
+func (o *Overrides) record(action, key, member, reason string) {
+	if len(o.audit) >= maxAuditEntries {
+		o.audit = append(o.audit[:0], o.audit[1:]...)
+	}
+	o.audit = append(o.audit, AuditEntry{Time: o.now(), Action: action, Key: key, Member: member, Reason: reason})
+}
+
+func (o *Overrides) expiry(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return o.now().Add(ttl)
+}
+
+func live(ov Override, now time.Time) bool {
+	return ov.Expires.IsZero() || now.Before(ov.Expires)
+}
+
+// lookup returns the live pin of key and a function reporting the members excluded for it,
+// which is nil when there are none.
+func (o *Overrides) lookup(key string) (string, func(string) bool) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	now := o.now()
+	pin := ""
+	if p, ok := o.pins[key]; ok && live(p, now) {
+		pin = p.Member
+	}
+	var excluded []string
+	for _, e := range o.exclusions[key] {
+		if live(e, now) {
+			excluded = append(excluded, e.Member)
+		}
+	}
+	if len(excluded) == 0 {
+		return pin, nil
+	}
+	return pin, func(m string) bool { return sliceContainsMember(excluded, m) }
+}
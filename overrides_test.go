@@ -0,0 +1,102 @@
+package consistent
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func newOverridesRing() (*Consistent, *Overrides) {
+	x := New(newConfig())
+	x.Add("abcdefg")
+	x.Add("hijklmn")
+	x.Add("opqrstu")
+	o := NewOverrides()
+	x.SetOverrides(o)
+	return x, o
+}
+
+func TestOverridesPin(t *testing.T) {
+	x, o := newOverridesRing()
+	o.Pin("ggg", "opqrstu", 0, "incident 42")
+	if m, _ := x.Get("ggg"); m != "opqrstu" {
+		t.Errorf("got %q, expected pinned opqrstu", m)
+	}
+	members, _ := x.GetN("ggg", 2)
+	if len(members) != 2 || members[0] != "opqrstu" || members[1] == "opqrstu" {
+		t.Errorf("got %q, expected pinned member first", members)
+	}
+	if a, b, _ := x.GetTwo("ggg"); a != members[0] || b != members[1] {
+		t.Errorf("GetTwo gives %s, %s, GetN %q", a, b, members)
+	}
+	x.Remove("opqrstu")
+	if m, _ := x.Get("ggg"); m != "abcdefg" {
+		t.Errorf("got %q, expected pin to removed member to be ignored", m)
+	}
+	o.Unpin("ggg", "resolved")
+	if len(o.Pins()) != 0 {
+		t.Errorf("expected no pins")
+	}
+}
+
+func TestOverridesExclude(t *testing.T) {
+	x, o := newOverridesRing()
+	o.Exclude("ggg", "abcdefg", 0, "")
+	if m, _ := x.Get("ggg"); m == "abcdefg" {
+		t.Errorf("expected excluded member to be skipped")
+	}
+	members, _ := x.GetN("ggg", 3)
+	checkNum(len(members), 2, t)
+	if a, b, _ := x.GetTwo("ggg"); a == "abcdefg" || b == "abcdefg" {
+		t.Errorf("GetTwo gives excluded member: %s, %s", a, b)
+	}
+	o.Exclude("ggg", "hijklmn", 0, "")
+	o.Exclude("ggg", "opqrstu", 0, "")
+	if m, _ := x.Get("ggg"); m != "abcdefg" {
+		t.Errorf("got %q, expected fallback to the circle when everything is excluded", m)
+	}
+	o.Include("ggg", "abcdefg", "")
+	if m, _ := x.Get("ggg"); m != "abcdefg" {
+		t.Errorf("got %q after include", m)
+	}
+}
+
+func TestOverridesTTL(t *testing.T) {
+	x, o := newOverridesRing()
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	o.now = func() time.Time { return now }
+	o.Pin("ggg", "opqrstu", time.Minute, "")
+	o.Exclude("hhh", "opqrstu", time.Hour, "")
+	if m, _ := x.Get("ggg"); m != "opqrstu" {
+		t.Errorf("got %q before expiry", m)
+	}
+	now = now.Add(2 * time.Minute)
+	if m, _ := x.Get("ggg"); m != "abcdefg" {
+		t.Errorf("got %q after expiry", m)
+	}
+	checkNum(o.Expire(), 1, t)
+	checkNum(len(o.Exclusions()), 1, t)
+}
+
+func TestOverridesSaveLoad(t *testing.T) {
+	_, o := newOverridesRing()
+	o.Pin("ggg", "opqrstu", 0, "incident 42")
+	o.Exclude("hhh", "opqrstu", time.Hour, "draining")
+	var buf bytes.Buffer
+	if err := o.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	y, p := newOverridesRing()
+	if err := p.Load(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if m, _ := y.Get("ggg"); m != "opqrstu" {
+		t.Errorf("got %q, expected pin to survive a reload", m)
+	}
+	checkNum(len(p.Exclusions()), 1, t)
+	audit := p.Audit()
+	checkNum(len(audit), 2, t)
+	if audit[0].Action != "pin" || audit[0].Reason != "incident 42" {
+		t.Errorf("unexpected audit entry %+v", audit[0])
+	}
+}
@@ -0,0 +1,30 @@
+package consistent
+
+import "sort"
+
+// Mismatch is a key stored on another member than the one owning it.
+type Mismatch struct {
+	Key    string
+	Stored string // the member the key is stored on
+	Owner  string // the member Get returns for the key, "" if the circle is empty
+}
+
+// VerifyOwnership checks assignments, a map of keys to the member storing them, against
+// the ring and returns the keys whose member is not the one Get returns, sorted by key.
+// Every key is checked against the same state of the ring.
+func (c *Consistent) VerifyOwnership(assignments map[string]string) []Mismatch {
+	c.RLock()
+	defer c.RUnlock()
+	var res []Mismatch
+	for k, stored := range assignments {
+		owner := ""
+		if len(c.circle) > 0 {
+			owner, _ = c.owner(k)
+		}
+		if owner != stored {
+			res = append(res, Mismatch{Key: k, Stored: stored, Owner: owner})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Key < res[j].Key })
+	return res
+}
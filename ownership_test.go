@@ -0,0 +1,39 @@
+package consistent
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestVerifyOwnership(t *testing.T) {
+	x := New(newConfig())
+	x.Set([]string{"a", "b", "c"})
+	stored := make(map[string]string)
+	for i := 0; i < 100; i++ {
+		k := "user" + strconv.Itoa(i)
+		stored[k], _ = x.Get(k)
+	}
+	if m := x.VerifyOwnership(stored); len(m) != 0 {
+		t.Fatalf("unexpected mismatches %v", m)
+	}
+	x.Remove("c")
+	moved := 0
+	for _, m := range stored {
+		if m == "c" {
+			moved++
+		}
+	}
+	m := x.VerifyOwnership(stored)
+	if len(m) != moved {
+		t.Fatalf("got %d mismatches, expected %d", len(m), moved)
+	}
+	for i, v := range m {
+		owner, _ := x.Get(v.Key)
+		if v.Stored != "c" || v.Owner != owner || (i > 0 && m[i-1].Key >= v.Key) {
+			t.Errorf("unexpected mismatch %+v", v)
+		}
+	}
+	if m := New(newConfig()).VerifyOwnership(map[string]string{"k": "a"}); len(m) != 1 || m[0].Owner != "" {
+		t.Errorf("unexpected mismatches on an empty ring %v", m)
+	}
+}
@@ -0,0 +1,146 @@
+package consistent
+
+import "sync"
+
+// FailoverEvent reports that a Pair moved its lookups from one ring to the other.
+type FailoverEvent struct {
+	// Standby is true when lookups moved to the standby ring (failover) and false when they
+	// moved back to the primary ring (failback).
+	Standby bool
+	// PrimaryHealthy and PrimaryMembers describe the primary ring at the time of the move.
+	PrimaryHealthy bool
+	PrimaryMembers int
+}
+
+// Pair routes lookups to a primary ring, e.g. the members in the local datacenter, and
+// fails over to a standby ring, e.g. the members in another datacenter, while the primary
+// is reported unhealthy with SetPrimaryHealthy or has fewer than its minimum number of
+// members. It fails back as soon as the primary is usable again. A Pair does not fail
+// over to an empty standby ring.
+type Pair struct {
+	primary    *Consistent
+	standby    *Consistent
+	minMembers int
+	cancel     func()
+
+	mu        sync.Mutex
+	healthy   bool
+	onStandby bool
+	next      int
+	listeners []failoverListener
+}
+
+type failoverListener struct {
+	id int
+	fn func(FailoverEvent)
+}
+
+var _ Locator = (*Pair)(nil)
+
+// NewPair creates a pair of primary and standby failing over when primary has fewer than
+// minMembers members, at least 1. The primary is assumed healthy until told otherwise.
+func NewPair(primary, standby *Consistent, minMembers int) *Pair {
+	if minMembers < 1 {
+		minMembers = 1
+	}
+	p := &Pair{primary: primary, standby: standby, minMembers: minMembers, healthy: true}
+	cancelPrimary := primary.OnChange(func(ChangeEvent) { p.check() })
+	cancelStandby := standby.OnChange(func(ChangeEvent) { p.check() })
+	p.cancel = func() {
+		cancelPrimary()
+		cancelStandby()
+	}
+	p.check()
+	return p
+}
+
+// Close stops following the membership of the rings.
+func (p *Pair) Close() {
+	p.cancel()
+}
+
+// SetPrimaryHealthy reports the health of the primary ring, typically from a health
+// checker, failing over or back if needed.
+func (p *Pair) SetPrimaryHealthy(healthy bool) {
+	p.mu.Lock()
+	p.healthy = healthy
+	p.mu.Unlock()
+	p.check()
+}
+
+// OnFailover registers fn to be called after every failover and failback. The returned
+// function unregisters fn.
+func (p *Pair) OnFailover(fn func(FailoverEvent)) (cancel func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	id := p.next
+	p.next++
+	p.listeners = append(p.listeners, failoverListener{id, fn})
+	return func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		for i, v := range p.listeners {
+			if v.id == id {
+				p.listeners = append(p.listeners[:i:i], p.listeners[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Active returns the ring lookups are currently routed to.
+func (p *Pair) Active() *Consistent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.onStandby {
+		return p.standby
+	}
+	return p.primary
+}
+
+// OnStandby reports whether lookups are routed to the standby ring.
+func (p *Pair) OnStandby() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.onStandby
+}
+
+// Get returns the owner of name in the active ring.
+func (p *Pair) Get(name string) (string, error) {
+	return p.Active().Get(name)
+}
+
+// GetTwo returns the two closest owners of name in the active ring.
+func (p *Pair) GetTwo(name string) (string, string, error) {
+	return p.Active().GetTwo(name)
+}
+
+// GetN returns the n closest owners of name in the active ring.
+func (p *Pair) GetN(name string, n int) ([]string, error) {
+	return p.Active().GetN(name, n)
+}
+
+// Members returns the members of the active ring.
+func (p *Pair) Members() []string {
+	return p.Active().Members()
+}
+
+// check reads the rings with p.mu held, so the last of concurrent checks decides from
+// their latest membership.
+func (p *Pair) check() {
+	p.mu.Lock()
+	members := p.primary.MemberCount()
+	usable := p.healthy && members >= p.minMembers
+	onStandby := !usable && p.standby.MemberCount() > 0
+	if onStandby == p.onStandby {
+		p.mu.Unlock()
+		return
+	}
+	p.onStandby = onStandby
+	ev := FailoverEvent{Standby: onStandby, PrimaryHealthy: p.healthy, PrimaryMembers: members}
+	list := p.listeners
+	p.mu.Unlock()
+	for _, v := range list {
+		v.fn(ev)
+	}
+}
@@ -0,0 +1,87 @@
+package consistent
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestPair(t *testing.T) {
+	primary := New(newConfig())
+	primary.Set([]string{"a1", "a2"})
+	standby := New(newConfig())
+	standby.Set([]string{"b1", "b2"})
+	p := NewPair(primary, standby, 2)
+	defer p.Close()
+	var events []FailoverEvent
+	p.OnFailover(func(ev FailoverEvent) { events = append(events, ev) })
+
+	if m, _ := p.Get("ggg"); m != "a1" && m != "a2" {
+		t.Errorf("got %s, expected a primary member", m)
+	}
+	p.SetPrimaryHealthy(false)
+	if m, _ := p.Get("ggg"); m != "b1" && m != "b2" {
+		t.Errorf("got %s, expected a standby member", m)
+	}
+	p.SetPrimaryHealthy(true)
+	if p.OnStandby() {
+		t.Errorf("expected failback once healthy")
+	}
+	primary.Remove("a2")
+	if !p.OnStandby() {
+		t.Errorf("expected failover below the minimum number of members")
+	}
+	primary.Add("a3")
+	if p.Active() != primary {
+		t.Errorf("expected failback once the primary has enough members")
+	}
+	want := []FailoverEvent{
+		{Standby: true, PrimaryHealthy: false, PrimaryMembers: 2},
+		{Standby: false, PrimaryHealthy: true, PrimaryMembers: 2},
+		{Standby: true, PrimaryHealthy: true, PrimaryMembers: 1},
+		{Standby: false, PrimaryHealthy: true, PrimaryMembers: 2},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("got events %v, expected %v", events, want)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("event %d: got %v, expected %v", i, events[i], want[i])
+		}
+	}
+}
+
+func TestPairEmptyStandby(t *testing.T) {
+	primary := New(newConfig())
+	primary.Add("a1")
+	p := NewPair(primary, New(newConfig()), 1)
+	defer p.Close()
+	p.SetPrimaryHealthy(false)
+	if p.OnStandby() {
+		t.Errorf("expected no failover to an empty standby")
+	}
+}
+
+func TestPairConcurrentChecks(t *testing.T) {
+	primary := New(newConfig())
+	standby := New(newConfig())
+	standby.Set([]string{"b1"})
+	p := NewPair(primary, standby, 1)
+	defer p.Close()
+	for i := 0; i < 50; i++ {
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			primary.Add("a")
+		}()
+		go func() {
+			defer wg.Done()
+			p.SetPrimaryHealthy(true)
+		}()
+		wg.Wait()
+		if p.OnStandby() {
+			t.Fatalf("round %d: expected failback once the primary recovered", i)
+		}
+		primary.Remove("a")
+	}
+}
@@ -0,0 +1,74 @@
+package consistent
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// ReadPolicy decides which of the owners of a key a read is sent to, and in which order
+// they are tried.
+type ReadPolicy interface {
+	// Order returns the owners to try for key, most preferred first. owners is in ring
+	// order and may be modified.
+	Order(key string, owners []string) []string
+}
+
+// ReadOwners returns the owners among the n closest to name that policy wants a read to
+// try, in order. A nil policy means the ring's Config.ReadPolicy.
+func (c *Consistent) ReadOwners(name string, n int, policy ReadPolicy) ([]string, error) {
+	owners, err := c.GetN(name, n)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		policy = c.readPolicy
+	}
+	return policy.Order(name, owners), nil
+}
+
+// PrimaryOnly reads from the primary owner only.
+type PrimaryOnly struct{}
+
+// Order returns the primary owner.
+func (PrimaryOnly) Order(key string, owners []string) []string {
+	return owners[:1]
+}
+
+// NearestReplica tries the owners closest first according to Distance, e.g. network
+// latency or zone distance from the caller. Owners at the same distance keep ring order.
+type NearestReplica struct {
+	Distance func(member string) float64
+}
+
+// Order sorts owners by distance.
+func (p NearestReplica) Order(key string, owners []string) []string {
+	sort.SliceStable(owners, func(i, j int) bool {
+		return p.Distance(owners[i]) < p.Distance(owners[j])
+	})
+	return owners
+}
+
+// RoundRobin rotates successive reads over the owners of a key. Use it through a pointer.
+type RoundRobin struct {
+	next uint32
+}
+
+// Order rotates owners by one position more than the previous call.
+func (p *RoundRobin) Order(key string, owners []string) []string {
+	k := int(atomic.AddUint32(&p.next, 1)-1) % len(owners)
+	res := make([]string, 0, len(owners))
+	return append(append(res, owners[k:]...), owners[:k]...)
+}
+
+// Hedged tries every owner in ring order, the next one being started Delay after the
+// previous one if it has not answered yet: Order returns the owners to try and Do tries
+// them. DoHedged uses it.
+type Hedged struct {
+	Delay time.Duration
+}
+
+// Order returns owners unchanged.
+func (p Hedged) Order(key string, owners []string) []string {
+	return owners
+}
@@ -0,0 +1,58 @@
+package consistent
+
+import "testing"
+
+func newPolicyRing(p ReadPolicy) *Consistent {
+	x := New(Config{DefaultNumberOfReplicas: 20, ReadPolicy: p})
+	x.Add("abcdefg")
+	x.Add("hijklmn")
+	x.Add("opqrstu")
+	return x
+}
+
+func TestReadOwnersPrimaryOnly(t *testing.T) {
+	x := newPolicyRing(nil)
+	owners, err := x.ReadOwners("9999999", 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(owners) != 1 || owners[0] != "opqrstu" {
+		t.Errorf("got %q, expected the primary owner only", owners)
+	}
+}
+
+func TestReadOwnersNearest(t *testing.T) {
+	x := newPolicyRing(nil)
+	distance := map[string]float64{"abcdefg": 2, "hijklmn": 1, "opqrstu": 3}
+	p := NearestReplica{Distance: func(m string) float64 { return distance[m] }}
+	owners, _ := x.ReadOwners("9999999", 3, p)
+	expected := []string{"hijklmn", "abcdefg", "opqrstu"}
+	for i := range expected {
+		if owners[i] != expected[i] {
+			t.Errorf("wrong owners[%d]: %q", i, owners[i])
+		}
+	}
+}
+
+func TestReadOwnersRoundRobin(t *testing.T) {
+	x := newPolicyRing(&RoundRobin{})
+	var firsts []string
+	for i := 0; i < 4; i++ {
+		owners, _ := x.ReadOwners("9999999", 3, nil)
+		checkNum(len(owners), 3, t)
+		firsts = append(firsts, owners[0])
+	}
+	expected := []string{"opqrstu", "abcdefg", "hijklmn", "opqrstu"}
+	for i := range expected {
+		if firsts[i] != expected[i] {
+			t.Errorf("read %d went to %q, expected %q", i, firsts[i], expected[i])
+		}
+	}
+}
+
+func TestReadOwnersEmpty(t *testing.T) {
+	x := New(newConfig())
+	if _, err := x.ReadOwners("9999999", 3, Hedged{}); err != ErrEmptyCircle {
+		t.Errorf("expected empty circle error")
+	}
+}
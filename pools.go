@@ -0,0 +1,184 @@
+package consistent
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrNotMember is returned when an operation needs a member that is not in the ring.
+var ErrNotMember = errors.New("consistent: not a member")
+
+// Pool is a per-member resource, typically a connection pool, managed by a PoolManager.
+type Pool interface {
+	Close() error
+}
+
+// PoolManager keeps one Pool per member of a ring. Pools are opened when members are added
+// and closed DrainTimeout after they are removed, so requests in flight can finish.
+type PoolManager struct {
+	ring    *Consistent
+	open    func(member string) (Pool, error)
+	drain   time.Duration
+	onError func(member string, err error)
+	cancel  func()
+
+	mu       sync.Mutex
+	pools    map[string]Pool
+	draining map[string]*drainingPool
+	closed   bool
+}
+
+type drainingPool struct {
+	pool  Pool
+	timer *time.Timer
+}
+
+// NewPoolManager creates a manager opening pools of the members of c with open. onError,
+// which may be nil, is told about failures to open or close pools in the background.
+func NewPoolManager(c *Consistent, open func(member string) (Pool, error), drainTimeout time.Duration, onError func(member string, err error)) *PoolManager {
+	p := &PoolManager{
+		ring:     c,
+		open:     open,
+		drain:    drainTimeout,
+		onError:  onError,
+		pools:    make(map[string]Pool),
+		draining: make(map[string]*drainingPool),
+	}
+	p.cancel = c.OnChange(p.handle)
+	for _, m := range c.Members() {
+		p.opened(m)
+	}
+	return p
+}
+
+// Get returns the pool of member, opening it if needed.
+func (p *PoolManager) Get(member string) (Pool, error) {
+	p.mu.Lock()
+	if pool, ok := p.pools[member]; ok {
+		p.mu.Unlock()
+		return pool, nil
+	}
+	p.mu.Unlock()
+	if !p.isMember(member) {
+		return nil, ErrNotMember
+	}
+	return p.opened(member)
+}
+
+// For returns the pool of the owner of key.
+func (p *PoolManager) For(key string) (Pool, error) {
+	m, err := p.ring.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return p.Get(m)
+}
+
+// Close stops following the ring and closes every pool, including draining ones. It
+// returns the first error returned by a pool.
+func (p *PoolManager) Close() error {
+	p.cancel()
+	p.mu.Lock()
+	p.closed = true
+	var pools []Pool
+	for m, pool := range p.pools {
+		pools = append(pools, pool)
+		delete(p.pools, m)
+	}
+	for m, d := range p.draining {
+		if d.timer.Stop() {
+			pools = append(pools, d.pool)
+		}
+		delete(p.draining, m)
+	}
+	p.mu.Unlock()
+	var first error
+	for _, pool := range pools {
+		if err := pool.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+func (p *PoolManager) isMember(member string) bool {
+	p.ring.RLock()
+	defer p.ring.RUnlock()
+	return p.ring.members[member]
+}
+
+func (p *PoolManager) handle(ev ChangeEvent) {
+	for _, m := range ev.Removed {
+		p.retire(m)
+	}
+	for _, m := range ev.Added {
+		p.opened(m)
+	}
+}
+
+// opened returns the pool of member, reviving it if it is draining or opening a new one.
+func (p *PoolManager) opened(member string) (Pool, error) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil, ErrNotMember
+	}
+	if pool, ok := p.pools[member]; ok {
+		p.mu.Unlock()
+		return pool, nil
+	}
+	if d, ok := p.draining[member]; ok && d.timer.Stop() {
+		delete(p.draining, member)
+		p.pools[member] = d.pool
+		p.mu.Unlock()
+		return d.pool, nil
+	}
+	p.mu.Unlock()
+
+	pool, err := p.open(member)
+	if err != nil {
+		p.report(member, err)
+		return nil, err
+	}
+	p.mu.Lock()
+	if existing, ok := p.pools[member]; ok || p.closed {
+		p.mu.Unlock()
+		pool.Close()
+		if ok {
+			return existing, nil
+		}
+		return nil, ErrNotMember
+	}
+	p.pools[member] = pool
+	p.mu.Unlock()
+	return pool, nil
+}
+
+func (p *PoolManager) retire(member string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pool, ok := p.pools[member]
+	if !ok {
+		return
+	}
+	delete(p.pools, member)
+	d := &drainingPool{pool: pool}
+	d.timer = time.AfterFunc(p.drain, func() {
+		p.mu.Lock()
+		if p.draining[member] == d {
+			delete(p.draining, member)
+		}
+		p.mu.Unlock()
+		if err := pool.Close(); err != nil {
+			p.report(member, err)
+		}
+	})
+	p.draining[member] = d
+}
+
+func (p *PoolManager) report(member string, err error) {
+	if p.onError != nil {
+		p.onError(member, err)
+	}
+}
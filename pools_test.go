@@ -0,0 +1,95 @@
+package consistent
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testPool struct {
+	member string
+	mu     sync.Mutex
+	closed bool
+}
+
+func (p *testPool) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	return nil
+}
+
+func (p *testPool) isClosed() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.closed
+}
+
+func TestPoolManager(t *testing.T) {
+	x := New(newConfig())
+	x.Add("abcdefg")
+	var mu sync.Mutex
+	opened := make(map[string]*testPool)
+	open := func(m string) (Pool, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		p := &testPool{member: m}
+		opened[m] = p
+		return p, nil
+	}
+	pm := NewPoolManager(x, open, 20*time.Millisecond, nil)
+	x.Add("hijklmn")
+
+	p, err := pm.For("ggg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.(*testPool).member != "abcdefg" {
+		t.Errorf("got pool of %q", p.(*testPool).member)
+	}
+	if _, err := pm.Get("missing"); err != ErrNotMember {
+		t.Errorf("expected not member error, got %v", err)
+	}
+
+	x.Remove("abcdefg")
+	if opened["abcdefg"].isClosed() {
+		t.Errorf("expected pool to drain before closing")
+	}
+	time.Sleep(50 * time.Millisecond)
+	if !opened["abcdefg"].isClosed() {
+		t.Errorf("expected pool of removed member to be closed")
+	}
+
+	x.Remove("hijklmn")
+	x.Add("hijklmn")
+	time.Sleep(50 * time.Millisecond)
+	mu.Lock()
+	revived := opened["hijklmn"]
+	mu.Unlock()
+	if revived.isClosed() {
+		t.Errorf("expected pool of a member re-added while draining to be kept")
+	}
+
+	if err := pm.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !revived.isClosed() {
+		t.Errorf("expected Close to close every pool")
+	}
+}
+
+func TestPoolManagerOpenError(t *testing.T) {
+	x := New(newConfig())
+	x.Add("abcdefg")
+	boom := errors.New("boom")
+	var reported []string
+	pm := NewPoolManager(x, func(m string) (Pool, error) { return nil, boom }, time.Millisecond, func(m string, err error) {
+		reported = append(reported, m)
+	})
+	defer pm.Close()
+	if _, err := pm.Get("abcdefg"); err != boom {
+		t.Errorf("expected open error, got %v", err)
+	}
+	checkNum(len(reported), 2, t)
+}
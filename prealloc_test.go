@@ -0,0 +1,29 @@
+package consistent
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestExpectedMembers(t *testing.T) {
+	build := func(conf Config) *Consistent {
+		x := New(conf)
+		for i := 0; i < 50; i++ {
+			x.Add("server" + strconv.Itoa(i))
+		}
+		return x
+	}
+	plain := testing.AllocsPerRun(5, func() { build(Config{DefaultNumberOfReplicas: 100}) })
+	hinted := testing.AllocsPerRun(5, func() {
+		build(Config{DefaultNumberOfReplicas: 100, ExpectedMembers: 50})
+	})
+	if hinted >= plain {
+		t.Errorf("got %v allocations with hints, %v without", hinted, plain)
+	}
+
+	x := New(Config{DefaultNumberOfReplicas: 10, ExpectedMembers: 100, ExpectedReplicas: 20})
+	x.Add("a")
+	if cap(x.sortedHashes) < 2000 {
+		t.Errorf("expected the preallocated sorted hashes to be kept, got cap %d", cap(x.sortedHashes))
+	}
+}
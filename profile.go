@@ -0,0 +1,89 @@
+package consistent
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+// profileLabels holds the pprof label sets a ring runs its lookups and rebuilds under,
+// built once per ring rather than per call. See Config.ProfileName.
+type profileLabels struct {
+	get, getTwo, getN, getBytes, getByHash pprof.LabelSet
+	rebuild                                context.Context
+}
+
+func newProfileLabels(ring string) *profileLabels {
+	labels := func(op string) pprof.LabelSet {
+		return pprof.Labels("ring", ring, "op", op)
+	}
+	return &profileLabels{
+		get:       labels("Get"),
+		getTwo:    labels("GetTwo"),
+		getN:      labels("GetN"),
+		getBytes:  labels("GetBytes"),
+		getByHash: labels("GetByHash"),
+		rebuild:   pprof.WithLabels(context.Background(), labels("rebuild")),
+	}
+}
+
+// rebuilding runs f under the rebuild labels. The goroutine labels of the caller cannot be
+// read back to be restored, so f runs on a goroutine of its own while the caller waits.
+func (p *profileLabels) rebuilding(f func()) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		pprof.SetGoroutineLabels(p.rebuild)
+		f()
+	}()
+	<-done
+}
+
+// GetContext is Get run under the pprof labels of Config.ProfileName added to those of
+// ctx. As with pprof.Do, the goroutine labels are set back to those of ctx afterwards.
+func (c *Consistent) GetContext(ctx context.Context, name string) (elt string, err error) {
+	if c.profile == nil {
+		return c.Get(name)
+	}
+	pprof.Do(ctx, c.profile.get, func(context.Context) { elt, err = c.Get(name) })
+	return elt, err
+}
+
+// GetTwoContext is GetTwo run under the pprof labels of Config.ProfileName added to those
+// of ctx.
+func (c *Consistent) GetTwoContext(ctx context.Context, name string) (a, b string, err error) {
+	if c.profile == nil {
+		return c.GetTwo(name)
+	}
+	pprof.Do(ctx, c.profile.getTwo, func(context.Context) { a, b, err = c.GetTwo(name) })
+	return a, b, err
+}
+
+// GetNContext is GetN run under the pprof labels of Config.ProfileName added to those of
+// ctx.
+func (c *Consistent) GetNContext(ctx context.Context, name string, n int) (res []string, err error) {
+	if c.profile == nil {
+		return c.GetN(name, n)
+	}
+	pprof.Do(ctx, c.profile.getN, func(context.Context) { res, err = c.GetN(name, n) })
+	return res, err
+}
+
+// GetBytesContext is GetBytes run under the pprof labels of Config.ProfileName added to
+// those of ctx.
+func (c *Consistent) GetBytesContext(ctx context.Context, key []byte) (elt string, err error) {
+	if c.profile == nil {
+		return c.GetBytes(key)
+	}
+	pprof.Do(ctx, c.profile.getBytes, func(context.Context) { elt, err = c.GetBytes(key) })
+	return elt, err
+}
+
+// GetByHashContext is GetByHash run under the pprof labels of Config.ProfileName added to
+// those of ctx.
+func (c *Consistent) GetByHashContext(ctx context.Context, h uint32) (elt string, err error) {
+	if c.profile == nil {
+		return c.GetByHash(h)
+	}
+	pprof.Do(ctx, c.profile.getByHash, func(context.Context) { elt, err = c.GetByHash(h) })
+	return elt, err
+}
@@ -0,0 +1,57 @@
+package consistent
+
+import (
+	"bytes"
+	"context"
+	"runtime/pprof"
+	"strings"
+	"testing"
+	"time"
+)
+
+// labelsHasher records the goroutine labels every key is hashed under.
+type labelsHasher struct{ seen []string }
+
+func (h *labelsHasher) HashFunc(key string) uint32 {
+	h.seen = append(h.seen, goroutineLabels())
+	return hashKeyCRC32(key)
+}
+
+// goroutineLabels returns the goroutine profile in text form, which lists the labels of
+// every goroutine. Only the test goroutines set "svc".
+func goroutineLabels() string {
+	var b bytes.Buffer
+	pprof.Lookup("goroutine").WriteTo(&b, 1)
+	return b.String()
+}
+
+func TestProfileLabels(t *testing.T) {
+	if New(newConfig()).profile != nil {
+		t.Error("labels set without ProfileName")
+	}
+	h := &labelsHasher{}
+	var rebuilds []string
+	m := NewManager(Config{DefaultNumberOfReplicas: 20, ProfileName: "cache", CustomHasher: h, OnRebuild: func(int, time.Duration) {
+		rebuilds = append(rebuilds, goroutineLabels())
+	}})
+	x := m.Ring("users")
+	pprof.Do(context.Background(), pprof.Labels("svc", "api"), func(ctx context.Context) {
+		x.Add("a")
+		if len(rebuilds) == 0 || !strings.Contains(rebuilds[0], `"op":"rebuild"`) || !strings.Contains(rebuilds[0], `"ring":"cache/users"`) {
+			t.Errorf("expected the rebuild labels during a rebuild, got %v", rebuilds)
+		}
+		h.seen = nil
+		if m, err := x.GetContext(ctx, "key"); err != nil || m != "a" {
+			t.Errorf("got %s, %v", m, err)
+		}
+		if len(h.seen) != 1 || !strings.Contains(h.seen[0], `"op":"Get", "ring":"cache/users", "svc":"api"`) {
+			t.Errorf("expected the Get labels added to the caller's during GetContext, got %v", h.seen)
+		}
+		x.Get("key")
+		x.GetN("key", 2)
+		labels := goroutineLabels()
+		if !strings.Contains(labels, `"svc":"api"`) || strings.Contains(labels, `"op":`) {
+			t.Errorf("expected the caller's labels to survive lookups and rebuilds, got\n%s", labels)
+		}
+	})
+}
@@ -0,0 +1,8 @@
+//go:build race
+// +build race
+
+package consistent
+
+// raceEnabled reports whether the tests run with the race detector, which makes some
+// allocations the compiler otherwise avoids.
+const raceEnabled = true
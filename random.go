@@ -0,0 +1,27 @@
+package consistent
+
+import "math/rand"
+
+// RandomOwnerWeighted returns a member picked with probability its share of the hash
+// space, the owner of a hash drawn uniformly from rng, or from the global source of
+// math/rand if rng is nil. Sampling jobs such as audits can use it to visit members in
+// proportion to the data they hold. Only the circle is considered: groups, overrides and
+// weighted mode are ignored.
+func (c *Consistent) RandomOwnerWeighted(rng *rand.Rand) (string, error) {
+	var h uint32
+	if rng != nil {
+		h = rng.Uint32()
+	} else {
+		h = rand.Uint32()
+	}
+	c.RLock()
+	defer c.RUnlock()
+	if len(c.circle) == 0 {
+		return "", ErrEmptyCircle
+	}
+	res, _, err := c.route(h, 1, 0, "", nil, nil)
+	if err != nil {
+		return "", err
+	}
+	return res[0], nil
+}
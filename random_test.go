@@ -0,0 +1,32 @@
+package consistent
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestRandomOwnerWeighted(t *testing.T) {
+	x := New(newConfig())
+	if _, err := x.RandomOwnerWeighted(nil); err != ErrEmptyCircle {
+		t.Errorf("got %v, expected ErrEmptyCircle", err)
+	}
+	x.Add("abcdefg")
+	x.Add("hijklmn", 60)
+	x.Add("opqrstu")
+	rng := rand.New(rand.NewSource(1))
+	const draws = 20000
+	got := make(map[string]int)
+	for i := 0; i < draws; i++ {
+		m, err := x.RandomOwnerWeighted(rng)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[m]++
+	}
+	for m, share := range ownershipShares(x.sortedHashes, x.circle) {
+		if math.Abs(float64(got[m])/draws-share) > 0.02 {
+			t.Errorf("%s drawn %.3f of the time, owns %.3f", m, float64(got[m])/draws, share)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package consistent
+
+import "math"
+
+// Range is a range of the hash space owned by Member: the keys whose hash is from
+// StartHash to EndHash included.
+type Range struct {
+	StartHash uint32 `json:"start"`
+	EndHash   uint32 `json:"end"`
+	Member    string `json:"member"`
+}
+
+// Ranges returns the ranges of the hash space owned by each member, in order from 0 to
+// math.MaxUint32, adjacent ranges of the same member merged, e.g. to assign scan ranges to
+// workers or to draw the ring. They cover the whole hash space without wrapping around,
+// so the owner of the vnodes past the last one owns both the first and the last range.
+// Only the circle is considered, like Heatmap. It returns nil if the ring is empty.
+func (c *Consistent) Ranges() []Range {
+	c.RLock()
+	defer c.RUnlock()
+	n := len(c.sortedHashes)
+	if n == 0 {
+		return nil
+	}
+	res := make([]Range, 0, n+1)
+	add := func(start, end uint32, m string) {
+		if l := len(res); l > 0 && res[l-1].Member == m {
+			res[l-1].EndHash = end
+			return
+		}
+		res = append(res, Range{StartHash: start, EndHash: end, Member: m})
+	}
+	start := uint32(0)
+	for _, h := range c.sortedHashes {
+		// a key goes to the first vnode above its hash
+		if h > start {
+			add(start, h-1, c.circle[h])
+		}
+		start = h
+	}
+	add(start, math.MaxUint32, c.circle[c.sortedHashes[0]])
+	return res
+}
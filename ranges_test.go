@@ -0,0 +1,50 @@
+package consistent
+
+import (
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestRanges(t *testing.T) {
+	x := New(newConfig())
+	if x.Ranges() != nil {
+		t.Errorf("expected no ranges for an empty ring")
+	}
+	x.Add("abcdefg")
+	rs := x.Ranges()
+	if len(rs) != 1 || rs[0] != (Range{0, math.MaxUint32, "abcdefg"}) {
+		t.Errorf("expected one range over the whole hash space, got %+v", rs)
+	}
+
+	x.Add("hijklmn")
+	x.Add("opqrstu")
+	rs = x.Ranges()
+	if rs[0].StartHash != 0 || rs[len(rs)-1].EndHash != math.MaxUint32 {
+		t.Errorf("expected the ranges to cover the hash space, got %+v...%+v", rs[0], rs[len(rs)-1])
+	}
+	for i := 1; i < len(rs); i++ {
+		if rs[i].StartHash != rs[i-1].EndHash+1 || rs[i].Member == rs[i-1].Member {
+			t.Fatalf("ranges %+v and %+v are not adjacent and distinct", rs[i-1], rs[i])
+		}
+	}
+	for i := 0; i < 1000; i++ {
+		k := "key" + strconv.Itoa(i)
+		h := x.HashKey(k)
+		for _, r := range rs {
+			if h >= r.StartHash && h <= r.EndHash {
+				if m := mustGet(t, x, k); m != r.Member {
+					t.Errorf("%s in the range of %s, owned by %s", k, r.Member, m)
+				}
+				break
+			}
+		}
+	}
+	for _, r := range rs {
+		for _, h := range []uint32{r.StartHash, r.EndHash} {
+			if m := x.circle[x.sortedHashes[x.search(h)]]; m != r.Member {
+				t.Errorf("hash %d of the range of %s owned by %s", h, r.Member, m)
+			}
+		}
+	}
+}
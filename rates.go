@@ -0,0 +1,88 @@
+package consistent
+
+import (
+	"sync"
+	"time"
+)
+
+// rateSlots is the number of one-second slots kept per member, covering the longest window.
+const rateSlots = 300
+
+// Rate is the average number of times per second a member was selected by Get, GetTwo or
+// GetN (as the first owner) over the last minute and the last five minutes.
+type Rate struct {
+	PerSecond1m float64
+	PerSecond5m float64
+}
+
+// Rates returns the selection rate of every member selected recently. It returns nil unless
+// Config.TrackRates was set.
+func (c *Consistent) Rates() map[string]Rate {
+	if c.rates == nil {
+		return nil
+	}
+	return c.rates.rates()
+}
+
+type rateWindow struct {
+	counts [rateSlots]uint32
+	secs   [rateSlots]int64
+}
+
+type rateTracker struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	members map[string]*rateWindow
+}
+
+func newRateTracker() *rateTracker {
+	return &rateTracker{now: time.Now, members: make(map[string]*rateWindow)}
+}
+
+func (r *rateTracker) record(member string) {
+	sec := r.now().Unix()
+	slot := sec % rateSlots
+	r.mu.Lock()
+	w, ok := r.members[member]
+	if !ok {
+		w = new(rateWindow)
+		r.members[member] = w
+	}
+	if w.secs[slot] != sec {
+		w.secs[slot] = sec
+		w.counts[slot] = 0
+	}
+	w.counts[slot]++
+	r.mu.Unlock()
+}
+
+func (r *rateTracker) forget(member string) {
+	r.mu.Lock()
+	delete(r.members, member)
+	r.mu.Unlock()
+}
+
+func (r *rateTracker) rates() map[string]Rate {
+	sec := r.now().Unix()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	res := make(map[string]Rate, len(r.members))
+	for m, w := range r.members {
+		var last1m, last5m uint32
+		for i := range w.secs {
+			age := sec - w.secs[i]
+			if age < 0 || age >= rateSlots {
+				continue
+			}
+			last5m += w.counts[i]
+			if age < 60 {
+				last1m += w.counts[i]
+			}
+		}
+		if last5m == 0 {
+			continue
+		}
+		res[m] = Rate{PerSecond1m: float64(last1m) / 60, PerSecond5m: float64(last5m) / rateSlots}
+	}
+	return res
+}
@@ -0,0 +1,45 @@
+package consistent
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRates(t *testing.T) {
+	x := New(Config{DefaultNumberOfReplicas: 20, TrackRates: true})
+	now := time.Unix(1000000, 0)
+	x.rates.now = func() time.Time { return now }
+	x.Add("abcdefg")
+	for i := 0; i < 120; i++ {
+		x.Get("ggg")
+	}
+	now = now.Add(2 * time.Minute)
+	for i := 0; i < 60; i++ {
+		x.GetN("ggg", 1)
+	}
+	r := x.Rates()["abcdefg"]
+	if r.PerSecond1m != 1 {
+		t.Errorf("got 1m rate %v, expected 1", r.PerSecond1m)
+	}
+	if r.PerSecond5m != 180.0/300 {
+		t.Errorf("got 5m rate %v, expected %v", r.PerSecond5m, 180.0/300)
+	}
+	now = now.Add(10 * time.Minute)
+	if len(x.Rates()) != 0 {
+		t.Errorf("expected old selections to age out")
+	}
+	x.Get("ggg")
+	x.Remove("abcdefg")
+	if len(x.Rates()) != 0 {
+		t.Errorf("expected removed members to be forgotten")
+	}
+}
+
+func TestRatesDisabled(t *testing.T) {
+	x := New(newConfig())
+	x.Add("abcdefg")
+	x.Get("ggg")
+	if x.Rates() != nil {
+		t.Errorf("expected no rates without TrackRates")
+	}
+}
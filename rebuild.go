@@ -0,0 +1,81 @@
+package consistent
+
+import (
+	"sort"
+	"sync"
+)
+
+// DefaultParallelRebuildThreshold is the vnode count above which the sorted hash index
+// is rebuilt in parallel when Config.ParallelRebuildThreshold is not set.
+const DefaultParallelRebuildThreshold = 1 << 16
+
+// parallelSort sorts x in place by sorting up to workers chunks concurrently and then
+// merging neighbouring runs pairwise, also concurrently, until a single run is left.
+func parallelSort(x uints, workers int) {
+	if workers < 2 || len(x) < 2*workers {
+		sort.Sort(x)
+		return
+	}
+	size := (len(x) + workers - 1) / workers
+	var runs [][2]int
+	for lo := 0; lo < len(x); lo += size {
+		hi := lo + size
+		if hi > len(x) {
+			hi = len(x)
+		}
+		runs = append(runs, [2]int{lo, hi})
+	}
+
+	var wg sync.WaitGroup
+	for _, r := range runs {
+		wg.Add(1)
+		go func(part uints) {
+			sort.Sort(part)
+			wg.Done()
+		}(x[r[0]:r[1]])
+	}
+	wg.Wait()
+
+	src, dst := x, make(uints, len(x))
+	for len(runs) > 1 {
+		next := make([][2]int, 0, (len(runs)+1)/2)
+		for j := 0; j < len(runs); j += 2 {
+			if j+1 == len(runs) {
+				r := runs[j]
+				copy(dst[r[0]:r[1]], src[r[0]:r[1]])
+				next = append(next, r)
+				continue
+			}
+			a, b := runs[j], runs[j+1]
+			wg.Add(1)
+			go func(a, b [2]int) {
+				mergeRuns(dst[a[0]:b[1]], src[a[0]:a[1]], src[b[0]:b[1]])
+				wg.Done()
+			}(a, b)
+			next = append(next, [2]int{a[0], b[1]})
+		}
+		wg.Wait()
+		runs = next
+		src, dst = dst, src
+	}
+	if &src[0] != &x[0] {
+		copy(x, src)
+	}
+}
+
+// mergeRuns merges the sorted runs a and b into dst, which must hold len(a)+len(b) elements.
+func mergeRuns(dst, a, b uints) {
+	i, j, k := 0, 0, 0
+	for i < len(a) && j < len(b) {
+		if b[j] < a[i] {
+			dst[k] = b[j]
+			j++
+		} else {
+			dst[k] = a[i]
+			i++
+		}
+		k++
+	}
+	k += copy(dst[k:], a[i:])
+	copy(dst[k:], b[j:])
+}
@@ -0,0 +1,82 @@
+package consistent
+
+import (
+	"math/rand"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+func TestParallelSort(t *testing.T) {
+	for _, workers := range []int{1, 2, 3, 7, 8} {
+		x := make(uints, 10007)
+		for i := range x {
+			x[i] = rand.Uint32()
+		}
+		expected := append(uints(nil), x...)
+		sort.Sort(expected)
+		parallelSort(x, workers)
+		for i := range x {
+			if x[i] != expected[i] {
+				t.Fatalf("workers %d: index %d got %d, expected %d", workers, i, x[i], expected[i])
+			}
+		}
+	}
+}
+
+func TestParallelRebuild(t *testing.T) {
+	conf := newConfig()
+	conf.ParallelRebuildThreshold = 100
+	x := New(conf)
+	y := New(Config{DefaultNumberOfReplicas: 20, ParallelRebuildThreshold: -1})
+	for i := 0; i < 50; i++ {
+		x.Add("member" + strconv.Itoa(i))
+		y.Add("member" + strconv.Itoa(i))
+	}
+	// Add maintains the hashes incrementally, force the rebuild
+	x.updateSortedHashes()
+	if !sort.IsSorted(x.sortedHashes) {
+		t.Errorf("expected sorted hashes to be sorted")
+	}
+	checkNum(len(x.sortedHashes), len(y.sortedHashes), t)
+	for i := range x.sortedHashes {
+		if x.sortedHashes[i] != y.sortedHashes[i] {
+			t.Fatalf("index %d differs between parallel and serial rebuild", i)
+		}
+	}
+}
+
+func TestIncrementalSortedHashes(t *testing.T) {
+	x := New(Config{DefaultNumberOfReplicas: 50})
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		m := "member" + strconv.Itoa(rnd.Intn(40))
+		if rnd.Intn(3) == 0 {
+			x.Remove(m)
+		} else {
+			x.Add(m, 1+rnd.Intn(100))
+		}
+		expected := append(uints(nil), x.sortedHashes...)
+		x.updateSortedHashes()
+		if len(expected) != len(x.sortedHashes) {
+			t.Fatalf("step %d: got %d hashes, expected %d", i, len(expected), len(x.sortedHashes))
+		}
+		for j := range expected {
+			if expected[j] != x.sortedHashes[j] {
+				t.Fatalf("step %d: index %d differs from a rebuild", i, j)
+			}
+		}
+	}
+}
+
+func BenchmarkRebuildLarge(b *testing.B) {
+	x := New(Config{DefaultNumberOfReplicas: 1000})
+	for i := 0; i < 200; i++ {
+		x.Add("start" + strconv.Itoa(i))
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x.Add("foo")
+		x.Remove("foo")
+	}
+}
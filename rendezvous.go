@@ -0,0 +1,154 @@
+package consistent
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// Rendezvous maps keys to members with rendezvous (highest random weight) hashing: every
+// member scores every key and the highest score wins. It has the surface of Consistent
+// for the basics, so either can be chosen per use case. For small member sets it balances
+// keys perfectly without tuning vnodes, at the cost of lookups linear in the members.
+type Rendezvous struct {
+	mu      sync.RWMutex
+	hash    func(key string) uint32
+	members []weightedMember // sorted by name
+}
+
+var _ Locator = (*Rendezvous)(nil)
+
+// NewRendezvous creates an empty Rendezvous hashing keys and members with the hasher of
+// conf: CustomHasher, UseFnv or Hash. The other settings are ignored.
+func NewRendezvous(conf Config) *Rendezvous {
+	r := &Rendezvous{}
+	switch {
+	case conf.CustomHasher != nil:
+		r.hash = conf.CustomHasher.HashFunc
+	case conf.UseFnv:
+		r.hash = hashKeyFnv
+	default:
+		r.hash = conf.Hash.hash
+	}
+	return r
+}
+
+// Add inserts elt with a weight of 1. If elt is already a member its weight is kept.
+func (r *Rendezvous) Add(elt string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.index(elt) < 0 {
+		r.insert(weightedMember{name: elt, hash: r.hash(elt), weight: 1})
+	}
+}
+
+// AddWeighted inserts elt, or changes its weight, so it gets a share of the keys
+// proportional to weight. A weight <= 0 does nothing.
+func (r *Rendezvous) AddWeighted(elt string, weight float64) {
+	if weight <= 0 || math.IsNaN(weight) {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if i := r.index(elt); i >= 0 {
+		r.members[i].weight = weight
+		return
+	}
+	r.insert(weightedMember{name: elt, hash: r.hash(elt), weight: weight})
+}
+
+// Remove removes elt. Only the keys it owned move.
+func (r *Rendezvous) Remove(elt string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if i := r.index(elt); i >= 0 {
+		r.members = append(r.members[:i], r.members[i+1:]...)
+	}
+}
+
+// Members returns the members, sorted.
+func (r *Rendezvous) Members() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	res := make([]string, len(r.members))
+	for i, m := range r.members {
+		res[i] = m.name
+	}
+	return res
+}
+
+// Get returns the member with the highest score for name.
+func (r *Rendezvous) Get(name string) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.members) == 0 {
+		return "", ErrEmptyCircle
+	}
+	key := r.hash(name)
+	best, bestScore := "", math.Inf(-1)
+	for _, m := range r.members {
+		if s := weightedScore(key, m); s > bestScore {
+			best, bestScore = m.name, s
+		}
+	}
+	return best, nil
+}
+
+// GetTwo returns the two members with the highest scores for name, the second "" if there
+// is a single member.
+func (r *Rendezvous) GetTwo(name string) (string, string, error) {
+	res, err := r.GetN(name, 2)
+	if err != nil {
+		return "", "", err
+	}
+	if len(res) == 1 {
+		return res[0], "", nil
+	}
+	return res[0], res[1], nil
+}
+
+// GetN returns the n members with the highest scores for name, best first, or all of them
+// if there are fewer.
+func (r *Rendezvous) GetN(name string, n int) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.members) == 0 {
+		return nil, ErrEmptyCircle
+	}
+	if n > len(r.members) {
+		n = len(r.members)
+	}
+	key := r.hash(name)
+	type scored struct {
+		name  string
+		score float64
+	}
+	ranked := make([]scored, len(r.members))
+	for i, m := range r.members {
+		ranked[i] = scored{m.name, weightedScore(key, m)}
+	}
+	sort.Slice(ranked, func(i, j int) bool { return ranked[i].score > ranked[j].score })
+	res := make([]string, 0, n)
+	for _, s := range ranked[:n] {
+		res = append(res, s.name)
+	}
+	return res, nil
+}
+
+// need r.mu held before calling
+// index returns the position of elt in the members, -1 if it is not one.
+func (r *Rendezvous) index(elt string) int {
+	i := sort.Search(len(r.members), func(i int) bool { return r.members[i].name >= elt })
+	if i < len(r.members) && r.members[i].name == elt {
+		return i
+	}
+	return -1
+}
+
+// need r.mu locked before calling
+func (r *Rendezvous) insert(m weightedMember) {
+	i := sort.Search(len(r.members), func(i int) bool { return r.members[i].name >= m.name })
+	r.members = append(r.members, weightedMember{})
+	copy(r.members[i+1:], r.members[i:])
+	r.members[i] = m
+}
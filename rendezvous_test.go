@@ -0,0 +1,59 @@
+package consistent
+
+import (
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestRendezvous(t *testing.T) {
+	r := NewRendezvous(Config{})
+	if _, err := r.Get("key"); err != ErrEmptyCircle {
+		t.Errorf("got %v, expected ErrEmptyCircle", err)
+	}
+	for _, m := range []string{"a", "b", "c", "d"} {
+		r.Add(m)
+	}
+	const keys = 20000
+	before := make(map[string]string)
+	dist := make(map[string]int)
+	for i := 0; i < keys; i++ {
+		k := "key" + strconv.Itoa(i)
+		m, err := r.Get(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		before[k] = m
+		dist[m]++
+		top, _ := r.GetN(k, 4)
+		if len(top) != 4 || top[0] != m {
+			t.Errorf("%s: GetN gives %q, Get %s", k, top, m)
+		}
+	}
+	for m, n := range dist {
+		if math.Abs(float64(n)/keys-0.25) > 0.02 {
+			t.Errorf("%s got %.3f of the keys, expected about 0.25", m, float64(n)/keys)
+		}
+	}
+
+	r.Remove("b")
+	for k, old := range before {
+		m, _ := r.Get(k)
+		if old != "b" && m != old {
+			t.Errorf("%s moved from %s to %s", k, old, m)
+		}
+	}
+
+	r.AddWeighted("a", 3)
+	dist = make(map[string]int)
+	for k := range before {
+		m, _ := r.Get(k)
+		dist[m]++
+	}
+	if share := float64(dist["a"]) / keys; math.Abs(share-0.6) > 0.02 {
+		t.Errorf("a got %.3f of the keys, expected about 0.6", share)
+	}
+	if got := r.Members(); len(got) != 3 || got[0] != "a" || got[2] != "d" {
+		t.Errorf("got members %q", got)
+	}
+}
@@ -0,0 +1,57 @@
+package consistent
+
+// View returns a live, read-only view of the ring restricted to the members for which
+// filter returns true, e.g. only the "ssd-" ones. It follows the membership of the ring
+// and routes each key to its closest member that passes filter, so views need no
+// membership management of their own. filter is called with the ring read-locked and must
+// not change it.
+func (c *Consistent) View(filter func(member string) bool) Locator {
+	return &filteredView{ring: c, exclude: func(m string) bool { return !filter(m) }, filter: filter}
+}
+
+type filteredView struct {
+	ring    *Consistent
+	filter  func(string) bool
+	exclude func(string) bool
+}
+
+func (v *filteredView) Get(name string) (string, error) {
+	res, err := v.GetN(name, 1)
+	if err != nil {
+		return "", err
+	}
+	return res[0], nil
+}
+
+func (v *filteredView) GetTwo(name string) (string, string, error) {
+	res, err := v.GetN(name, 2)
+	if err != nil {
+		return "", "", err
+	}
+	if len(res) < 2 {
+		return res[0], "", nil
+	}
+	return res[0], res[1], nil
+}
+
+// GetN returns ErrEmptyCircle if no member passes the filter.
+func (v *filteredView) GetN(name string, n int) ([]string, error) {
+	res, err := v.ring.GetNFiltered(name, n, v.exclude)
+	if err != nil {
+		return nil, err
+	}
+	if len(res) == 0 {
+		return nil, ErrEmptyCircle
+	}
+	return res, nil
+}
+
+func (v *filteredView) Members() []string {
+	var res []string
+	for _, m := range v.ring.Members() {
+		if v.filter(m) {
+			res = append(res, m)
+		}
+	}
+	return res
+}
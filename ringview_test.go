@@ -0,0 +1,32 @@
+package consistent
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestView(t *testing.T) {
+	x := New(newConfig())
+	x.Set([]string{"ssd-1", "ssd-2", "hdd-1", "hdd-2"})
+	ssd := x.View(func(m string) bool { return strings.HasPrefix(m, "ssd-") })
+	checkNum(len(ssd.Members()), 2, t)
+	for _, k := range []string{"a", "b", "c", "d", "e"} {
+		m, err := ssd.Get(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(m, "ssd-") {
+			t.Errorf("%s: got %s, expected an ssd member", k, m)
+		}
+		a, b, _ := ssd.GetTwo(k)
+		if a != m || b == a || !strings.HasPrefix(b, "ssd-") {
+			t.Errorf("%s: got %s, %s", k, a, b)
+		}
+	}
+	x.Add("ssd-3")
+	checkNum(len(ssd.Members()), 3, t)
+	x.Set([]string{"hdd-1"})
+	if _, err := ssd.Get("a"); err != ErrEmptyCircle {
+		t.Errorf("got %v, expected ErrEmptyCircle for a view without members", err)
+	}
+}
@@ -0,0 +1,92 @@
+package consistent
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+)
+
+// ErrRoundTrip is returned by VerifyRoundTrip when a restored ring differs from the original.
+var ErrRoundTrip = errors.New("consistent: ring changed across snapshot round trip")
+
+// Fingerprint returns a hash of the members, their replicas and the points of the circle,
+// equal for rings that route every key the same way and very likely different otherwise.
+// Quarantines and other per-process state do not change it.
+func (c *Consistent) Fingerprint() uint64 {
+	c.RLock()
+	defer c.RUnlock()
+	return c.fingerprint()
+}
+
+// need c.RLock() before calling
+func (c *Consistent) fingerprint() uint64 {
+	h := fnv.New64a()
+	var buf [4]byte
+	put := func(v uint32) {
+		binary.LittleEndian.PutUint32(buf[:], v)
+		h.Write(buf[:])
+	}
+	names := make([]string, 0, len(c.members))
+	for m := range c.members {
+		names = append(names, m)
+	}
+	sort.Strings(names)
+	for _, m := range names {
+		put(uint32(len(m)))
+		h.Write([]byte(m))
+		put(uint32(c.membersReplicas[m]))
+	}
+	points := make(uints, 0, len(c.circle))
+	for p := range c.circle {
+		points = append(points, p)
+	}
+	sort.Sort(points)
+	for _, p := range points {
+		put(p)
+		m := c.circle[p]
+		put(uint32(len(m)))
+		h.Write([]byte(m))
+	}
+	return h.Sum64()
+}
+
+// VerifyRoundTrip encodes the snapshot of the ring as JSON, as the stores of package store
+// do, decodes it, restores it into an emptied copy of the ring, so with all its settings,
+// and checks that both rings have the same members, replicas and fingerprint. The copy
+// has none of the hooks, flap detection or TTLs of the ring, so the run neither fires
+// Config.OnRebuild or Config.Warmup nor starts timers. Persistence backends can run it at
+// startup to check the ring survives them intact.
+func (c *Consistent) VerifyRoundTrip() error {
+	c.RLock()
+	r := c.cloneRing()
+	snap := c.snapshot()
+	want := c.fingerprint()
+	c.RUnlock()
+	r.Clear()
+
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	var back Snapshot
+	if err := json.Unmarshal(data, &back); err != nil {
+		return err
+	}
+	r.Restore(back)
+	got := r.Snapshot()
+	if len(got.Members) != len(snap.Members) {
+		return fmt.Errorf("%w: %d members restored as %d", ErrRoundTrip, len(snap.Members), len(got.Members))
+	}
+	for i, m := range got.Members {
+		if m != snap.Members[i] {
+			return fmt.Errorf("%w: member %+v restored as %+v", ErrRoundTrip, snap.Members[i], m)
+		}
+	}
+	if got := r.Fingerprint(); got != want {
+		return fmt.Errorf("%w: fingerprint %x restored as %x", ErrRoundTrip, want, got)
+	}
+	return nil
+}
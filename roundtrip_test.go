@@ -0,0 +1,76 @@
+package consistent
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestVerifyRoundTrip(t *testing.T) {
+	x := New(Config{DefaultNumberOfReplicas: 20, UseFnv: true})
+	if err := x.VerifyRoundTrip(); err != nil {
+		t.Errorf("empty ring: %v", err)
+	}
+	x.Add("a")
+	x.Add("b", 35)
+	x.AddWithSalt("c", "gen2", 7)
+	if err := x.VerifyRoundTrip(); err != nil {
+		t.Fatal(err)
+	}
+	// Restore reads 0 replicas as the default, so such members do not survive
+	x.Add("d", 0)
+	if err := x.VerifyRoundTrip(); !errors.Is(err, ErrRoundTrip) {
+		t.Errorf("got %v, expected ErrRoundTrip", err)
+	}
+}
+
+func TestVerifyRoundTripKetama(t *testing.T) {
+	for _, conf := range []Config{
+		{KetamaCompatible: true},
+		{DefaultNumberOfReplicas: 20, TieBreak: TieBreakJoinTime},
+	} {
+		x := New(conf)
+		x.Set([]string{"10.0.0.1:11211", "10.0.0.2:11211", "10.0.0.3:11211"})
+		if err := x.VerifyRoundTrip(); err != nil {
+			t.Errorf("%+v: %v", conf, err)
+		}
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	x := New(newConfig())
+	y := New(newConfig())
+	x.Add("a")
+	x.Add("b")
+	y.Add("b")
+	y.Add("a")
+	if x.Fingerprint() != y.Fingerprint() {
+		t.Errorf("expected equal rings to have equal fingerprints")
+	}
+	y.Remove("a")
+	y.Add("a", 21)
+	if x.Fingerprint() == y.Fingerprint() {
+		t.Errorf("expected a replica change to change the fingerprint")
+	}
+}
+
+func TestVerifyRoundTripHooks(t *testing.T) {
+	rebuilds, warmups := 0, 0
+	x := New(Config{
+		DefaultNumberOfReplicas: 20,
+		OnRebuild:               func(int, time.Duration) { rebuilds++ },
+		Warmup:                  func(string, []MovedRange) { warmups++ },
+		FlapThreshold:           1,
+		FlapWindow:              time.Minute,
+		FlapCooldown:            time.Minute,
+	})
+	x.Set([]string{"a", "b"})
+	x.AddWithTTL("c", time.Minute)
+	rebuilds, warmups = 0, 0
+	if err := x.VerifyRoundTrip(); err != nil {
+		t.Fatal(err)
+	}
+	if rebuilds != 0 || warmups != 0 {
+		t.Errorf("got %d rebuilds and %d warmups reported, expected none from the verification", rebuilds, warmups)
+	}
+}
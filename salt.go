@@ -0,0 +1,67 @@
+package consistent
+
+// AddWithSalt inserts elt like Add, mixing salt into the keys of its vnodes so that they
+// land on different points of the circle than those of an unsalted or differently salted
+// elt. Use it with something like an instance generation ID to deliberately remap the
+// range of a member replaced by an empty instance under the same name. If elt is already
+// a member with another salt, it is removed and added back with the new one.
+func (c *Consistent) AddWithSalt(elt, salt string, numbersOfReplicas ...int) {
+	numberOfReplicas := c.defaultNumberOfReplicas
+	if len(numbersOfReplicas) > 0 {
+		numberOfReplicas = numbersOfReplicas[0]
+	}
+	c.warmupAdd(joiner{SetElt: SetElt{Elt: elt, NumberOfReplicas: numberOfReplicas}, salt: salt}, func() bool { return c.salts[elt] != salt })
+	c.Lock()
+	defer c.unlockAndNotify()
+	if c.members[elt] {
+		if c.salts[elt] == salt {
+			return
+		}
+		c.remove(elt, c.membersReplicas[elt])
+	}
+	if salt != "" {
+		c.salts[elt] = salt
+	}
+	c.add(elt, numberOfReplicas)
+}
+
+// Salt returns the salt elt was added with, "" if none.
+func (c *Consistent) Salt(elt string) string {
+	c.RLock()
+	defer c.RUnlock()
+	return c.salts[elt]
+}
+
+// memberHash is the hash of elt itself, as used by weighted rendezvous, including its salt.
+func (c *Consistent) memberHash(elt string) uint32 {
+	if salt := c.salts[elt]; salt != "" {
+		return c.hashKey(elt + "|" + salt)
+	}
+	return c.hashKey(elt)
+}
+
+// AddWithHasher inserts elt like Add, placing its vnodes with h instead of the hasher of the
+// ring. Keys are still hashed with the hasher of the ring. When merging rings built with
+// different hash functions, adding the members of each with its original hasher keeps them
+// where they were, so few keys move. If elt is already a member it is left as it is.
+// Snapshots do not record the hasher, so Restore places such members with the hasher of
+// the ring.
+func (c *Consistent) AddWithHasher(elt string, h Hasher, numbersOfReplicas ...int) {
+	numberOfReplicas := c.defaultNumberOfReplicas
+	if len(numbersOfReplicas) > 0 {
+		numberOfReplicas = numbersOfReplicas[0]
+	}
+	c.warmupAdd(joiner{SetElt: SetElt{Elt: elt, NumberOfReplicas: numberOfReplicas}, hasher: h}, nil)
+	c.Lock()
+	defer c.unlockAndNotify()
+	if c.members[elt] {
+		return
+	}
+	if h != nil {
+		if c.memberHashers == nil {
+			c.memberHashers = make(map[string]Hasher)
+		}
+		c.memberHashers[elt] = h
+	}
+	c.add(elt, numberOfReplicas)
+}
@@ -0,0 +1,79 @@
+package consistent
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestAddWithSalt(t *testing.T) {
+	x := New(newConfig())
+	x.Add("a")
+	x.Add("b")
+	x.Add("c")
+	before := make(map[string]string)
+	for i := 0; i < 1000; i++ {
+		k := "user" + strconv.Itoa(i)
+		before[k], _ = x.Get(k)
+	}
+
+	var events []ChangeEvent
+	x.OnChange(func(ev ChangeEvent) { events = append(events, ev) })
+	x.AddWithSalt("c", "gen2")
+	x.AddWithSalt("c", "gen2")
+	if len(events) != 1 || len(events[0].Removed) != 1 || len(events[0].Added) != 1 {
+		t.Fatalf("unexpected events %+v", events)
+	}
+	if x.Salt("c") != "gen2" {
+		t.Errorf("got salt %q", x.Salt("c"))
+	}
+	checkNum(len(x.circle), 60, t)
+
+	moved := 0
+	for k, m := range before {
+		now, _ := x.Get(k)
+		if m != "c" && now != "c" && now != m {
+			t.Errorf("%s moved from %s to %s", k, m, now)
+		}
+		if m != now {
+			moved++
+		}
+	}
+	if moved == 0 {
+		t.Errorf("expected the salt to remap some keys")
+	}
+
+	x.Remove("c")
+	checkNum(len(x.circle), 40, t)
+	if x.Salt("c") != "" {
+		t.Errorf("expected the salt to be forgotten")
+	}
+	x.Add("c")
+	for k, m := range before {
+		if now, _ := x.Get(k); now != m {
+			t.Errorf("%s: got %s, expected %s without salt", k, now, m)
+		}
+	}
+}
+
+type fnvHasher struct{}
+
+func (fnvHasher) HashFunc(key string) uint32 { return hashKeyFnv(key) }
+
+func TestAddWithHasher(t *testing.T) {
+	x := New(newConfig())
+	x.AddWithHasher("a", fnvHasher{})
+	x.AddWithHasher("b", fnvHasher{})
+	x.Add("c")
+	for _, m := range []string{"a", "b"} {
+		for i := 0; i < 20; i++ {
+			if x.circle[hashKeyFnv(x.eltKey(m, i))] != m {
+				t.Errorf("vnode %d of %s is not where the FNV ring put it", i, m)
+			}
+		}
+	}
+	x.Remove("a")
+	checkNum(len(x.circle), 40, t)
+	if _, ok := x.memberHashers["a"]; ok {
+		t.Errorf("expected the hasher of a to be forgotten")
+	}
+}
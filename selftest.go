@@ -0,0 +1,78 @@
+package consistent
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"strconv"
+)
+
+// Thresholds of SelfTest. The default CRC32 hasher passes them; FNV is reported for
+// spreading short numeric keys unevenly over the top bits of the hash.
+const (
+	selfTestKeys         = 10000
+	selfTestBuckets      = 64
+	selfTestMinAvalanche = 8 // output bits flipped, on average, by flipping one input bit
+	selfTestMaxChiSquare = 150
+	selfTestMaxCollision = 0.01
+)
+
+// SelfTest checks the configured hasher against a battery of tests and returns a warning
+// for each one it fails, so a misbehaving custom Hasher is caught at startup before it
+// skews traffic. It checks that the hasher is deterministic, that flipping any bit of a key
+// flips enough bits of its hash (avalanche), and that short keys spread evenly over the
+// hash space and rarely collide.
+func (c *Consistent) SelfTest() []string {
+	var warnings []string
+	keys := make([]string, selfTestKeys)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+	}
+
+	for _, k := range keys[:100] {
+		if c.hashKey(k) != c.hashKey(k) {
+			warnings = append(warnings, fmt.Sprintf("hasher is not deterministic: key %q hashes differently", k))
+			break
+		}
+	}
+
+	// avalanche: flip every bit of 8-byte keys
+	worst, worstBit := math.MaxFloat64, 0
+	for bit := 0; bit < 64; bit++ {
+		total := 0
+		for i := 0; i < 256; i++ {
+			key := []byte(fmt.Sprintf("%08d", i*3919))
+			h := c.hashKey(string(key))
+			key[bit/8] ^= 1 << (bit % 8)
+			total += bits.OnesCount32(h ^ c.hashKey(string(key)))
+		}
+		if avg := float64(total) / 256; avg < worst {
+			worst, worstBit = avg, bit
+		}
+	}
+	if worst < selfTestMinAvalanche {
+		warnings = append(warnings, fmt.Sprintf("poor avalanche: flipping bit %d of a key flips %.1f bits of its hash on average", worstBit, worst))
+	}
+
+	// distribution of short keys over the top bits, which decide their place on the circle
+	var counts [selfTestBuckets]int
+	seen := make(map[uint32]bool, len(keys))
+	for _, k := range keys {
+		h := c.hashKey(k)
+		counts[h>>26]++
+		seen[h] = true
+	}
+	expected := float64(len(keys)) / selfTestBuckets
+	chi := 0.0
+	for _, n := range counts {
+		d := float64(n) - expected
+		chi += d * d / expected
+	}
+	if chi > selfTestMaxChiSquare {
+		warnings = append(warnings, fmt.Sprintf("uneven distribution of short keys: chi-square %.0f over %d buckets", chi, selfTestBuckets))
+	}
+	if rate := 1 - float64(len(seen))/float64(len(keys)); rate > selfTestMaxCollision {
+		warnings = append(warnings, fmt.Sprintf("%.1f%% of short keys collide", rate*100))
+	}
+	return warnings
+}
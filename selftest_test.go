@@ -0,0 +1,39 @@
+package consistent
+
+import (
+	"strings"
+	"testing"
+)
+
+type sumHasher struct{}
+
+func (sumHasher) HashFunc(key string) uint32 {
+	var h uint32
+	for i := 0; i < len(key); i++ {
+		h += uint32(key[i]) << 24
+	}
+	return h
+}
+
+type counterHasher struct{ n uint32 }
+
+func (h *counterHasher) HashFunc(key string) uint32 {
+	h.n++
+	return h.n
+}
+
+func TestSelfTest(t *testing.T) {
+	if w := New(newConfig()).SelfTest(); len(w) != 0 {
+		t.Errorf("unexpected warnings for CRC32: %v", w)
+	}
+	w := New(Config{CustomHasher: sumHasher{}}).SelfTest()
+	for _, want := range []string{"avalanche", "distribution", "collide"} {
+		if !strings.Contains(strings.Join(w, "\n"), want) {
+			t.Errorf("expected a %s warning, got %v", want, w)
+		}
+	}
+	w = New(Config{CustomHasher: &counterHasher{}}).SelfTest()
+	if len(w) == 0 || !strings.Contains(w[0], "deterministic") {
+		t.Errorf("expected a determinism warning, got %v", w)
+	}
+}
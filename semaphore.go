@@ -0,0 +1,126 @@
+package consistent
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+var (
+	// ErrNotOwner is returned by Semaphore.TryAcquire for a resource owned by another member.
+	ErrNotOwner = errors.New("consistent: resource owned by another member")
+	// ErrHeld is returned by Semaphore.TryAcquire for a resource already held.
+	ErrHeld = errors.New("consistent: resource already held")
+)
+
+// Semaphore gives a fleet a cheap "single worker per resource" primitive without a lock
+// service: each process holds a Semaphore for its own member, and may only acquire the
+// resources its member owns on the ring, once at a time. When a change of membership moves
+// a held resource to another member, its lease is lost and the worker must stop. The
+// guarantee is only as strong as the agreement of the fleet on the membership: while a
+// change propagates, the old and new owners may both hold a resource.
+type Semaphore struct {
+	c      *Consistent
+	self   string
+	cancel func()
+
+	mu   sync.Mutex
+	held map[string]*Lease
+}
+
+// Lease is a resource held through a Semaphore.
+type Lease struct {
+	Resource string
+	s        *Semaphore
+	lost     chan struct{}
+}
+
+// NewSemaphore creates a Semaphore for the process acting as member self of c.
+func NewSemaphore(c *Consistent, self string) *Semaphore {
+	s := &Semaphore{c: c, self: self, held: make(map[string]*Lease)}
+	s.cancel = c.OnChange(func(ChangeEvent) { s.revalidate() })
+	return s
+}
+
+// TryAcquire acquires resource if self owns it and it is not held yet. It returns
+// ErrEmptyCircle, ErrNotOwner or ErrHeld otherwise.
+func (s *Semaphore) TryAcquire(resource string) (*Lease, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.held[resource]; ok {
+		return nil, ErrHeld
+	}
+	s.c.RLock()
+	empty := len(s.c.circle) == 0
+	owned := false
+	if !empty {
+		m, _ := s.c.owner(resource)
+		owned = m == s.self
+	}
+	s.c.RUnlock()
+	if empty {
+		return nil, ErrEmptyCircle
+	}
+	if !owned {
+		return nil, ErrNotOwner
+	}
+	l := &Lease{Resource: resource, s: s, lost: make(chan struct{})}
+	s.held[resource] = l
+	return l, nil
+}
+
+// Held returns the resources held, sorted.
+func (s *Semaphore) Held() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := make([]string, 0, len(s.held))
+	for r := range s.held {
+		res = append(res, r)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Close stops following the changes of the ring and loses every lease.
+func (s *Semaphore) Close() {
+	s.cancel()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for r, l := range s.held {
+		close(l.lost)
+		delete(s.held, r)
+	}
+}
+
+// revalidate loses the leases of the resources self no longer owns.
+func (s *Semaphore) revalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.c.RLock()
+	defer s.c.RUnlock()
+	for r, l := range s.held {
+		owner := ""
+		if len(s.c.circle) > 0 {
+			owner, _ = s.c.owner(r)
+		}
+		if owner != s.self {
+			close(l.lost)
+			delete(s.held, r)
+		}
+	}
+}
+
+// Lost returns a channel closed when the lease is lost to a change of membership or to
+// Semaphore.Close. It is not closed by Release.
+func (l *Lease) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// Release releases the resource, so it can be acquired again.
+func (l *Lease) Release() {
+	l.s.mu.Lock()
+	defer l.s.mu.Unlock()
+	if l.s.held[l.Resource] == l {
+		delete(l.s.held, l.Resource)
+	}
+}
@@ -0,0 +1,70 @@
+package consistent
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestSemaphore(t *testing.T) {
+	x := New(newConfig())
+	if _, err := NewSemaphore(x, "a").TryAcquire("job"); err != ErrEmptyCircle {
+		t.Errorf("got %v, expected ErrEmptyCircle", err)
+	}
+	x.Set([]string{"a", "b", "c"})
+	sems := map[string]*Semaphore{"a": NewSemaphore(x, "a"), "b": NewSemaphore(x, "b"), "c": NewSemaphore(x, "c")}
+
+	leases := make(map[string]*Lease)
+	holder := make(map[string]string)
+	for i := 0; i < 100; i++ {
+		r := "job" + strconv.Itoa(i)
+		holders := 0
+		for m, s := range sems {
+			l, err := s.TryAcquire(r)
+			switch {
+			case err == nil:
+				holders++
+				leases[r] = l
+				holder[r] = m
+				if m != mustGet(t, x, r) {
+					t.Errorf("%s acquired by %s, owned by %s", r, m, mustGet(t, x, r))
+				}
+			case err != ErrNotOwner:
+				t.Errorf("unexpected error %v", err)
+			}
+		}
+		if holders != 1 {
+			t.Fatalf("%s acquired by %d members", r, holders)
+		}
+		if _, err := sems[mustGet(t, x, r)].TryAcquire(r); err != ErrHeld {
+			t.Errorf("got %v, expected ErrHeld", err)
+		}
+	}
+
+	// the leases of c are lost when it leaves, the others kept
+	x.Remove("c")
+	for r, l := range leases {
+		select {
+		case <-l.Lost():
+			if holder[r] != "c" {
+				t.Errorf("%s lost by %s though still owned", r, holder[r])
+			}
+		default:
+			if holder[r] == "c" {
+				t.Errorf("%s kept by c after it left", r)
+			}
+		}
+	}
+	if len(sems["c"].Held()) != 0 {
+		t.Errorf("c still holds %v", sems["c"].Held())
+	}
+
+	r := sems["a"].Held()[0]
+	leases[r].Release()
+	if _, err := sems["a"].TryAcquire(r); err != nil {
+		t.Errorf("got %v after Release", err)
+	}
+	sems["a"].Close()
+	if len(sems["a"].Held()) != 0 {
+		t.Error("leases kept after Close")
+	}
+}
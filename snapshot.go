@@ -0,0 +1,121 @@
+package consistent
+
+import (
+	"context"
+	"errors"
+	"sort"
+)
+
+// ErrNoSnapshot is returned by a Store that holds no snapshot yet.
+var ErrNoSnapshot = errors.New("consistent: no snapshot stored")
+
+// SnapshotMember is one member of a Snapshot.
+type SnapshotMember struct {
+	Name     string `json:"name"`
+	Replicas int    `json:"replicas"`
+	Salt     string `json:"salt,omitempty"`
+}
+
+// Snapshot is the membership of a ring, sorted by name.
+type Snapshot struct {
+	Members []SnapshotMember `json:"members"`
+}
+
+// Store persists snapshots and shares them between processes. Implementations for files,
+// etcd and Redis are in package store.
+type Store interface {
+	// Load returns the stored snapshot, or ErrNoSnapshot.
+	Load(ctx context.Context) (Snapshot, error)
+	// Save replaces the stored snapshot.
+	Save(ctx context.Context, s Snapshot) error
+	// Watch calls fn with the stored snapshot, if any, then with every snapshot saved
+	// after it, possibly coalescing quick successive ones, until ctx is done or watching
+	// fails. It returns the error that stopped it. Changes are watched before the stored
+	// snapshot is read, so that none saved in between is missed.
+	Watch(ctx context.Context, fn func(Snapshot)) error
+}
+
+// Snapshot returns the membership of the ring.
+func (c *Consistent) Snapshot() Snapshot {
+	c.RLock()
+	defer c.RUnlock()
+	return c.snapshot()
+}
+
+// need c.RLock() before calling
+func (c *Consistent) snapshot() Snapshot {
+	s := Snapshot{Members: make([]SnapshotMember, 0, len(c.members))}
+	for m := range c.members {
+		s.Members = append(s.Members, SnapshotMember{Name: m, Replicas: c.membersReplicas[m], Salt: c.salts[m]})
+	}
+	sort.Slice(s.Members, func(i, j int) bool { return s.Members[i].Name < s.Members[j].Name })
+	return s
+}
+
+// Restore makes the membership of the ring that of s. Members whose replicas or salt differ
+// are removed and added back. Restore does nothing if the change is rejected by Config.Guard.
+func (c *Consistent) Restore(s Snapshot) {
+	c.warmupRestore(s)
+	c.Lock()
+	defer c.unlockAndNotify()
+	c.restore(s)
+}
+
+// need c.Lock() before calling
+// restore reports false if the change was rejected by Config.Guard.
+func (c *Consistent) restore(s Snapshot) bool {
+	removed, added := c.restorePlan(s)
+	if c.guard != nil && !c.allow(removed, added) {
+		return false
+	}
+	for _, k := range removed {
+		c.remove(k, c.membersReplicas[k])
+	}
+	for _, v := range added {
+		if v.salt != "" {
+			c.salts[v.Elt] = v.salt
+		}
+		c.add(v.Elt, v.NumberOfReplicas)
+	}
+	return true
+}
+
+// need c.RLock() before calling
+// restorePlan returns the members restoring s removes, those whose replicas or salt differ
+// included, and those it adds.
+func (c *Consistent) restorePlan(s Snapshot) (removed []string, added []joiner) {
+	want := make(map[string]SnapshotMember, len(s.Members))
+	for _, m := range s.Members {
+		if m.Replicas == 0 {
+			m.Replicas = c.defaultNumberOfReplicas
+		}
+		want[m.Name] = m
+	}
+	for k := range c.members {
+		if m, ok := want[k]; !ok || m.Replicas != c.membersReplicas[k] || m.Salt != c.salts[k] {
+			removed = append(removed, k)
+		}
+	}
+	for _, m := range s.Members {
+		m = want[m.Name]
+		if (!c.members[m.Name] || sliceContainsMember(removed, m.Name)) && !containsElt(added, m.Name) {
+			added = append(added, joiner{SetElt: SetElt{Elt: m.Name, NumberOfReplicas: m.Replicas}, salt: m.Salt})
+		}
+	}
+	return removed, added
+}
+
+// Follow restores the ring from the snapshot in s, then from every snapshot saved to it,
+// until ctx is done or watching fails. A store holding no snapshot yet leaves the ring as it is.
+func (c *Consistent) Follow(ctx context.Context, s Store) error {
+	return s.Watch(ctx, c.Restore)
+}
+
+func containsElt(elts []joiner, elt string) bool {
+	for _, v := range elts {
+		if v.Elt == elt {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package consistent
+
+import "testing"
+
+func TestSnapshotRestore(t *testing.T) {
+	x := New(newConfig())
+	x.Add("a")
+	x.Add("b", 40)
+	x.AddWithSalt("c", "gen2")
+	s := x.Snapshot()
+	if len(s.Members) != 3 || s.Members[1] != (SnapshotMember{"b", 40, ""}) || s.Members[2].Salt != "gen2" {
+		t.Fatalf("unexpected snapshot %+v", s)
+	}
+
+	y := New(newConfig())
+	y.Add("b")
+	y.Add("d")
+	var events []ChangeEvent
+	y.OnChange(func(ev ChangeEvent) { events = append(events, ev) })
+	y.Restore(s)
+	if got := y.Snapshot(); len(got.Members) != 3 || got.Members[1] != s.Members[1] || got.Members[2] != s.Members[2] {
+		t.Errorf("got %+v, expected %+v", got, s)
+	}
+	for _, k := range []string{"ggg", "hhh", "iii", "jjj"} {
+		a, _ := x.Get(k)
+		b, _ := y.Get(k)
+		if a != b {
+			t.Errorf("%s: got %s, expected %s", k, b, a)
+		}
+	}
+	if len(events) != 1 || len(events[0].Removed) != 2 || len(events[0].Added) != 3 {
+		t.Errorf("unexpected events %+v", events)
+	}
+	y.Restore(s)
+	checkNum(len(events), 1, t)
+}
@@ -0,0 +1,21 @@
+package consistent
+
+// Split deterministically assigns key to one of the variants of ratios, each variant
+// receiving a share of keys proportional to its ratio. It uses the same weighted rendezvous
+// scoring as Config.WeightedRendezvous, so when ratios change only the keys needed to reach
+// the new proportions are reassigned. Variants with a ratio <= 0 receive no keys; Split
+// returns "" if there are none left.
+func Split(key string, ratios map[string]float64) string {
+	h := hashKeyCRC32(key)
+	best, bestScore := "", 0.0
+	for variant, ratio := range ratios {
+		if ratio <= 0 {
+			continue
+		}
+		score := weightedScore(h, weightedMember{name: variant, hash: hashKeyCRC32(variant), weight: ratio})
+		if best == "" || score > bestScore || (score == bestScore && variant < best) {
+			best, bestScore = variant, score
+		}
+	}
+	return best
+}
@@ -0,0 +1,49 @@
+package consistent
+
+import (
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestSplitProportions(t *testing.T) {
+	ratios := map[string]float64{"control": 0.9, "canary": 0.1}
+	dist := make(map[string]int)
+	const keys = 20000
+	for i := 0; i < keys; i++ {
+		dist[Split("user"+strconv.Itoa(i), ratios)]++
+	}
+	share := float64(dist["canary"]) / keys
+	if math.Abs(share-0.1) > 0.01 {
+		t.Errorf("canary got %.3f of the keys, expected about 0.1", share)
+	}
+}
+
+func TestSplitMinimalReassignment(t *testing.T) {
+	before := map[string]float64{"control": 0.9, "canary": 0.1}
+	after := map[string]float64{"control": 0.8, "canary": 0.2}
+	moved := 0
+	const keys = 20000
+	for i := 0; i < keys; i++ {
+		k := "user" + strconv.Itoa(i)
+		a, b := Split(k, before), Split(k, after)
+		if a == "canary" && b != "canary" {
+			t.Fatalf("%s left the canary although its share grew", k)
+		}
+		if a != b {
+			moved++
+		}
+	}
+	if share := float64(moved) / keys; share > 0.13 {
+		t.Errorf("%.3f of the keys moved, expected about 0.1", share)
+	}
+}
+
+func TestSplitEmpty(t *testing.T) {
+	if v := Split("user", map[string]float64{"off": 0}); v != "" {
+		t.Errorf("got %q, expected no variant", v)
+	}
+	if v := Split("user", map[string]float64{"only": 1}); v != "only" {
+		t.Errorf("got %q, expected the only variant", v)
+	}
+}
@@ -0,0 +1,125 @@
+package consistent
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a point-in-time view of the activity and shape of a ring, as returned by
+// StatsSnapshot. Counters only grow, so two snapshots can be compared with Sub.
+type Stats struct {
+	// Lookups and Errors count the calls to Get, GetTwo and GetN, and those that failed.
+	Lookups uint64
+	Errors  uint64
+	// Writes counts the calls that may change the membership, Version those that did,
+	// including the start and end of quarantines.
+	Writes  uint64
+	Version uint64
+	// LastChange is the time of the last membership change, zero if there was none.
+	LastChange time.Time
+	Members    int
+	Vnodes     int
+	// Imbalance is the largest ratio, over the members, of the share of the circle a member
+	// owns to the share its replicas entitle it to. 1 is a perfect balance.
+	Imbalance float64
+	// Rebuilds counts the rebuilds of the sorted hashes caused by membership changes, and
+	// RebuildTime is the total time they took. LastRebuildTime and LastRebuildVnodes describe
+	// the last one.
+	Rebuilds          uint64
+	RebuildTime       time.Duration
+	LastRebuildTime   time.Duration
+	LastRebuildVnodes int
+}
+
+// Sub returns the counters of s minus those of prev, keeping the other fields of s.
+func (s Stats) Sub(prev Stats) Stats {
+	s.Lookups -= prev.Lookups
+	s.Errors -= prev.Errors
+	s.Writes -= prev.Writes
+	s.Version -= prev.Version
+	s.Rebuilds -= prev.Rebuilds
+	s.RebuildTime -= prev.RebuildTime
+	return s
+}
+
+// ringStats holds the counters of a ring. Lookups and errors are updated atomically under
+// the read lock, the rest under the write lock.
+type ringStats struct {
+	lookups    uint64
+	errors     uint64
+	writes     uint64
+	version    uint64
+	lastChange time.Time
+
+	rebuilds          uint64
+	rebuildTime       time.Duration
+	lastRebuildTime   time.Duration
+	lastRebuildVnodes int
+}
+
+func (s *ringStats) lookup(err error) {
+	atomic.AddUint64(&s.lookups, 1)
+	if err != nil {
+		atomic.AddUint64(&s.errors, 1)
+	}
+}
+
+// lookupBatch counts n lookups failing with err, if not nil.
+func (s *ringStats) lookupBatch(n int, err error) {
+	atomic.AddUint64(&s.lookups, uint64(n))
+	if err != nil {
+		atomic.AddUint64(&s.errors, uint64(n))
+	}
+}
+
+func (s *ringStats) rebuilt(vnodes int, took time.Duration, hook func(int, time.Duration)) {
+	s.rebuilds++
+	s.rebuildTime += took
+	s.lastRebuildTime = took
+	s.lastRebuildVnodes = vnodes
+	if hook != nil {
+		hook(vnodes, took)
+	}
+}
+
+// StatsSnapshot returns the statistics of the ring.
+func (c *Consistent) StatsSnapshot() Stats {
+	c.RLock()
+	defer c.RUnlock()
+	return c.statsSnapshot()
+}
+
+// need c.RLock() before calling
+func (c *Consistent) statsSnapshot() Stats {
+	s := Stats{
+		Lookups:    atomic.LoadUint64(&c.stats.lookups),
+		Errors:     atomic.LoadUint64(&c.stats.errors),
+		Writes:     c.stats.writes,
+		Version:    c.stats.version,
+		LastChange: c.stats.lastChange,
+		Members:    len(c.members),
+		Vnodes:     len(c.sortedHashes),
+
+		Rebuilds:          c.stats.rebuilds,
+		RebuildTime:       c.stats.rebuildTime,
+		LastRebuildTime:   c.stats.lastRebuildTime,
+		LastRebuildVnodes: c.stats.lastRebuildVnodes,
+	}
+	if len(c.sortedHashes) == 0 {
+		return s
+	}
+	total := 0
+	owners := make(map[string]bool)
+	for _, h := range c.sortedHashes {
+		owners[c.circle[h]] = true
+	}
+	for m := range owners {
+		total += c.membersReplicas[m]
+	}
+	for m, share := range ownershipShares(c.sortedHashes, c.circle) {
+		if r := share * float64(total) / float64(c.membersReplicas[m]); r > s.Imbalance {
+			s.Imbalance = r
+		}
+	}
+	return s
+}
@@ -0,0 +1,70 @@
+package consistent
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStatsSnapshot(t *testing.T) {
+	x := New(newConfig())
+	x.Get("ggg")
+	x.Add("a")
+	x.Add("a")
+	x.Add("b", 60)
+	first := x.StatsSnapshot()
+	if first.Lookups != 1 || first.Errors != 1 || first.Writes != 3 || first.Version != 2 {
+		t.Errorf("unexpected counters %+v", first)
+	}
+	if first.LastChange.IsZero() || first.Members != 2 || first.Vnodes != 80 {
+		t.Errorf("unexpected stats %+v", first)
+	}
+	if first.Imbalance < 1 || first.Imbalance > 3 {
+		t.Errorf("unexpected imbalance %v", first.Imbalance)
+	}
+
+	x.Get("ggg")
+	x.GetTwo("ggg")
+	x.GetN("ggg", 2)
+	x.Remove("a")
+	d := x.StatsSnapshot().Sub(first)
+	if d.Lookups != 3 || d.Errors != 0 || d.Writes != 1 || d.Version != 1 || d.Members != 1 {
+		t.Errorf("unexpected difference %+v", d)
+	}
+	if d.Imbalance != 1 {
+		t.Errorf("got imbalance %v with one member", d.Imbalance)
+	}
+}
+
+func TestManagerStatsSnapshot(t *testing.T) {
+	m := NewManager(newConfig())
+	m.Ring("cache").Add("a")
+	m.Ring("db").Set([]string{"b", "c"})
+	m.Ring("db").Get("ggg")
+	total, rings := m.StatsSnapshot()
+	if len(rings) != 2 || rings["db"].Lookups != 1 || rings["cache"].Members != 1 {
+		t.Errorf("unexpected rings %+v", rings)
+	}
+	if total.Members != 3 || total.Version != 2 || total.Lookups != 1 || total.LastChange != rings["db"].LastChange {
+		t.Errorf("unexpected total %+v", total)
+	}
+}
+
+func TestRebuildStats(t *testing.T) {
+	var sizes []int
+	x := New(Config{DefaultNumberOfReplicas: 20, OnRebuild: func(vnodes int, took time.Duration) {
+		if took < 0 {
+			t.Errorf("negative rebuild time %v", took)
+		}
+		sizes = append(sizes, vnodes)
+	}})
+	x.Add("a")
+	x.Add("b")
+	x.Remove("a")
+	s := x.StatsSnapshot()
+	if s.Rebuilds != 3 || s.LastRebuildVnodes != 20 || s.RebuildTime < s.LastRebuildTime {
+		t.Errorf("unexpected rebuild stats %+v", s)
+	}
+	if len(sizes) != 3 || sizes[0] != 20 || sizes[1] != 40 || sizes[2] != 20 {
+		t.Errorf("unexpected rebuild sizes %v", sizes)
+	}
+}
@@ -0,0 +1,69 @@
+package store
+
+import (
+	"context"
+
+	"github.com/jiangz222/consistent"
+)
+
+// EtcdClient is the subset of an etcd client the Etcd store uses.
+type EtcdClient interface {
+	// Get returns the value of key, or nil if it does not exist.
+	Get(ctx context.Context, key string) ([]byte, error)
+	Put(ctx context.Context, key string, value []byte) error
+	// Watch returns a channel receiving every new value of key, closed when ctx is done
+	// or the watch fails.
+	Watch(ctx context.Context, key string) <-chan []byte
+}
+
+// Etcd stores snapshots as JSON, or with Codec, under a key of etcd.
+type Etcd struct {
+	Client EtcdClient
+	Key    string
+	// Codec encodes the snapshots. Defaults to consistent.JSONCodec.
+	Codec consistent.Codec
+}
+
+// NewEtcd creates an Etcd store keeping snapshots under key.
+func NewEtcd(client EtcdClient, key string) *Etcd {
+	return &Etcd{Client: client, Key: key}
+}
+
+// Load reads the snapshot under the key.
+func (e *Etcd) Load(ctx context.Context) (consistent.Snapshot, error) {
+	data, err := e.Client.Get(ctx, e.Key)
+	if err != nil {
+		return consistent.Snapshot{}, err
+	}
+	if data == nil {
+		return consistent.Snapshot{}, consistent.ErrNoSnapshot
+	}
+	return codec(e.Codec).DecodeSnapshot(data)
+}
+
+// Save puts s under the key.
+func (e *Etcd) Save(ctx context.Context, s consistent.Snapshot) error {
+	data, err := codec(e.Codec).EncodeSnapshot(s)
+	if err != nil {
+		return err
+	}
+	return e.Client.Put(ctx, e.Key, data)
+}
+
+// Watch calls fn with the snapshot under the key, if any, then with every value put under
+// it. Values that are not snapshots are skipped. It returns ErrWatchClosed if the watch
+// fails.
+func (e *Etcd) Watch(ctx context.Context, fn func(consistent.Snapshot)) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	values := e.Client.Watch(ctx, e.Key)
+	if err := load(ctx, e, fn); err != nil {
+		return err
+	}
+	for data := range values {
+		if s, err := codec(e.Codec).DecodeSnapshot(data); err == nil {
+			fn(s)
+		}
+	}
+	return closed(ctx)
+}
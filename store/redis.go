@@ -0,0 +1,77 @@
+package store
+
+import (
+	"context"
+
+	"github.com/jiangz222/consistent"
+)
+
+// RedisClient is the subset of a Redis client the Redis store uses.
+type RedisClient interface {
+	// Get returns the value of key, or nil if it does not exist.
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte) error
+	Publish(ctx context.Context, channel string, message []byte) error
+	// Subscribe returns a channel receiving the messages published to channel, closed
+	// when ctx is done or the subscription fails.
+	Subscribe(ctx context.Context, channel string) (<-chan []byte, error)
+}
+
+// Redis stores snapshots as JSON, or with Codec, under a key of Redis, and announces new
+// ones on a channel of the same name.
+type Redis struct {
+	Client RedisClient
+	Key    string
+	// Codec encodes the snapshots. Defaults to consistent.JSONCodec.
+	Codec consistent.Codec
+}
+
+// NewRedis creates a Redis store keeping snapshots under key.
+func NewRedis(client RedisClient, key string) *Redis {
+	return &Redis{Client: client, Key: key}
+}
+
+// Load reads the snapshot under the key.
+func (r *Redis) Load(ctx context.Context) (consistent.Snapshot, error) {
+	data, err := r.Client.Get(ctx, r.Key)
+	if err != nil {
+		return consistent.Snapshot{}, err
+	}
+	if data == nil {
+		return consistent.Snapshot{}, consistent.ErrNoSnapshot
+	}
+	return codec(r.Codec).DecodeSnapshot(data)
+}
+
+// Save sets the key to s and publishes s on the channel.
+func (r *Redis) Save(ctx context.Context, s consistent.Snapshot) error {
+	data, err := codec(r.Codec).EncodeSnapshot(s)
+	if err != nil {
+		return err
+	}
+	if err := r.Client.Set(ctx, r.Key, data); err != nil {
+		return err
+	}
+	return r.Client.Publish(ctx, r.Key, data)
+}
+
+// Watch calls fn with the snapshot under the key, if any, then with every snapshot
+// published on the channel. Messages that are not snapshots are skipped. It returns
+// ErrWatchClosed if the subscription fails.
+func (r *Redis) Watch(ctx context.Context, fn func(consistent.Snapshot)) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	msgs, err := r.Client.Subscribe(ctx, r.Key)
+	if err != nil {
+		return err
+	}
+	if err := load(ctx, r, fn); err != nil {
+		return err
+	}
+	for data := range msgs {
+		if s, err := codec(r.Codec).DecodeSnapshot(data); err == nil {
+			fn(s)
+		}
+	}
+	return closed(ctx)
+}
@@ -0,0 +1,152 @@
+// Package store provides consistent.Store implementations persisting ring snapshots to a
+// file, etcd or Redis.
+//
+// The etcd and Redis stores do not depend on any client library. They use a small
+// interface that an adapter around the client of your choice implements, typically a few
+// lines around clientv3.Client or redis.Client.
+package store
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jiangz222/consistent"
+)
+
+var (
+	_ consistent.Store = (*File)(nil)
+	_ consistent.Store = (*Etcd)(nil)
+	_ consistent.Store = (*Redis)(nil)
+)
+
+// ErrWatchClosed is returned by Watch when the backend closes the watch before ctx is done.
+var ErrWatchClosed = errors.New("store: watch closed")
+
+// DefaultPollInterval is how often File checks its file for changes by default.
+const DefaultPollInterval = time.Second
+
+// File stores snapshots as JSON, or with Codec, in a file. Save replaces the file
+// atomically, and Watch polls its modification time.
+type File struct {
+	Path string
+	// Codec encodes the snapshots. Defaults to consistent.JSONCodec.
+	Codec consistent.Codec
+	// PollInterval is how often Watch checks the file. Defaults to DefaultPollInterval.
+	PollInterval time.Duration
+}
+
+// NewFile creates a File store at path.
+func NewFile(path string) *File {
+	return &File{Path: path}
+}
+
+// Load reads the snapshot in the file.
+func (f *File) Load(ctx context.Context) (consistent.Snapshot, error) {
+	data, err := ioutil.ReadFile(f.Path)
+	if os.IsNotExist(err) {
+		return consistent.Snapshot{}, consistent.ErrNoSnapshot
+	}
+	if err != nil {
+		return consistent.Snapshot{}, err
+	}
+	return codec(f.Codec).DecodeSnapshot(data)
+}
+
+// Save writes s to a temporary file next to the file and renames it over the file.
+func (f *File) Save(ctx context.Context, s consistent.Snapshot) error {
+	data, err := codec(f.Codec).EncodeSnapshot(s)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(f.Path), filepath.Base(f.Path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), f.Path)
+}
+
+// Watch calls fn with the snapshot in the file, if any, then polls the file and calls fn
+// each time it changes.
+func (f *File) Watch(ctx context.Context, fn func(consistent.Snapshot)) error {
+	interval := f.PollInterval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	last := f.modTime()
+	if err := load(ctx, f, fn); err != nil {
+		return err
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+		mod := f.modTime()
+		if mod.Equal(last) {
+			continue
+		}
+		last = mod
+		s, err := f.Load(ctx)
+		if err == consistent.ErrNoSnapshot {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		fn(s)
+	}
+}
+
+func (f *File) modTime() time.Time {
+	fi, err := os.Stat(f.Path)
+	if err != nil {
+		return time.Time{}
+	}
+	return fi.ModTime()
+}
+
+// load calls fn with the snapshot in s, if any.
+func load(ctx context.Context, s consistent.Store, fn func(consistent.Snapshot)) error {
+	snap, err := s.Load(ctx)
+	if err == consistent.ErrNoSnapshot {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	fn(snap)
+	return nil
+}
+
+// closed returns the error of a watch whose channel was closed: ctx.Err() if ctx is done,
+// ErrWatchClosed otherwise.
+func closed(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return ErrWatchClosed
+}
+
+// codec returns c, or consistent.JSONCodec if c is nil.
+func codec(c consistent.Codec) consistent.Codec {
+	if c == nil {
+		return consistent.JSONCodec{}
+	}
+	return c
+}
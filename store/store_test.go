@@ -0,0 +1,202 @@
+package store
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jiangz222/consistent"
+)
+
+var snap = consistent.Snapshot{Members: []consistent.SnapshotMember{
+	{Name: "a", Replicas: 20},
+	{Name: "b", Replicas: 40, Salt: "gen2"},
+}}
+
+// memory implements EtcdClient and RedisClient.
+type memory struct {
+	mu   sync.Mutex
+	data map[string][]byte
+	subs []chan []byte
+}
+
+func newMemory() *memory {
+	return &memory{data: make(map[string][]byte)}
+}
+
+func (m *memory) Get(ctx context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.data[key], nil
+}
+
+func (m *memory) Put(ctx context.Context, key string, value []byte) error {
+	m.Set(ctx, key, value)
+	return m.Publish(ctx, key, value)
+}
+
+func (m *memory) Set(ctx context.Context, key string, value []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data[key] = value
+	return nil
+}
+
+func (m *memory) Publish(ctx context.Context, channel string, message []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, ch := range m.subs {
+		ch <- message
+	}
+	return nil
+}
+
+func (m *memory) Subscribe(ctx context.Context, channel string) (<-chan []byte, error) {
+	return m.Watch(ctx, channel), nil
+}
+
+func (m *memory) Watch(ctx context.Context, key string) <-chan []byte {
+	ch := make(chan []byte, 8)
+	m.mu.Lock()
+	m.subs = append(m.subs, ch)
+	m.mu.Unlock()
+	go func() {
+		<-ctx.Done()
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		for i, c := range m.subs {
+			if c == ch {
+				m.subs = append(m.subs[:i], m.subs[i+1:]...)
+				break
+			}
+		}
+		close(ch)
+	}()
+	return ch
+}
+
+func testStore(t *testing.T, s consistent.Store) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := s.Load(ctx); err != consistent.ErrNoSnapshot {
+		t.Fatalf("got %v, expected ErrNoSnapshot", err)
+	}
+
+	x := consistent.New(consistent.Config{DefaultNumberOfReplicas: 20})
+	done := make(chan error, 1)
+	go func() { done <- x.Follow(ctx, s) }()
+	time.Sleep(20 * time.Millisecond)
+	if err := s.Save(ctx, snap); err != nil {
+		t.Fatal(err)
+	}
+	for len(x.Members()) != 2 {
+		select {
+		case err := <-done:
+			t.Fatalf("Follow stopped: %v", err)
+		case <-ctx.Done():
+			t.Fatal("snapshot was not applied")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+	if got, err := s.Load(ctx); err != nil || len(got.Members) != 2 || got.Members[1] != snap.Members[1] {
+		t.Errorf("got %+v, %v", got, err)
+	}
+	if x.Salt("b") != "gen2" || x.MemberReplicas()["b"] != 40 {
+		t.Errorf("unexpected ring %+v", x.Snapshot())
+	}
+	cancel()
+	<-done
+}
+
+func TestFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	f := NewFile(filepath.Join(dir, "ring.json"))
+	f.PollInterval = 5 * time.Millisecond
+	testStore(t, f)
+}
+
+func TestEtcd(t *testing.T) {
+	testStore(t, NewEtcd(newMemory(), "/ring"))
+}
+
+func TestRedis(t *testing.T) {
+	testStore(t, NewRedis(newMemory(), "ring"))
+}
+
+func TestCodecs(t *testing.T) {
+	for _, c := range []consistent.Codec{consistent.BinaryCodec{}, consistent.ProtobufCodec{}} {
+		e := NewEtcd(newMemory(), "/ring")
+		e.Codec = c
+		testStore(t, e)
+	}
+}
+
+// racy saves a snapshot the first time it is read, as if it had been saved between the
+// read and the start of the watch.
+type racy struct {
+	*memory
+	once sync.Once
+}
+
+func (r *racy) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := r.memory.Get(ctx, key)
+	r.once.Do(func() {
+		b, _ := consistent.JSONCodec{}.EncodeSnapshot(snap)
+		r.Put(ctx, key, b)
+	})
+	return data, err
+}
+
+func TestFollowSavedDuringLoad(t *testing.T) {
+	for _, s := range []consistent.Store{NewEtcd(&racy{memory: newMemory()}, "/ring"), NewRedis(&racy{memory: newMemory()}, "ring")} {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		x := consistent.New(consistent.Config{DefaultNumberOfReplicas: 20})
+		done := make(chan error, 1)
+		go func() { done <- x.Follow(ctx, s) }()
+		for len(x.Members()) != 2 {
+			select {
+			case <-ctx.Done():
+				t.Fatalf("%T: the snapshot saved during the load was missed", s)
+			case <-time.After(5 * time.Millisecond):
+			}
+		}
+		cancel()
+		<-done
+	}
+}
+
+// broken closes its watches right away, as a client whose connection failed.
+type broken struct {
+	*memory
+}
+
+func (b broken) Watch(ctx context.Context, key string) <-chan []byte {
+	ch := make(chan []byte)
+	close(ch)
+	return ch
+}
+
+func (b broken) Subscribe(ctx context.Context, channel string) (<-chan []byte, error) {
+	return b.Watch(ctx, channel), nil
+}
+
+func TestWatchClosed(t *testing.T) {
+	for _, s := range []consistent.Store{NewEtcd(broken{newMemory()}, "/ring"), NewRedis(broken{newMemory()}, "ring")} {
+		if err := s.Watch(context.Background(), func(consistent.Snapshot) {}); err != ErrWatchClosed {
+			t.Errorf("%T: got %v, expected ErrWatchClosed", s, err)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := NewEtcd(broken{newMemory()}, "/ring").Watch(ctx, func(consistent.Snapshot) {}); err != context.Canceled {
+		t.Errorf("got %v, expected context.Canceled", err)
+	}
+}
@@ -0,0 +1,48 @@
+package consistent
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNotStrict is returned by Config.Validate for a strict config that could let two
+// processes build different rings.
+var ErrNotStrict = errors.New("consistent: config not allowed in strict mode")
+
+// Validate checks a config with Strict set: the number of replicas must be given rather
+// than defaulted, the hasher unambiguous, and no option may make the placement depend on
+// timing, as the flap detector and EvictLRU do. AddWithTTL, whose members expire on
+// timing too, panics on a strict ring. Configs without Strict are always valid.
+func (conf Config) Validate() error {
+	if !conf.Strict {
+		return nil
+	}
+	if conf.DefaultNumberOfReplicas <= 0 {
+		return fmt.Errorf("%w: DefaultNumberOfReplicas must be set", ErrNotStrict)
+	}
+	if conf.UseFnv && conf.CustomHasher != nil {
+		return fmt.Errorf("%w: UseFnv and CustomHasher are both set", ErrNotStrict)
+	}
+	if conf.Hash != HashCRC32 && (conf.CustomHasher != nil || conf.UseFnv && conf.Hash != HashFnv) {
+		return fmt.Errorf("%w: Hash %s conflicts with the hasher set", ErrNotStrict, conf.Hash)
+	}
+	if conf.KetamaCompatible && (conf.Hash != HashCRC32 || conf.UseFnv || conf.CustomHasher != nil || conf.KeyDeriver != nil) {
+		return fmt.Errorf("%w: KetamaCompatible ignores the hasher and key deriver set", ErrNotStrict)
+	}
+	if conf.KetamaCompatible && conf.DefaultNumberOfReplicas%4 != 0 {
+		return fmt.Errorf("%w: KetamaCompatible needs a multiple of 4 replicas", ErrNotStrict)
+	}
+	if conf.ExpectedReplicas > 0 && conf.ExpectedReplicas != conf.DefaultNumberOfReplicas {
+		return fmt.Errorf("%w: ExpectedReplicas %d differs from DefaultNumberOfReplicas %d", ErrNotStrict, conf.ExpectedReplicas, conf.DefaultNumberOfReplicas)
+	}
+	if conf.FlapThreshold > 0 {
+		return fmt.Errorf("%w: FlapThreshold routes keys depending on timing", ErrNotStrict)
+	}
+	if conf.MaxMembers > 0 {
+		switch conf.Eviction.(type) {
+		case EvictLRU, *EvictLRU:
+			return fmt.Errorf("%w: EvictLRU evicts members depending on heartbeat timing", ErrNotStrict)
+		}
+	}
+	return nil
+}
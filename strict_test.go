@@ -0,0 +1,52 @@
+package consistent
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStrict(t *testing.T) {
+	valid := Config{Strict: true, DefaultNumberOfReplicas: 20, UseFnv: true}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	x, y := New(valid), New(valid)
+	x.Set([]string{"a", "b", "c"})
+	y.Set([]string{"c", "b", "a"})
+	if x.Fingerprint() != y.Fingerprint() {
+		t.Errorf("expected identical rings")
+	}
+	for _, conf := range []Config{
+		{Strict: true},
+		{Strict: true, DefaultNumberOfReplicas: 20, UseFnv: true, CustomHasher: fnvHasher{}},
+		{Strict: true, DefaultNumberOfReplicas: 20, ExpectedReplicas: 30},
+		{Strict: true, DefaultNumberOfReplicas: 20, FlapThreshold: 3},
+		{Strict: true, DefaultNumberOfReplicas: 20, MaxMembers: 3, Eviction: EvictLRU{}},
+		{Strict: true, DefaultNumberOfReplicas: 20, MaxMembers: 3, Eviction: &EvictLRU{}},
+	} {
+		if err := conf.Validate(); !errors.Is(err, ErrNotStrict) {
+			t.Errorf("%+v: got %v, expected ErrNotStrict", conf, err)
+		}
+	}
+	if err := (Config{FlapThreshold: 3}).Validate(); err != nil {
+		t.Errorf("expected configs without Strict to be valid, got %v", err)
+	}
+	if err := (Config{Strict: true, DefaultNumberOfReplicas: 20, MaxMembers: 3, Eviction: EvictLowestWeight{}}).Validate(); err != nil {
+		t.Errorf("expected eviction by weight to be valid, got %v", err)
+	}
+	func() {
+		defer func() {
+			if err, _ := recover().(error); !errors.Is(err, ErrNotStrict) {
+				t.Errorf("expected AddWithTTL to panic with ErrNotStrict, got %v", err)
+			}
+		}()
+		x.AddWithTTL("d", time.Minute)
+	}()
+	defer func() {
+		if err, _ := recover().(error); !errors.Is(err, ErrNotStrict) {
+			t.Errorf("expected New to panic with ErrNotStrict, got %v", err)
+		}
+	}()
+	New(Config{Strict: true})
+}
@@ -0,0 +1,90 @@
+package consistent
+
+import "sort"
+
+// RoutingTable approximates a ring with a fixed number of buckets of the hash space, for
+// components such as nginx Lua scripts that cannot run this package but need to mostly
+// agree with it. The bucket of a key is hash(key) * len(Buckets) >> 32, computed in 64 bits,
+// and is owned by Members[Buckets[bucket]].
+type RoutingTable struct {
+	// Hash is the hash function of keys: "crc32" (IEEE), "fnv32a", "xxh32", "xxh64" (low 32
+	// bits), "murmur3", "ketama" (the first 4 bytes of the MD5 digest as a little endian
+	// integer, minus one, see Config.KetamaCompatible) or "custom".
+	Hash    string   `json:"hash"`
+	Members []string `json:"members"`
+	Buckets []int    `json:"buckets"`
+}
+
+// Owner returns the member owning the keys with hash h.
+func (t *RoutingTable) Owner(h uint32) string {
+	if len(t.Buckets) == 0 {
+		return ""
+	}
+	return t.Members[t.Buckets[uint64(h)*uint64(len(t.Buckets))>>32]]
+}
+
+// RoutingTable returns a table of buckets buckets, each owned by the member owning most of
+// its range of the circle. More buckets make a larger table agreeing with more keys. It
+// returns an empty table if the circle is empty or buckets < 1.
+func (c *Consistent) RoutingTable(buckets int) *RoutingTable {
+	c.RLock()
+	defer c.RUnlock()
+	t := &RoutingTable{Hash: "crc32"}
+	if c.ketama {
+		t.Hash = "ketama"
+	} else if c.customHasher != nil {
+		t.Hash = "custom"
+	} else if c.useFnv {
+		t.Hash = "fnv32a"
+	} else if c.algorithm != HashCRC32 {
+		t.Hash = c.algorithm.String()
+	}
+	if len(c.sortedHashes) == 0 || buckets < 1 {
+		return t
+	}
+
+	ids := make(map[string]int)
+	for _, h := range c.sortedHashes {
+		ids[c.circle[h]] = 0
+	}
+	for m := range ids {
+		t.Members = append(t.Members, m)
+	}
+	sort.Strings(t.Members)
+	for i, m := range t.Members {
+		ids[m] = i
+	}
+
+	t.Buckets = make([]int, buckets)
+	shares := make([]uint64, len(t.Members))
+	for b := range t.Buckets {
+		for i := range shares {
+			shares[i] = 0
+		}
+		pos := uint64(b) << 32 / uint64(buckets)
+		end := uint64(b+1) << 32 / uint64(buckets)
+		for pos < end {
+			// keys from pos up to the next hash are owned by that hash
+			i := sort.Search(len(c.sortedHashes), func(x int) bool { return uint64(c.sortedHashes[x]) > pos })
+			next := uint64(1) << 32
+			if i == len(c.sortedHashes) {
+				i = 0
+			} else {
+				next = uint64(c.sortedHashes[i])
+			}
+			if next > end {
+				next = end
+			}
+			shares[ids[c.circle[c.sortedHashes[i]]]] += next - pos
+			pos = next
+		}
+		best := 0
+		for i, s := range shares {
+			if s > shares[best] {
+				best = i
+			}
+		}
+		t.Buckets[b] = best
+	}
+	return t
+}
@@ -0,0 +1,48 @@
+package consistent
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+)
+
+func TestRoutingTable(t *testing.T) {
+	x := New(newConfig())
+	x.Set([]string{"a", "b", "c", "d"})
+	table := x.RoutingTable(4096)
+	if table.Hash != "crc32" || len(table.Members) != 4 || len(table.Buckets) != 4096 {
+		t.Fatalf("unexpected table %s %v %d", table.Hash, table.Members, len(table.Buckets))
+	}
+	agree := 0
+	const keys = 10000
+	for i := 0; i < keys; i++ {
+		k := "user" + strconv.Itoa(i)
+		m, _ := x.Get(k)
+		if table.Owner(hashKeyCRC32(k)) == m {
+			agree++
+		}
+	}
+	if agree < keys*98/100 {
+		t.Errorf("table agrees with the ring on %d of %d keys", agree, keys)
+	}
+
+	data, err := json.Marshal(x.RoutingTable(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back RoutingTable
+	if err := json.Unmarshal(data, &back); err != nil || len(back.Buckets) != 4 {
+		t.Errorf("got %+v, %v from %s", back, err, data)
+	}
+	if empty := New(newConfig()).RoutingTable(16); len(empty.Buckets) != 0 || empty.Owner(42) != "" {
+		t.Errorf("expected an empty table, got %+v", empty)
+	}
+}
+
+func TestRoutingTableKetama(t *testing.T) {
+	x := New(Config{KetamaCompatible: true})
+	x.Set([]string{"10.0.0.1:11211", "10.0.0.2:11211"})
+	if table := x.RoutingTable(16); table.Hash != "ketama" {
+		t.Errorf("got hash %q, expected ketama", table.Hash)
+	}
+}
@@ -0,0 +1,47 @@
+package consistent
+
+import "github.com/jiangz222/consistent/core"
+
+// TieBreak decides which member owns a point of the circle claimed by several members, as
+// happens on hash collisions. The other claimants keep their claim: when the owner leaves,
+// the point goes to the next winner rather than disappearing. It is core.TieBreak, so a
+// core.Ring set up with the same one agrees with the ring.
+type TieBreak = core.TieBreak
+
+const (
+	// TieBreakLastAdd, the default, gives the point to the claimant that joined last, as
+	// a member added to the ring always took over the points it collided on.
+	TieBreakLastAdd = core.TieBreakLastAdd
+	// TieBreakLexicographic gives the point to the claimant with the smallest name, so
+	// every process agrees on the owner whatever the order the members joined in.
+	TieBreakLexicographic = core.TieBreakLexicographic
+	// TieBreakWeight gives the point to the claimant with the most replicas, then the
+	// smallest name.
+	TieBreakWeight = core.TieBreakWeight
+	// TieBreakJoinTime gives the point to the claimant that joined first, then the
+	// smallest name. Processes agree as long as they apply the same changes in the same
+	// order; Restore adds members in order of name.
+	TieBreakJoinTime = core.TieBreakJoinTime
+)
+
+// need c.Lock() before calling
+// claim records that elt claims the point h, giving it to the winner of its claimants, and
+// reports whether h is a new point of the circle.
+func (c *Consistent) claim(h uint32, elt string) bool {
+	if c.contested == nil {
+		c.contested = make(map[uint32][]string)
+	}
+	return c.tieBreak.Claim(c.circle, c.contested, h, elt, c.claimant)
+}
+
+// need c.Lock() before calling
+// unclaim withdraws the claim of elt on the point h, giving it to the winner of the
+// remaining claimants, and reports whether h left the circle.
+func (c *Consistent) unclaim(h uint32, elt string) bool {
+	return c.tieBreak.Unclaim(c.circle, c.contested, h, elt, c.claimant)
+}
+
+// need c.RLock() before calling
+func (c *Consistent) claimant(elt string) core.Claimant {
+	return core.Claimant{Name: elt, Replicas: c.membersReplicas[elt], Joined: c.joined[elt]}
+}
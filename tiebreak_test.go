@@ -0,0 +1,114 @@
+package consistent
+
+import (
+	"hash/crc32"
+	"reflect"
+	"testing"
+)
+
+// collidingHasher hashes into 50 points, so members collide on most of their vnodes.
+type collidingHasher struct{}
+
+func (collidingHasher) HashFunc(key string) uint32 {
+	return crc32.ChecksumIEEE([]byte(key)) % 50 * 80000000
+}
+
+func TestTieBreak(t *testing.T) {
+	for _, tb := range []TieBreak{TieBreakLastAdd, TieBreakLexicographic, TieBreakWeight, TieBreakJoinTime} {
+		conf := Config{DefaultNumberOfReplicas: 20, CustomHasher: collidingHasher{}, TieBreak: tb}
+		x, y := New(conf), New(conf)
+		x.Add("a")
+		x.Add("b", 40)
+		x.Add("c")
+		y.Add("c")
+		y.Add("b", 40)
+		y.Add("a")
+		if len(x.contested) == 0 {
+			t.Fatal("expected collisions")
+		}
+		for h, claimants := range x.contested {
+			// a, b then c joined x; b has the most replicas
+			want := "c"
+			switch {
+			case tb == TieBreakLastAdd && sliceContainsMember(claimants, "c"):
+				want = "c"
+			case tb == TieBreakLastAdd:
+				want = "b"
+				if !sliceContainsMember(claimants, "b") {
+					want = "a"
+				}
+			case tb == TieBreakWeight && sliceContainsMember(claimants, "b"):
+				want = "b"
+			case sliceContainsMember(claimants, "a"):
+				want = "a"
+			case sliceContainsMember(claimants, "b"):
+				want = "b"
+			}
+			if x.circle[h] != want {
+				t.Errorf("%s: point %d claimed by %v owned by %s, expected %s", tb, h, claimants, x.circle[h], want)
+			}
+			if tb != TieBreakLastAdd && tb != TieBreakJoinTime && y.circle[h] != x.circle[h] {
+				t.Errorf("%s: point %d owned by %s and %s depending on the join order", tb, h, x.circle[h], y.circle[h])
+			}
+		}
+
+		// the points of a leaving owner go to the other claimants
+		x.Remove("a")
+		y.Remove("a")
+		for _, h := range x.sortedHashes {
+			if x.circle[h] == "a" {
+				t.Errorf("%s: point %d still owned by a removed member", tb, h)
+			}
+		}
+		for h := range x.contested {
+			if len(x.contested[h]) < 2 || sliceContainsMember(x.contested[h], "a") {
+				t.Errorf("%s: unexpected claimants %v", tb, x.contested[h])
+			}
+		}
+		z := New(conf)
+		z.Add("b", 40)
+		z.Add("c")
+		if len(z.sortedHashes) != len(x.sortedHashes) {
+			t.Fatalf("%s: %d points after removing a, expected %d", tb, len(x.sortedHashes), len(z.sortedHashes))
+		}
+		if tb == TieBreakLastAdd || tb == TieBreakJoinTime {
+			// b joined before c on x but after it on y
+			continue
+		}
+		for _, h := range z.sortedHashes {
+			if x.circle[h] != z.circle[h] || y.circle[h] != z.circle[h] {
+				t.Errorf("%s: point %d owned by %s and %s, expected %s", tb, h, x.circle[h], y.circle[h], z.circle[h])
+			}
+		}
+	}
+
+	x := New(Config{DefaultNumberOfReplicas: 20, CustomHasher: collidingHasher{}, TieBreak: TieBreakJoinTime})
+	x.Add("z")
+	x.Add("a")
+	for h, claimants := range x.contested {
+		if sliceContainsMember(claimants, "z") && x.circle[h] != "z" {
+			t.Errorf("point %d owned by %s, expected the first to join", h, x.circle[h])
+		}
+	}
+}
+
+func TestTieBreakDryRun(t *testing.T) {
+	// a dry run sees the points of a leaving owner go to the other claimants
+	for _, tb := range []TieBreak{TieBreakLastAdd, TieBreakLexicographic} {
+		x := New(Config{DefaultNumberOfReplicas: 20, CustomHasher: collidingHasher{}, TieBreak: tb})
+		x.Add("a")
+		x.Add("b")
+		x.Add("c")
+		before := x.Clone()
+		e := x.DryRun().Remove("c")
+		x.Remove("c")
+		if moved := movedShare(before.sortedHashes, before.circle, x.sortedHashes, x.circle); e.MovedShare != moved || e.Vnodes != len(x.sortedHashes) {
+			t.Errorf("%s: dry run moves %f over %d points, removing moved %f over %d", tb, e.MovedShare, e.Vnodes, moved, len(x.sortedHashes))
+		}
+		e = x.DryRun().Add("c")
+		x.Add("c")
+		if shares := ownershipShares(x.sortedHashes, x.circle); !reflect.DeepEqual(e.Shares, shares) {
+			t.Errorf("%s: dry run shares %v, adding gave %v", tb, e.Shares, shares)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package consistent
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// Partitions returns the names of the n partitions of topic, "topic-0" to "topic-<n-1>".
+func Partitions(topic string, n int) []string {
+	res := make([]string, n)
+	for i := range res {
+		res[i] = topic + "-" + strconv.Itoa(i)
+	}
+	return res
+}
+
+// RebalanceListener gets the partitions revoked from and assigned to each member when a
+// TopicRouter rebalances, like the rebalance listener of a Kafka consumer group: every
+// Revoked call of a rebalance comes before its Assigned calls, members in order of name and
+// partitions sorted. Either function may be nil.
+type RebalanceListener struct {
+	Revoked  func(member string, partitions []string)
+	Assigned func(member string, partitions []string)
+}
+
+// TopicRouter assigns the partitions of topics to the members of a ring, as consumers,
+// and rebalances them when members join or leave or partitions are added or removed, for
+// queue systems that need consumer group semantics.
+type TopicRouter struct {
+	c        *Consistent
+	listener RebalanceListener
+	cancel   func()
+
+	notify sync.Mutex // serializes rebalances and their callbacks
+	mu     sync.RWMutex
+	owners map[string]string // partition: member, "" while the ring is empty
+}
+
+// NewTopicRouter creates a TopicRouter over the members of c, reporting rebalances to l.
+// It has no partitions until AddPartitions is called. The callbacks of l run on the
+// goroutine changing the ring or the partitions; they may read the router but must not
+// change its partitions.
+func NewTopicRouter(c *Consistent, l RebalanceListener) *TopicRouter {
+	r := &TopicRouter{c: c, listener: l, owners: make(map[string]string)}
+	r.cancel = c.OnChange(func(ChangeEvent) { r.rebalance(nil, nil) })
+	return r
+}
+
+// Close stops following the changes of the ring.
+func (r *TopicRouter) Close() {
+	r.cancel()
+}
+
+// AddPartitions adds partitions, such as those returned by Partitions, and assigns them.
+func (r *TopicRouter) AddPartitions(partitions ...string) {
+	r.rebalance(partitions, nil)
+}
+
+// RemovePartitions removes partitions, revoking them from their members.
+func (r *TopicRouter) RemovePartitions(partitions ...string) {
+	r.rebalance(nil, partitions)
+}
+
+// Owner returns the member partition is assigned to, false if it is not a partition of r
+// or the ring is empty.
+func (r *TopicRouter) Owner(partition string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	m := r.owners[partition]
+	return m, m != ""
+}
+
+// Assignment returns the partitions assigned to member, sorted.
+func (r *TopicRouter) Assignment(member string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var res []string
+	for p, m := range r.owners {
+		if m == member {
+			res = append(res, p)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// rebalance adds and removes partitions, assigns every partition to its owner on the ring
+// and reports the differences to the listener.
+func (r *TopicRouter) rebalance(add, remove []string) {
+	r.notify.Lock()
+	defer r.notify.Unlock()
+
+	r.mu.Lock()
+	revoked := make(map[string][]string)
+	assigned := make(map[string][]string)
+	for _, p := range remove {
+		if m, ok := r.owners[p]; ok {
+			if m != "" {
+				revoked[m] = append(revoked[m], p)
+			}
+			delete(r.owners, p)
+		}
+	}
+	for _, p := range add {
+		if _, ok := r.owners[p]; !ok {
+			r.owners[p] = ""
+		}
+	}
+	r.c.RLock()
+	empty := len(r.c.circle) == 0
+	for p, old := range r.owners {
+		m := ""
+		if !empty {
+			m, _ = r.c.owner(p)
+		}
+		if m == old {
+			continue
+		}
+		if old != "" {
+			revoked[old] = append(revoked[old], p)
+		}
+		if m != "" {
+			assigned[m] = append(assigned[m], p)
+		}
+		r.owners[p] = m
+	}
+	r.c.RUnlock()
+	r.mu.Unlock()
+
+	reportRebalance(revoked, r.listener.Revoked)
+	reportRebalance(assigned, r.listener.Assigned)
+}
+
+// reportRebalance calls fn with the partitions of each member of changes, in order.
+func reportRebalance(changes map[string][]string, fn func(member string, partitions []string)) {
+	if fn == nil {
+		return
+	}
+	members := make([]string, 0, len(changes))
+	for m := range changes {
+		members = append(members, m)
+	}
+	sort.Strings(members)
+	for _, m := range members {
+		sort.Strings(changes[m])
+		fn(m, changes[m])
+	}
+}
@@ -0,0 +1,60 @@
+package consistent
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTopicRouter(t *testing.T) {
+	x := New(newConfig())
+	x.Add("abcdefg")
+	var log []string
+	held := make(map[string]string)
+	r := NewTopicRouter(x, RebalanceListener{
+		Revoked: func(member string, partitions []string) {
+			log = append(log, "revoked")
+			for _, p := range partitions {
+				if held[p] != member {
+					t.Errorf("%s revoked from %s, held by %s", p, member, held[p])
+				}
+				delete(held, p)
+			}
+		},
+		Assigned: func(member string, partitions []string) {
+			log = append(log, "assigned")
+			for _, p := range partitions {
+				if held[p] != "" {
+					t.Errorf("%s assigned to %s, still held by %s", p, member, held[p])
+				}
+				held[p] = member
+			}
+		},
+	})
+	defer r.Close()
+	r.AddPartitions(Partitions("orders", 32)...)
+	checkNum(len(held), 32, t)
+	checkNum(len(r.Assignment("abcdefg")), 32, t)
+
+	log = nil
+	x.Add("hijklmn")
+	if got := strings.Join(log, ","); got != "revoked,assigned" {
+		t.Errorf("got callbacks %s, expected revoked,assigned", got)
+	}
+	x.Add("opqrstu")
+	for p, m := range held {
+		if owner := mustGet(t, x, p); owner != m {
+			t.Errorf("%s held by %s, owned by %s", p, m, owner)
+		}
+		if o, ok := r.Owner(p); !ok || o != m {
+			t.Errorf("%s: Owner gives %s, held by %s", p, o, m)
+		}
+	}
+
+	r.RemovePartitions("orders-0", "orders-1")
+	checkNum(len(held), 30, t)
+	if _, ok := r.Owner("orders-0"); ok {
+		t.Errorf("removed partition still has an owner")
+	}
+	x.Set(nil)
+	checkNum(len(held), 0, t)
+}
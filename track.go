@@ -0,0 +1,43 @@
+package consistent
+
+import "sort"
+
+// Track adds keys to the index KeysOwnedBy reads, so services can enumerate the entities
+// they own after a change without keeping a parallel structure. The index only holds the
+// keys; their owners are looked up on each query.
+func (c *Consistent) Track(keys ...string) {
+	c.Lock()
+	defer c.Unlock()
+	if c.tracked == nil {
+		c.tracked = make(map[string]struct{}, len(keys))
+	}
+	for _, k := range keys {
+		c.tracked[k] = struct{}{}
+	}
+}
+
+// Untrack removes keys from the index of Track.
+func (c *Consistent) Untrack(keys ...string) {
+	c.Lock()
+	defer c.Unlock()
+	for _, k := range keys {
+		delete(c.tracked, k)
+	}
+}
+
+// KeysOwnedBy returns the tracked keys Get routes to member, sorted.
+func (c *Consistent) KeysOwnedBy(member string) []string {
+	c.RLock()
+	defer c.RUnlock()
+	if len(c.circle) == 0 || !c.members[member] {
+		return nil
+	}
+	var res []string
+	for k := range c.tracked {
+		if m, _ := c.owner(k); m == member {
+			res = append(res, k)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
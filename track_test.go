@@ -0,0 +1,34 @@
+package consistent
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestKeysOwnedBy(t *testing.T) {
+	x := New(newConfig())
+	x.Add("abcdefg")
+	x.Add("hijklmn")
+	for i := 0; i < 100; i++ {
+		x.Track("key" + strconv.Itoa(i))
+	}
+	x.Untrack("key0", "key1")
+	x.Add("opqrstu")
+	total := 0
+	for _, m := range x.Members() {
+		keys := x.KeysOwnedBy(m)
+		total += len(keys)
+		for _, k := range keys {
+			if k == "key0" || k == "key1" {
+				t.Errorf("untracked %s returned", k)
+			}
+			if owner := mustGet(t, x, k); owner != m {
+				t.Errorf("%s: owned by %s, returned for %s", k, owner, m)
+			}
+		}
+	}
+	checkNum(total, 98, t)
+	if keys := x.KeysOwnedBy("nobody"); keys != nil {
+		t.Errorf("got %q for a non-member", keys)
+	}
+}
@@ -0,0 +1,124 @@
+package consistent
+
+import (
+	"fmt"
+	"time"
+)
+
+// ttlTracker holds the deadlines of the members added with AddWithTTL. It is guarded by
+// the ring lock.
+type ttlTracker struct {
+	ttls      map[string]time.Duration
+	deadlines map[string]time.Time
+	timer     *time.Timer
+	next      time.Time // when timer fires
+	now       func() time.Time
+}
+
+// AddWithTTL adds elt like Add, then removes it automatically unless Touch is called for
+// it at least every ttl, for membership fed by heartbeats rather than explicit
+// deregistration. For a member already in the ring it only sets the ttl, starting now.
+// Expired members are removed regardless of Config.Guard and listed in the Expired field
+// of the ChangeEvent. It panics with an error wrapping ErrNotStrict on a ring created with
+// Config.Strict, as expiry depends on timing.
+func (c *Consistent) AddWithTTL(elt string, ttl time.Duration, numbersOfReplicas ...int) {
+	if c.strict {
+		panic(fmt.Errorf("%w: AddWithTTL expires members depending on timing", ErrNotStrict))
+	}
+	numberOfReplicas := c.defaultNumberOfReplicas
+	if len(numbersOfReplicas) > 0 {
+		numberOfReplicas = numbersOfReplicas[0]
+	}
+	c.warmupAdd(joiner{SetElt: SetElt{Elt: elt, NumberOfReplicas: numberOfReplicas}}, nil)
+	c.Lock()
+	defer c.unlockAndNotify()
+	if !c.members[elt] {
+		if !c.add(elt, numberOfReplicas) {
+			return
+		}
+	}
+	t := &c.ttl
+	if t.ttls == nil {
+		t.ttls = make(map[string]time.Duration)
+		t.deadlines = make(map[string]time.Time)
+	}
+	t.ttls[elt] = ttl
+	t.deadlines[elt] = t.clock().Add(ttl)
+	c.scheduleExpiry()
+}
+
+// Touch restarts the ttl of elt, also recording a Heartbeat for Config.MaxMembers. It
+// reports false if elt is not a member added with AddWithTTL.
+func (c *Consistent) Touch(elt string) bool {
+	c.Lock()
+	defer c.Unlock()
+	ttl, ok := c.ttl.ttls[elt]
+	if !ok {
+		return false
+	}
+	c.ttl.deadlines[elt] = c.ttl.clock().Add(ttl)
+	if c.capacity != nil {
+		c.capacity.heartbeats[elt] = c.capacity.now()
+	}
+	return true
+}
+
+// TTL returns the ttl elt was added with, false if it was not added with AddWithTTL.
+func (c *Consistent) TTL(elt string) (time.Duration, bool) {
+	c.RLock()
+	defer c.RUnlock()
+	ttl, ok := c.ttl.ttls[elt]
+	return ttl, ok
+}
+
+func (t *ttlTracker) clock() time.Time {
+	if t.now != nil {
+		return t.now()
+	}
+	return time.Now()
+}
+
+func (t *ttlTracker) forget(elt string) {
+	delete(t.ttls, elt)
+	delete(t.deadlines, elt)
+}
+
+// need c.Lock() before calling
+// scheduleExpiry makes sure the sweeper runs by the earliest deadline.
+func (c *Consistent) scheduleExpiry() {
+	t := &c.ttl
+	var first time.Time
+	for _, d := range t.deadlines {
+		if first.IsZero() || d.Before(first) {
+			first = d
+		}
+	}
+	if first.IsZero() || t.timer != nil && !t.next.After(first) {
+		return
+	}
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+	t.next = first
+	t.timer = time.AfterFunc(first.Sub(t.clock()), c.expire)
+}
+
+// expire removes the members whose deadline passed, and schedules the next sweep.
+func (c *Consistent) expire() {
+	c.Lock()
+	defer c.unlockAndNotify()
+	t := &c.ttl
+	t.timer = nil
+	now := t.clock()
+	for elt, d := range t.deadlines {
+		if d.After(now) {
+			continue
+		}
+		if c.members[elt] {
+			c.remove(elt, c.membersReplicas[elt])
+			c.changes.Expired = append(c.changes.Expired, elt)
+		}
+		t.forget(elt)
+	}
+	c.scheduleExpiry()
+}
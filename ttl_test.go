@@ -0,0 +1,70 @@
+package consistent
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAddWithTTL(t *testing.T) {
+	x := New(newConfig())
+	now := time.Unix(1000, 0)
+	x.ttl.now = func() time.Time { return now }
+	x.Add("static")
+	x.AddWithTTL("a", time.Minute)
+	x.AddWithTTL("b", time.Minute, 40)
+	if x.MemberReplicas()["b"] != 40 {
+		t.Errorf("unexpected replicas %v", x.MemberReplicas())
+	}
+	if ttl, ok := x.TTL("a"); !ok || ttl != time.Minute {
+		t.Errorf("got ttl %v, %v", ttl, ok)
+	}
+	if x.Touch("static") || x.Touch("nobody") {
+		t.Error("Touch succeeded for a member without ttl")
+	}
+	x.ttl.timer.Stop()
+
+	var events []ChangeEvent
+	x.OnChange(func(ev ChangeEvent) { events = append(events, ev) })
+	now = now.Add(40 * time.Second)
+	if !x.Touch("a") {
+		t.Error("Touch failed")
+	}
+	now = now.Add(30 * time.Second)
+	x.expire()
+	x.ttl.timer.Stop()
+	if len(events) != 1 || len(events[0].Expired) != 1 || events[0].Expired[0] != "b" || events[0].Removed[0] != "b" {
+		t.Fatalf("unexpected events %+v", events)
+	}
+	checkNum(len(x.Members()), 2, t)
+	now = now.Add(time.Minute)
+	x.expire()
+	checkNum(len(x.Members()), 1, t)
+	if x.ttl.timer != nil {
+		t.Error("sweeper scheduled without deadlines")
+	}
+
+	// a member removed explicitly is forgotten
+	x.AddWithTTL("c", time.Minute)
+	x.Remove("c")
+	x.Add("c")
+	if _, ok := x.TTL("c"); ok {
+		t.Error("ttl kept after Remove")
+	}
+}
+
+func TestTTLSweeper(t *testing.T) {
+	x := New(newConfig())
+	x.AddWithTTL("a", 20*time.Millisecond)
+	x.AddWithTTL("b", time.Hour)
+	expired := make(chan []string, 1)
+	x.OnChange(func(ev ChangeEvent) { expired <- ev.Expired })
+	select {
+	case e := <-expired:
+		if len(e) != 1 || e[0] != "a" {
+			t.Errorf("got %v expired, expected [a]", e)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("a did not expire")
+	}
+	x.Remove("b")
+}
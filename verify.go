@@ -0,0 +1,74 @@
+package consistent
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Verify checks the internal consistency of the ring: member counts, the circle, the sorted
+// hashes and their index. It returns nil for a healthy ring and is meant for tests and fuzz
+// targets, such as consistenttest.RunOps.
+func (c *Consistent) Verify() error {
+	c.RLock()
+	defer c.RUnlock()
+	if len(c.members) != len(c.membersReplicas) || int64(len(c.members)) != c.count {
+		return fmt.Errorf("consistent: %d members, %d replica counts, count %d", len(c.members), len(c.membersReplicas), c.count)
+	}
+	replicas := 0
+	for m := range c.members {
+		r, ok := c.membersReplicas[m]
+		if !ok {
+			return fmt.Errorf("consistent: member %q has no replica count", m)
+		}
+		replicas += r
+	}
+	if len(c.circle) > replicas {
+		return fmt.Errorf("consistent: %d points for %d replicas", len(c.circle), replicas)
+	}
+	for h, m := range c.circle {
+		if !c.members[m] {
+			return fmt.Errorf("consistent: point %d owned by non-member %q", h, m)
+		}
+	}
+	if !sort.IsSorted(c.sortedHashes) {
+		return fmt.Errorf("consistent: sorted hashes are not sorted")
+	}
+	// quarantined members are only routed to when every member is, whether or not
+	// Config.MaxShare left points out
+	routable := false
+	for _, m := range c.circle {
+		if !c.isQuarantined(m) {
+			routable = true
+			break
+		}
+	}
+	for i, h := range c.sortedHashes {
+		m, ok := c.circle[h]
+		if !ok {
+			return fmt.Errorf("consistent: sorted hash %d is not in the circle", h)
+		}
+		if i > 0 && h == c.sortedHashes[i-1] {
+			return fmt.Errorf("consistent: sorted hash %d is duplicated", h)
+		}
+		if routable && c.isQuarantined(m) {
+			return fmt.Errorf("consistent: quarantined member %q is routed to", m)
+		}
+	}
+	if len(c.sortedHashes) > len(c.circle) || (len(c.sortedHashes) < len(c.circle) && c.flaps == nil && c.maxShare == 0) {
+		return fmt.Errorf("consistent: %d sorted hashes for %d points", len(c.sortedHashes), len(c.circle))
+	}
+	if c.index.buckets != nil {
+		for _, h := range c.sortedHashes {
+			for _, key := range []uint32{h - 1, h, h + 1} {
+				want := sort.Search(len(c.sortedHashes), func(x int) bool { return c.sortedHashes[x] > key })
+				if want == len(c.sortedHashes) {
+					want = 0
+				}
+				if got := c.search(key); got != want {
+					return fmt.Errorf("consistent: index finds %d for %d instead of %d", got, key, want)
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package consistent
+
+import "testing"
+
+func TestVerify(t *testing.T) {
+	x := New(Config{DefaultNumberOfReplicas: 300})
+	for _, m := range []string{"a", "b", "c", "d"} {
+		x.Add(m)
+	}
+	if err := x.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	x.sortedHashes[0], x.sortedHashes[1] = x.sortedHashes[1], x.sortedHashes[0]
+	if x.Verify() == nil {
+		t.Errorf("expected unsorted hashes to be detected")
+	}
+	x.updateSortedHashes()
+	x.circle[x.sortedHashes[0]] = "ghost"
+	if x.Verify() == nil {
+		t.Errorf("expected a point owned by a non-member to be detected")
+	}
+	delete(x.circle, x.sortedHashes[0])
+	if x.Verify() == nil {
+		t.Errorf("expected a sorted hash missing from the circle to be detected")
+	}
+}
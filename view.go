@@ -0,0 +1,71 @@
+package consistent
+
+import "sort"
+
+// readView is an immutable copy of the routing state that Get reads without taking the
+// ring lock. Writers build a new one and swap it in; it is only published while Get can
+// answer from the circle alone, that is without groups, overrides, weighted mode or
+// members marked down. The members are always published, for the iterators of
+// AllMembers and AllMemberReplicas.
+type readView struct {
+	plain  bool // Get can use hashes and owners, otherwise it takes the lock
+	hashes uints
+	owners []string
+	index  bucketIndex
+
+	version  uint64
+	members  []string // sorted
+	replicas []int    // of members
+}
+
+// need c.Lock() before calling
+// publish swaps in a read view of the current routing state.
+func (c *Consistent) publish() {
+	v := &readView{plain: c.plain()}
+	if v.plain {
+		v.hashes = append(uints(nil), c.sortedHashes...)
+		v.owners = owners(v.hashes, c.circle)
+		v.index.build(v.hashes)
+	}
+	if old, ok := c.view.Load().(*readView); ok && old.version == c.stats.version {
+		// only the routing settings changed
+		v.members, v.replicas = old.members, old.replicas
+	} else {
+		v.members = make([]string, 0, len(c.members))
+		for m := range c.members {
+			v.members = append(v.members, m)
+		}
+		sort.Strings(v.members)
+		v.replicas = make([]int, len(v.members))
+		for i, m := range v.members {
+			v.replicas[i] = c.membersReplicas[m]
+		}
+	}
+	v.version = c.stats.version
+	c.view.Store(v)
+}
+
+// need c.Lock() before calling
+func (c *Consistent) unlockAndPublish() {
+	c.publish()
+	c.Unlock()
+}
+
+func (v *readView) owner(key uint32) string {
+	return v.owners[searchHashes(v.hashes, &v.index, key)]
+}
+
+// searchHashes returns the position of the first of the sorted hashes greater than key,
+// wrapping around to 0, using index if it was built.
+func searchHashes(hashes uints, index *bucketIndex, key uint32) int {
+	if index.buckets != nil {
+		return index.search(hashes, key)
+	}
+	i := sort.Search(len(hashes), func(x int) bool {
+		return hashes[x] > key
+	})
+	if i >= len(hashes) {
+		i = 0
+	}
+	return i
+}
@@ -0,0 +1,73 @@
+package consistent
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestReadViewConcurrent(t *testing.T) {
+	x := New(newConfig())
+	x.Add("base")
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 2000; j++ {
+				if _, err := x.Get("key" + strconv.Itoa(j)); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		m := "member" + strconv.Itoa(i%10)
+		if i%2 == 0 {
+			x.Add(m)
+		} else {
+			x.Remove(m)
+		}
+	}
+	wg.Wait()
+}
+
+func TestReadViewFollowsRouting(t *testing.T) {
+	x := New(newConfig())
+	x.Set([]string{"a", "b", "c"})
+	owner := mustGet(t, x, "ggg")
+	o := NewOverrides()
+	o.Pin("ggg", "zzz", 0, "")
+	x.SetOverrides(o)
+	x.Add("zzz")
+	if m := mustGet(t, x, "ggg"); m != "zzz" {
+		t.Errorf("got %s, expected the pin to apply", m)
+	}
+	x.SetOverrides(nil)
+	x.Remove("zzz")
+	if m := mustGet(t, x, "ggg"); m != owner {
+		t.Errorf("got %s, expected %s", m, owner)
+	}
+	x.Group("g", "ggg")
+	if m, _ := x.GroupOwner("g"); m != mustGet(t, x, "ggg") {
+		t.Errorf("expected the group to apply to Get")
+	}
+	x.Ungroup("g")
+	if m := mustGet(t, x, "ggg"); m != owner {
+		t.Errorf("got %s, expected %s after ungrouping", m, owner)
+	}
+}
+
+func BenchmarkGetParallel(b *testing.B) {
+	x := New(newConfig())
+	for i := 0; i < 100; i++ {
+		x.Add("member" + strconv.Itoa(i))
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			x.Get("ggg")
+		}
+	})
+}
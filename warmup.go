@@ -0,0 +1,69 @@
+package consistent
+
+// need c.RLock() before calling
+// warmupRanges returns the ranges of the hash space each member of added would take over
+// from the current members if those in removed left and those in added joined, From being
+// their current owner.
+func (c *Consistent) warmupRanges(removed []string, added []joiner) map[string][]MovedRange {
+	if len(c.sortedHashes) == 0 || len(added) == 0 {
+		return nil
+	}
+	circle, hashes := c.simulate(removed, added)
+	res := make(map[string][]MovedRange, len(added))
+	for _, r := range movedRanges(c.sortedHashes, owners(c.sortedHashes, c.circle), hashes, owners(hashes, circle)) {
+		if containsElt(added, r.To) {
+			res[r.To] = append(res[r.To], r)
+		}
+	}
+	return res
+}
+
+// warmup calls the Warmup function of the config, if any, before a change of membership,
+// with the ranges every member the change adds is about to take over. plan returns the
+// members the change removes and adds, and runs with the ring read-locked. warmup runs
+// without the ring lock, so the ring may change before the change is made: the ranges are
+// those of the ring as the change found it.
+func (c *Consistent) warmup(plan func() (removed []string, added []joiner)) {
+	if c.onWarmup == nil {
+		return
+	}
+	c.RLock()
+	removed, added := plan()
+	ranges := c.warmupRanges(removed, added)
+	c.RUnlock()
+	for _, v := range added {
+		if r := ranges[v.Elt]; len(r) > 0 {
+			c.onWarmup(v.Elt, r)
+		}
+	}
+}
+
+// warmupAdd calls warmup for a member joining as j unless it is a member already. replace,
+// if not nil, reports whether that member is removed and added back as j, and runs with
+// the ring read-locked.
+func (c *Consistent) warmupAdd(j joiner, replace func() bool) {
+	c.warmup(func() ([]string, []joiner) {
+		switch {
+		case !c.members[j.Elt]:
+			return nil, []joiner{j}
+		case replace != nil && replace():
+			return []string{j.Elt}, []joiner{j}
+		}
+		return nil, nil
+	})
+}
+
+// warmupSet calls warmup for c.SetWithReplicas(elts).
+func (c *Consistent) warmupSet(elts []SetElt) {
+	c.warmup(func() ([]string, []joiner) {
+		removed, added := c.setDiff(elts)
+		return removed, joiners(added)
+	})
+}
+
+// warmupRestore calls warmup for c.Restore(s).
+func (c *Consistent) warmupRestore(s Snapshot) {
+	c.warmup(func() ([]string, []joiner) {
+		return c.restorePlan(s)
+	})
+}
@@ -0,0 +1,124 @@
+package consistent
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func inMovedRange(r MovedRange, h uint32) bool {
+	if r.Start < r.End {
+		return h >= r.Start && h < r.End
+	}
+	return h >= r.Start || h < r.End
+}
+
+func TestWarmup(t *testing.T) {
+	var (
+		x      *Consistent
+		warmed []string
+		ranges []MovedRange
+	)
+	conf := newConfig()
+	conf.Warmup = func(member string, rs []MovedRange) {
+		if x.IsMember(member) {
+			t.Errorf("expected %s to join after its warmup", member)
+		}
+		warmed = append(warmed, member)
+		ranges = rs
+	}
+	x = New(conf)
+	x.Add("a")
+	checkNum(len(warmed), 0, t) // nothing to warm up from an empty ring
+	x.Add("b")
+
+	before := make(map[string]string)
+	for i := 0; i < 1000; i++ {
+		k := "key" + strconv.Itoa(i)
+		before[k] = mustGet(t, x, k)
+	}
+	x.Add("c")
+	if len(warmed) != 2 || warmed[1] != "c" {
+		t.Fatalf("warmed %v, expected [b c]", warmed)
+	}
+	if len(ranges) == 0 {
+		t.Fatal("expected ranges to warm up")
+	}
+	for k, old := range before {
+		h := x.HashKey(k)
+		in := false
+		for _, r := range ranges {
+			if inMovedRange(r, h) {
+				in = true
+				if r.From != old || r.To != "c" {
+					t.Errorf("range %+v of %s, owned by %s", r, k, old)
+				}
+			}
+		}
+		if now := mustGet(t, x, k); (now == "c") != in {
+			t.Errorf("%s owned by %s, in warmed ranges: %v", k, now, in)
+		}
+	}
+
+	x.Add("c")
+	checkNum(len(warmed), 2, t)
+}
+
+func TestWarmupAddPaths(t *testing.T) {
+	salted := Snapshot{Members: []SnapshotMember{{Name: "a", Replicas: 60}, {Name: "b", Replicas: 20}, {Name: "c", Replicas: 20, Salt: "gen2"}}}
+	paths := map[string]func(x *Consistent){
+		"AddWithSalt":   func(x *Consistent) { x.AddWithSalt("c", "gen2") },
+		"AddWithHasher": func(x *Consistent) { x.AddWithHasher("c", fnvHasher{}) },
+		"AddWithMeta":   func(x *Consistent) { x.AddWithMeta("c", 1) },
+		"AddWithZone":   func(x *Consistent) { x.AddWithZone("c", "z1") },
+		"AddWeighted":   func(x *Consistent) { x.AddWeighted("c", 2) },
+		"AddWithTTL":    func(x *Consistent) { x.AddWithTTL("c", time.Hour) },
+		"Set":           func(x *Consistent) { x.Set([]string{"a", "b", "c"}) },
+		"Restore":       func(x *Consistent) { x.Restore(salted) },
+		"ImportMembers": func(x *Consistent) {
+			x.ImportMembers(MemberExport{Members: []ExportedMember{{Name: "a", Replicas: 60}, {Name: "b"}, {Name: "c", Salt: "gen2"}}})
+		},
+		"ApplyDelta": func(x *Consistent) { x.ApplyDelta(Delta{Added: salted.Members[2:]}) },
+	}
+	for name, add := range paths {
+		for _, maxShare := range []float64{0, 0.4} {
+			var (
+				x      *Consistent
+				ranges []MovedRange
+			)
+			conf := newConfig()
+			conf.MaxShare = maxShare
+			conf.Warmup = func(member string, rs []MovedRange) {
+				if x.MemberCount() < 2 {
+					return
+				}
+				if member != "c" {
+					t.Errorf("%s: warmed %s", name, member)
+				}
+				ranges = rs
+			}
+			x = New(conf)
+			x.Add("a", 60)
+			x.Add("b")
+			ranges = nil
+			before := make(map[string]string)
+			for i := 0; i < 1000; i++ {
+				k := "key" + strconv.Itoa(i)
+				before[k] = mustGet(t, x, k)
+			}
+			add(x)
+			if len(ranges) == 0 {
+				t.Fatalf("%s: expected ranges to warm up", name)
+			}
+			for k, old := range before {
+				in := false
+				for _, r := range ranges {
+					in = in || inMovedRange(r, x.HashKey(k))
+				}
+				if now := mustGet(t, x, k); (now == "c" && old != "c") != in {
+					t.Errorf("%s, MaxShare %v: %s owned by %s then %s, in warmed ranges: %v", name, maxShare, k, old, now, in)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package consistent
+
+import (
+	"math"
+	"sort"
+)
+
+type weightedMember struct {
+	name   string
+	hash   uint32
+	weight float64
+}
+
+// need c.Lock() before calling
+func (c *Consistent) updateWeighted() {
+	c.weighted = c.weighted[:0]
+	for m, r := range c.membersReplicas {
+		if c.isQuarantined(m) {
+			continue
+		}
+		c.weighted = append(c.weighted, weightedMember{name: m, hash: c.memberHash(m), weight: float64(r)})
+	}
+	if len(c.weighted) == 0 {
+		// everything is quarantined: route to the quarantined members rather than nowhere
+		for m, r := range c.membersReplicas {
+			c.weighted = append(c.weighted, weightedMember{name: m, hash: c.memberHash(m), weight: float64(r)})
+		}
+	}
+	sort.Slice(c.weighted, func(i, j int) bool { return c.weighted[i].name < c.weighted[j].name })
+}
+
+// weightedScore is the weighted rendezvous score of a member for the key hash: -w/ln(u)
+// where u is uniform in (0, 1) and derived from both hashes. The member with the highest
+// score owns the key.
+func weightedScore(key uint32, m weightedMember) float64 {
+	z := splitmix64(uint64(key)<<32 | uint64(m.hash))
+	u := (float64(z>>11) + 0.5) / (1 << 53)
+	return -m.weight / math.Log(u)
+}
+
+// need c.RLock() before calling
+// getWeightedHash ranks the members for the key hash key, with pin first if not "", and
+// returns the first n, all of them if n < 1, skipping those excluded.
+func (c *Consistent) getWeightedHash(key uint32, n int, pin string, excluded func(string) bool) []string {
+	if n < 1 || n > len(c.weighted) {
+		n = len(c.weighted)
+	}
+	type scored struct {
+		name  string
+		score float64
+	}
+	ranked := make([]scored, 0, len(c.weighted))
+	for _, m := range c.weighted {
+		if m.name == pin || m.weight <= 0 || (excluded != nil && excluded(m.name)) {
+			continue
+		}
+		ranked = append(ranked, scored{m.name, weightedScore(key, m)})
+	}
+	sort.Slice(ranked, func(i, j int) bool { return ranked[i].score > ranked[j].score })
+	res := make([]string, 0, n)
+	if pin != "" {
+		res = append(res, pin)
+	}
+	for _, r := range ranked {
+		if len(res) == n {
+			break
+		}
+		res = append(res, r.name)
+	}
+	return res
+}
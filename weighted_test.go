@@ -0,0 +1,70 @@
+package consistent
+
+import (
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestWeightedRendezvousDistribution(t *testing.T) {
+	x := New(Config{DefaultNumberOfReplicas: 20, WeightedRendezvous: true})
+	x.Add("small", 10)
+	x.Add("large", 30)
+	dist := make(map[string]int)
+	const keys = 20000
+	for i := 0; i < keys; i++ {
+		m, err := x.Get("user" + strconv.Itoa(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		dist[m]++
+	}
+	share := float64(dist["large"]) / keys
+	if math.Abs(share-0.75) > 0.02 {
+		t.Errorf("large got %.3f of the keys, expected about 0.75", share)
+	}
+}
+
+func TestWeightedRendezvousStickiness(t *testing.T) {
+	x := New(Config{DefaultNumberOfReplicas: 20, WeightedRendezvous: true})
+	x.Add("a", 20)
+	x.Add("b", 20)
+	x.Add("c", 20)
+	before := make(map[string]string)
+	for i := 0; i < 5000; i++ {
+		k := "user" + strconv.Itoa(i)
+		before[k], _ = x.Get(k)
+	}
+	x.Remove("c")
+	x.Add("c", 22)
+	for k, m := range before {
+		after, _ := x.Get(k)
+		if after != m && after != "c" {
+			t.Fatalf("%s moved from %q to %q when only c gained weight", k, m, after)
+		}
+	}
+}
+
+func TestWeightedRendezvousGetN(t *testing.T) {
+	x := New(Config{DefaultNumberOfReplicas: 20, WeightedRendezvous: true})
+	x.Add("a")
+	x.Add("b")
+	x.Add("c")
+	members, err := x.GetN("user1", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkNum(len(members), 3, t)
+	first, _ := x.Get("user1")
+	a, b, _ := x.GetTwo("user1")
+	if members[0] != first || a != first || b != members[1] {
+		t.Errorf("inconsistent results: %q, %q, %q %q", members, first, a, b)
+	}
+
+	o := NewOverrides()
+	x.SetOverrides(o)
+	o.Exclude("user1", first, 0, "")
+	if m, _ := x.Get("user1"); m != members[1] {
+		t.Errorf("got %q, expected exclusion to fall through to %q", m, members[1])
+	}
+}
@@ -0,0 +1,44 @@
+package consistent
+
+import "math"
+
+// AddWeighted inserts elt with a number of replicas proportional to weight, a weight of 1
+// getting DefaultNumberOfReplicas. Each member's replicas only depend on its own weight, so
+// the capacity ratios between members hold however many members come and go, and no
+// member is remapped when another one is added. Weights are rounded to whole replicas,
+// with at least one for a positive weight; DefaultNumberOfReplicas sets the precision. If
+// elt is already a member with another number of replicas, it is re-added with the new one.
+// A weight <= 0 does nothing.
+func (c *Consistent) AddWeighted(elt string, weight float64) {
+	if weight <= 0 || math.IsNaN(weight) {
+		return
+	}
+	replicas := int(math.Round(weight * float64(c.defaultNumberOfReplicas)))
+	if replicas < 1 {
+		replicas = 1
+	}
+	c.warmupAdd(joiner{SetElt: SetElt{Elt: elt, NumberOfReplicas: replicas}}, func() bool {
+		return c.membersReplicas[elt] != replicas
+	})
+	c.Lock()
+	defer c.unlockAndNotify()
+	if c.members[elt] {
+		if c.membersReplicas[elt] == replicas {
+			return
+		}
+		c.remove(elt, c.membersReplicas[elt])
+	}
+	c.add(elt, replicas)
+}
+
+// Weights returns the weight of every member: its replicas divided by
+// DefaultNumberOfReplicas.
+func (c *Consistent) Weights() map[string]float64 {
+	c.RLock()
+	defer c.RUnlock()
+	res := make(map[string]float64, len(c.membersReplicas))
+	for m, r := range c.membersReplicas {
+		res[m] = float64(r) / float64(c.defaultNumberOfReplicas)
+	}
+	return res
+}
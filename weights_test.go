@@ -0,0 +1,38 @@
+package consistent
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestAddWeighted(t *testing.T) {
+	x := New(Config{DefaultNumberOfReplicas: 100})
+	x.AddWeighted("small", 0.5)
+	x.AddWeighted("large", 1.5)
+	x.AddWeighted("tiny", 0.001)
+	x.AddWeighted("none", 0)
+	r := x.MemberReplicas()
+	if r["small"] != 50 || r["large"] != 150 || r["tiny"] != 1 || len(r) != 3 {
+		t.Errorf("unexpected replicas %v", r)
+	}
+	if w := x.Weights(); w["large"] != 1.5 {
+		t.Errorf("unexpected weights %v", w)
+	}
+
+	before := make(map[string]string)
+	for i := 0; i < 2000; i++ {
+		k := "user" + strconv.Itoa(i)
+		before[k], _ = x.Get(k)
+	}
+	x.AddWeighted("new", 1)
+	checkNum(x.MemberReplicas()["large"], 150, t)
+	for k, m := range before {
+		if now, _ := x.Get(k); now != m && now != "new" {
+			t.Errorf("%s moved from %s to %s", k, m, now)
+		}
+	}
+
+	x.AddWeighted("small", 0.5)
+	x.AddWeighted("small", 2)
+	checkNum(x.MemberReplicas()["small"], 200, t)
+}
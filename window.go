@@ -0,0 +1,26 @@
+package consistent
+
+import (
+	"strconv"
+	"time"
+)
+
+// GetForWindow returns the owner of key during the time window of length window that t
+// falls in. Within a window the owner only changes with the membership, while every new
+// window reshuffles the keys, e.g. to spread periodic jobs over the members instead of
+// always running the same job on the same member. A window <= 0 is the same as Get(key).
+func (c *Consistent) GetForWindow(key string, t time.Time, window time.Duration) (string, error) {
+	if window <= 0 {
+		return c.Get(key)
+	}
+	return c.Get(windowKey(key, t, window))
+}
+
+// windowKey is the key looked up for key in the window t falls in.
+func windowKey(key string, t time.Time, window time.Duration) string {
+	bucket := t.UnixNano() / int64(window)
+	if t.UnixNano() < 0 && t.UnixNano()%int64(window) != 0 {
+		bucket--
+	}
+	return key + "@" + strconv.FormatInt(bucket, 10)
+}
@@ -0,0 +1,37 @@
+package consistent
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestGetForWindow(t *testing.T) {
+	x := New(newConfig())
+	x.Set([]string{"a", "b", "c"})
+	start := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	moved := 0
+	for i := 0; i < 300; i++ {
+		k := "job" + strconv.Itoa(i)
+		first, _ := x.GetForWindow(k, start, time.Hour)
+		same, _ := x.GetForWindow(k, start.Add(59*time.Minute), time.Hour)
+		next, _ := x.GetForWindow(k, start.Add(time.Hour), time.Hour)
+		if first != same {
+			t.Errorf("%s moved within a window", k)
+		}
+		if first != next {
+			moved++
+		}
+	}
+	if moved < 100 {
+		t.Errorf("only %d keys moved between windows", moved)
+	}
+	a, _ := x.GetForWindow("job", start, 0)
+	b, _ := x.Get("job")
+	if a != b {
+		t.Errorf("expected a zero window to use the key as is")
+	}
+	if windowKey("k", time.Unix(0, -1), time.Second) != "k@-1" {
+		t.Errorf("unexpected window before 1970: %s", windowKey("k", time.Unix(0, -1), time.Second))
+	}
+}
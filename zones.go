@@ -0,0 +1,68 @@
+package consistent
+
+// AddWithZone inserts elt like Add and places it in zone, such as an availability zone or
+// a rack, for GetNDistinctZones. If elt is already a member only its zone is replaced.
+// Snapshots do not record zones.
+func (c *Consistent) AddWithZone(elt, zone string, numbersOfReplicas ...int) {
+	numberOfReplicas := c.defaultNumberOfReplicas
+	if len(numbersOfReplicas) > 0 {
+		numberOfReplicas = numbersOfReplicas[0]
+	}
+	c.warmupAdd(joiner{SetElt: SetElt{Elt: elt, NumberOfReplicas: numberOfReplicas}}, nil)
+	c.Lock()
+	defer c.unlockAndNotify()
+	if c.zones == nil {
+		c.zones = make(map[string]string)
+	}
+	c.zones[elt] = zone
+	if c.members[elt] {
+		return
+	}
+	c.add(elt, numberOfReplicas)
+}
+
+// Zone returns the zone elt was added with, "" if none.
+func (c *Consistent) Zone(elt string) string {
+	c.RLock()
+	defer c.RUnlock()
+	return c.zones[elt]
+}
+
+// GetNDistinctZones returns up to n owners of name like GetN, skipping those in the zone
+// of an owner already returned, so replicas placed on them survive the loss of a zone.
+// Members added without a zone count as alone in theirs. It returns fewer than n owners
+// when there are fewer zones.
+func (c *Consistent) GetNDistinctZones(name string, n int) ([]string, error) {
+	c.RLock()
+	defer c.RUnlock()
+	if len(c.circle) == 0 {
+		c.stats.lookup(ErrEmptyCircle)
+		return nil, ErrEmptyCircle
+	}
+	all, _, err := c.lookup(name, len(c.members), 0, nil)
+	c.stats.lookup(err)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	res := make([]string, 0, n)
+	for _, m := range all {
+		if len(res) >= n {
+			break
+		}
+		z, ok := c.zones[m]
+		if !ok || z == "" {
+			// a member without a zone is alone in its own
+			z = "\x00" + m
+		}
+		if seen[z] {
+			continue
+		}
+		seen[z] = true
+		res = append(res, m)
+	}
+	if c.rates != nil && len(res) > 0 {
+		c.rates.record(res[0])
+	}
+	return res, nil
+}
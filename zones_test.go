@@ -0,0 +1,47 @@
+package consistent
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestGetNDistinctZones(t *testing.T) {
+	x := New(newConfig())
+	zones := map[string]string{
+		"a1": "us-east-1a", "a2": "us-east-1a", "a3": "us-east-1a",
+		"b1": "us-east-1b", "b2": "us-east-1b",
+		"c1": "us-east-1c",
+	}
+	for m, z := range zones {
+		x.AddWithZone(m, z)
+	}
+	x.Add("nozone")
+	for i := 0; i < 200; i++ {
+		key := "key" + strconv.Itoa(i)
+		res, err := x.GetNDistinctZones(key, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkNum(len(res), 3, t)
+		if first := mustGet(t, x, key); res[0] != first {
+			t.Errorf("%s: first owner %s, Get gives %s", key, res[0], first)
+		}
+		seen := make(map[string]bool)
+		for _, m := range res {
+			z := x.Zone(m)
+			if z == "" {
+				z = m
+			}
+			if seen[z] {
+				t.Errorf("%s: %q has two owners in %s", key, res, z)
+			}
+			seen[z] = true
+		}
+	}
+	res, _ := x.GetNDistinctZones("key", 10)
+	checkNum(len(res), 4, t)
+	x.Remove("c1")
+	if x.Zone("c1") != "" {
+		t.Errorf("zone of a removed member kept")
+	}
+}